@@ -0,0 +1,45 @@
+// The age colour model gives every living cell a colour of the gradient
+// palette indexed by the number of consecutive generations it has been alive,
+// so that newborn cells are easily told apart from old still lifes and
+// oscillators
+
+package conway
+
+import "fmt"
+
+// AgeColorModel
+// ----------------------------------------------------------------------------
+
+// type
+
+// The age colour model gives newborn cells the first colour of living cells
+// and cells which have been alive for Maturity or more generations the last
+// one, i.e., color index 255, and all ages in between are spread evenly
+// across the gradient palette
+type AgeColorModel struct {
+	Maturity int
+}
+
+// Functions
+
+// Return a new age colour model where cells reach the last colour after the
+// given number of generations, along with an error if it is not strictly
+// positive
+func NewAgeColorModel(maturity int) (AgeColorModel, error) {
+
+	if maturity < 1 {
+		return AgeColorModel{}, fmt.Errorf("The maturity %v must be strictly positive", maturity)
+	}
+	return AgeColorModel{Maturity: maturity}, nil
+}
+
+// methods
+
+// Return the color index of the given living cell according to its age
+func (model AgeColorModel) CellColor(cell Cell) uint8 {
+
+	if cell.Age >= model.Maturity || model.Maturity <= 1 {
+		return 255
+	}
+	return uint8(1 + (cell.Age-1)*254/(model.Maturity-1))
+}
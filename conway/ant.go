@@ -0,0 +1,161 @@
+// Langton's Ant is a two-dimensional Turing machine where an ant moves over a
+// grid of cells, turning according to the colour of the cell it stands on and
+// changing it afterwards. Generalized ants (turmites) are given by a sequence
+// of turns, one per colour, so that Langton's Ant is given by "RL". Any number
+// of ants can move simultaneously over the same grid
+
+package conway
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Directions an ant can face
+const (
+	North = iota
+	East
+	South
+	West
+)
+
+// Ant
+// ----------------------------------------------------------------------------
+
+// type
+
+// An ant is given by its location on the grid and the direction it faces
+type Ant struct {
+	Position  image.Point
+	Direction int
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return a palette for ants moving over a grid with the given number of
+// colours. The first one is always black, and the others are evenly
+// distributed over the hue wheel
+func AntPalette(n int) color.Palette {
+
+	palette := color.Palette{color.RGBA{0x00, 0x00, 0x00, 0xff}}
+	for i := 1; i < n; i++ {
+
+		// compute the RGB components of a fully saturated color with the
+		// given hue
+		hue := 6.0 * float64(i-1) / float64(n-1)
+		x := uint8(255.0 * (1 - math.Abs(math.Mod(hue, 2)-1)))
+		switch int(hue) {
+		case 0:
+			palette = append(palette, color.RGBA{0xff, x, 0x00, 0xff})
+		case 1:
+			palette = append(palette, color.RGBA{x, 0xff, 0x00, 0xff})
+		case 2:
+			palette = append(palette, color.RGBA{0x00, 0xff, x, 0xff})
+		case 3:
+			palette = append(palette, color.RGBA{0x00, x, 0xff, 0xff})
+		case 4:
+			palette = append(palette, color.RGBA{x, 0x00, 0xff, 0xff})
+		default:
+			palette = append(palette, color.RGBA{0xff, 0x00, x, 0xff})
+		}
+	}
+	return palette
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the ants moving over this generation and the turns they make. Turns are
+// given as a string with one character per colour: 'R' (turn right), 'L'
+// (turn left), 'N' (no turn) or 'U' (u-turn). An ant standing on a cell with
+// colour i makes the i-th turn, changes the colour of the cell to the next one
+// (modulo the number of turns) and moves forward. An error is returned if the
+// turns are not valid or any ant is located off the grid
+func (g *generation) SetAnts(ants []Ant, turns string) error {
+
+	// verify the turns
+	if len(turns) < 2 || len(turns) > len(g.img.Palette) {
+		return errors.New("The number of turns must be at least 2 and no more than the number of colours")
+	}
+	for _, turn := range turns {
+		if turn != 'R' && turn != 'L' && turn != 'N' && turn != 'U' {
+			return errors.New("Turns must be given with the characters R, L, N and U")
+		}
+	}
+
+	// and the ants
+	for _, ant := range ants {
+		width, height := g.dimensions()
+		if ant.Position.X < 0 || ant.Position.X >= width ||
+			ant.Position.Y < 0 || ant.Position.Y >= height {
+			return errors.New("Ants must be located within the grid")
+		}
+		if ant.Direction < North || ant.Direction > West {
+			return errors.New("Unknown direction of an ant")
+		}
+	}
+
+	g.ants = append([]Ant(nil), ants...)
+	g.turns = turns
+	return nil
+}
+
+// Return the ants moving over this generation
+func (g *generation) Ants() []Ant {
+	return append([]Ant(nil), g.ants...)
+}
+
+// Return the next generation of a turmite. Ants move one after the other, and
+// those walking off the grid appear on the opposite side
+func (g *generation) nextAnt() *generation {
+
+	// create a new generation with the same features than this one, including
+	// the ants, and copy the colours of all cells
+	next := g.empty(1 + g.nbgeneration)
+	next.SetStates(g.States())
+
+	// compute the dimensions of the grid
+	width, height := g.dimensions()
+
+	// and now move all ants
+	for i := range next.ants {
+		ant := &next.ants[i]
+
+		// turn according to the colour of the current cell
+		c := next.ColorIndexAt(ant.Position.X, ant.Position.Y)
+		switch next.turns[int(c)%len(next.turns)] {
+		case 'R':
+			ant.Direction = (ant.Direction + 1) % 4
+		case 'L':
+			ant.Direction = (ant.Direction + 3) % 4
+		case 'U':
+			ant.Direction = (ant.Direction + 2) % 4
+		}
+
+		// change the colour of the current cell
+		next.SetColorIndex(ant.Position.X, ant.Position.Y, uint8((int(c)+1)%len(next.turns)))
+
+		// and move forward. Note that the y axis grows downwards
+		switch ant.Direction {
+		case North:
+			ant.Position.Y = (ant.Position.Y + height - 1) % height
+		case East:
+			ant.Position.X = (ant.Position.X + 1) % width
+		case South:
+			ant.Position.Y = (ant.Position.Y + 1) % height
+		case West:
+			ant.Position.X = (ant.Position.X + width - 1) % width
+		}
+	}
+
+	// and return the next generation
+	return next
+}
@@ -0,0 +1,100 @@
+// Apgcodes are the compact encoding of objects used by apgsearch and
+// Catagolue, e.g., xq4_153 for the glider. They consist of a prefix with the
+// kind of object (xs for still lifes, xp for oscillators and xq for
+// spaceships) and either its population or its period, followed by its cells
+// in the extended Wechsler format: the object is split into strips five cells
+// high, every strip is encoded column by column with a character in the range
+// 0-9a-v whose bits are the cells of the column from top to bottom, 'w' and
+// 'x' stand for two and three empty columns, 'y' followed by another
+// character stands for four to thirty nine empty columns and 'z' separates
+// strips
+
+package conway
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return the value of the given character in the range 0-9a-z, or -1 if it is
+// not in the range
+func wechslerValue(c rune) int {
+
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'z':
+		return 10 + int(c-'a')
+	}
+	return -1
+}
+
+// Return true if the given string is an apgcode, i.e., it starts with xs, xp or
+// xq followed by a number and an underscore
+func IsApgcode(code string) bool {
+
+	prefix, _, found := strings.Cut(code, "_")
+	if !found || len(prefix) < 3 ||
+		(!strings.HasPrefix(prefix, "xs") && !strings.HasPrefix(prefix, "xp") && !strings.HasPrefix(prefix, "xq")) {
+		return false
+	}
+	_, err := strconv.Atoi(prefix[2:])
+	return err == nil
+}
+
+// Return the pattern encoded by the given apgcode, whose name is the apgcode
+// itself, along with an error if it is not well formed. The population of
+// still lifes is checked against the one given in the prefix
+func DecodeApgcode(code string) (Pattern, error) {
+
+	if !IsApgcode(code) {
+		return Pattern{}, fmt.Errorf("'%v' is not an apgcode", code)
+	}
+	prefix, wechsler, _ := strings.Cut(code, "_")
+	if wechsler == "" {
+		return Pattern{}, fmt.Errorf("The apgcode '%v' has no cells", code)
+	}
+
+	var cells []image.Point
+	x, y := 0, 0
+	runes := []rune(wechsler)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case c == 'w':
+			x += 2
+		case c == 'x':
+			x += 3
+		case c == 'y':
+			if i++; i >= len(runes) || wechslerValue(runes[i]) < 0 {
+				return Pattern{}, fmt.Errorf("Missing number of empty columns after 'y' in the apgcode '%v'", code)
+			}
+			x += 4 + wechslerValue(runes[i])
+		case c == 'z':
+			x, y = 0, y+5
+		case wechslerValue(c) >= 0 && wechslerValue(c) < 32:
+			for bit := 0; bit < 5; bit++ {
+				if wechslerValue(c)&(1<<bit) != 0 {
+					cells = append(cells, image.Point{X: x, Y: y + bit})
+				}
+			}
+			x++
+		default:
+			return Pattern{}, fmt.Errorf("Unexpected character '%c' in the apgcode '%v'", c, code)
+		}
+	}
+	if len(cells) == 0 {
+		return Pattern{}, fmt.Errorf("The apgcode '%v' has no cells", code)
+	}
+	if population, _ := strconv.Atoi(prefix[2:]); strings.HasPrefix(prefix, "xs") && population != len(cells) {
+		return Pattern{}, fmt.Errorf("The apgcode '%v' has %v cells instead of %v", code, len(cells), population)
+	}
+
+	pattern := NewPattern(cells)
+	pattern.Name = code
+	return pattern, nil
+}
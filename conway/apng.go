@@ -0,0 +1,188 @@
+// Animations can also be written as animated PNG files (APNG), which are not
+// limited to the palette of 256 colours of GIF files and are shown crisp by
+// modern browsers. Every frame of the animation is composited over the
+// previous ones as GIF viewers do, and it is written in full colour with an
+// alpha channel, so that the result looks exactly the same as the GIF file
+
+package conway
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/gif"
+	"io"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// signature of all PNG files
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// write a chunk with the given type and data to the given writer
+func writePNGChunk(w io.Writer, kind string, data []byte) error {
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := binary.BigEndian.AppendUint32(nil, crc.Sum32())
+
+	for _, block := range [][]byte{header, data, footer} {
+		if _, err := w.Write(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// return the absolute value of the given byte interpreted as a signed number
+func absByte(b byte) int {
+
+	if b >= 128 {
+		return 256 - int(b)
+	}
+	return int(b)
+}
+
+// return the predictor of Paeth for the given bytes to the left (a), above (b)
+// and to the upper left (c)
+func paeth(a, b, c byte) byte {
+
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+// return the image data of the given image in RGBA with 8 bits per channel
+// compressed with zlib. Every row is filtered with the filter which minimizes
+// the sum of the absolute differences, as suggested by the specification
+func pngImageData(img *image.NRGBA) ([]byte, error) {
+
+	var buffer bytes.Buffer
+	z := zlib.NewWriter(&buffer)
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	stride := 4 * width
+	previous := make([]byte, stride)
+	filtered := make([][]byte, 5)
+	for i := range filtered {
+		filtered[i] = make([]byte, 1+stride)
+		filtered[i][0] = byte(i)
+	}
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+stride]
+		for x := 0; x < stride; x++ {
+			var left, upleft byte
+			if x >= 4 {
+				left, upleft = row[x-4], previous[x-4]
+			}
+			filtered[0][1+x] = row[x]
+			filtered[1][1+x] = row[x] - left
+			filtered[2][1+x] = row[x] - previous[x]
+			filtered[3][1+x] = row[x] - byte((int(left)+int(previous[x]))/2)
+			filtered[4][1+x] = row[x] - paeth(left, previous[x], upleft)
+		}
+
+		best, bestsum := 0, -1
+		for i, candidate := range filtered {
+			sum := 0
+			for _, b := range candidate[1:] {
+				sum += absByte(b)
+			}
+			if bestsum < 0 || sum < bestsum {
+				best, bestsum = i, sum
+			}
+		}
+		if _, err := z.Write(filtered[best]); err != nil {
+			return nil, err
+		}
+		copy(previous, row)
+	}
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Write the given animation to the given writer as an animated PNG, with the
+// same delays and number of loops. Frames are composited with ComposeGIF, so
+// that every frame of the animated PNG is complete
+func EncodeAPNG(w io.Writer, anim *gif.GIF) error {
+
+	if len(anim.Image) == 0 {
+		return errors.New("Animations must have at least one frame")
+	}
+	width, height := composedDimensions(anim)
+	if _, err := io.WriteString(w, pngSignature); err != nil {
+		return err
+	}
+	ihdr := binary.BigEndian.AppendUint32(nil, uint32(width))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(height))
+	ihdr = append(ihdr, 8, 6, 0, 0, 0)
+	if err := writePNGChunk(w, "IHDR", ihdr); err != nil {
+		return err
+	}
+
+	// GIF files loop forever with 0 and play once with -1, whereas animated
+	// PNG files loop forever with 0 and play as many times as requested
+	plays := 0
+	if anim.LoopCount < 0 {
+		plays = 1
+	} else if anim.LoopCount > 0 {
+		plays = 1 + anim.LoopCount
+	}
+	actl := binary.BigEndian.AppendUint32(nil, uint32(len(anim.Image)))
+	actl = binary.BigEndian.AppendUint32(actl, uint32(plays))
+	if err := writePNGChunk(w, "acTL", actl); err != nil {
+		return err
+	}
+
+	sequence := uint32(0)
+	if err := ComposeGIF(anim, func(i int, canvas *image.NRGBA) error {
+
+		var delay int
+		if i < len(anim.Delay) {
+			delay = anim.Delay[i]
+		}
+		fctl := binary.BigEndian.AppendUint32(nil, sequence)
+		fctl = binary.BigEndian.AppendUint32(fctl, uint32(width))
+		fctl = binary.BigEndian.AppendUint32(fctl, uint32(height))
+		fctl = binary.BigEndian.AppendUint32(fctl, 0)
+		fctl = binary.BigEndian.AppendUint32(fctl, 0)
+		fctl = binary.BigEndian.AppendUint16(fctl, uint16(delay))
+		fctl = binary.BigEndian.AppendUint16(fctl, 100)
+		fctl = append(fctl, 0, 0)
+		if err := writePNGChunk(w, "fcTL", fctl); err != nil {
+			return err
+		}
+		sequence++
+
+		data, err := pngImageData(canvas)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			return writePNGChunk(w, "IDAT", data)
+		}
+		sequence++
+		return writePNGChunk(w, "fdAT", append(binary.BigEndian.AppendUint32(nil, sequence-1), data...))
+	}); err != nil {
+		return err
+	}
+	return writePNGChunk(w, "IEND", nil)
+}
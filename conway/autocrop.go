@@ -0,0 +1,94 @@
+// Small patterns on large boards produce animations which are mostly empty.
+// Frames can be cropped automatically to the bounding box of all the cells
+// which are alive in any generation, plus some padding, so that the same
+// region is shown in all frames
+
+package conway
+
+import (
+	"errors"
+	"image"
+	"sync"
+)
+
+// autoCrop
+// ----------------------------------------------------------------------------
+
+// type
+
+// the region of frames shown when they are cropped automatically, which is
+// computed only once all generations have been simulated
+type autoCrop struct {
+	padding int
+	once    sync.Once
+	region  image.Rectangle
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Crop all frames of this game to the bounding box of the cells alive in any
+// generation, enlarged with the given number of cells in every direction.
+// Since the bounding box is computed over all generations, frames can only be
+// rendered once the game has been run. Unbounded games can not be cropped
+func (game *Conway) SetAutoCrop(padding int) error {
+
+	if padding < 0 {
+		return errors.New("The padding can not be negative")
+	}
+	if game.unbounded {
+		return errors.New("Unbounded games can not be cropped automatically")
+	}
+	game.autocrop = &autoCrop{padding: padding}
+	return nil
+}
+
+// return the region of pixels of frames shown when they are cropped
+// automatically, or an empty rectangle if there is no living cell in any
+// generation
+func (game *Conway) cropRegion() image.Rectangle {
+
+	game.autocrop.once.Do(func() {
+		board := game.board()
+		var box image.Rectangle
+		for index := 0; index < game.nbgenerations; index++ {
+			if !game.computed(index) {
+				continue
+			}
+			for _, p := range game.living(index) {
+				if p.In(board) {
+					p = p.Sub(board.Min)
+					box = box.Union(image.Rect(p.X, p.Y, p.X+1, p.Y+1))
+				}
+			}
+		}
+		if box.Empty() {
+			return
+		}
+		box = box.Inset(-game.autocrop.padding).Intersect(image.Rect(0, 0, board.Dx(), board.Dy()))
+		game.autocrop.region = game.cellRect(box.Min).Union(game.cellRect(box.Max.Sub(image.Pt(1, 1))))
+	})
+	return game.autocrop.region
+}
+
+// return a copy of the given frame cropped to the region computed
+// automatically, if requested. Otherwise, or if there is no living cell in any
+// generation, the same image is returned
+func (game *Conway) cropAutomatically(img *image.Paletted) *image.Paletted {
+
+	if game.autocrop == nil {
+		return img
+	}
+	region := game.cropRegion().Add(img.Rect.Min).Intersect(img.Rect)
+	if region.Empty() {
+		return img
+	}
+	result := image.NewPaletted(image.Rect(0, 0, region.Dx(), region.Dy()), img.Palette)
+	for y := 0; y < region.Dy(); y++ {
+		start := img.PixOffset(region.Min.X, region.Min.Y+y)
+		copy(result.Pix[y*result.Stride:(y+1)*result.Stride], img.Pix[start:start+region.Dx()])
+	}
+	return result
+}
@@ -0,0 +1,175 @@
+// Cells can be drawn over a background image, e.g., a photograph or a logo, so
+// that the game runs on top of it. Dead cells are either transparent, so that
+// the image is shown as it is, or tinted with their colour. Since frames are
+// paletted images, the colours of the image are reduced to the entries left
+// free in the palette of the cells, if any
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"sync"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Largest number of colours of paletted images
+const maxPaletteSize = 256
+
+// Background
+// ----------------------------------------------------------------------------
+
+// type
+
+// A background consists of an image, which is scaled to the frames, and the
+// opacity in the range [0, 1] of the colour of dead cells drawn over it, so
+// that 0 shows the image as it is
+type Background struct {
+	Image image.Image
+	Tint  float64
+}
+
+// a backdrop is a background rendered for frames with the given bounds and
+// palette, i.e., the colour index of every pixel in the palette extended with
+// the colours of the image
+type backdrop struct {
+	rect    image.Rectangle
+	source  color.Palette
+	palette color.Palette
+	pix     []uint8
+}
+
+// a background layer keeps the last backdrop rendered, which is shared by all
+// the frames rendered concurrently
+type backgroundLayer struct {
+	Background
+	mutex    sync.Mutex
+	backdrop *backdrop
+}
+
+// methods
+
+// return the backdrop of this background for frames with the given bounds and
+// palette. The colours of the image are quantized to the entries left free in
+// the palette by their popularity, with 5 bits per component
+func (background Background) render(rect image.Rectangle, palette color.Palette) *backdrop {
+
+	// the image is scaled to the frame with the nearest neighbour and tinted
+	// with the colour of dead cells
+	bounds := background.Image.Bounds()
+	colors := make([]color.NRGBA, rect.Dx()*rect.Dy())
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			c := background.Image.At(bounds.Min.X+x*bounds.Dx()/rect.Dx(), bounds.Min.Y+y*bounds.Dy()/rect.Dy())
+			colors[y*rect.Dx()+x] = color.NRGBAModel.Convert(blendColors(c, palette[0], background.Tint)).(color.NRGBA)
+		}
+	}
+
+	// the most popular colours are added to the palette
+	bucket := func(c color.NRGBA) uint16 {
+		return uint16(c.R>>3)<<10 | uint16(c.G>>3)<<5 | uint16(c.B>>3)
+	}
+	type popularity struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[uint16]*popularity)
+	for _, c := range colors {
+		p, ok := buckets[bucket(c)]
+		if !ok {
+			p = &popularity{}
+			buckets[bucket(c)] = p
+		}
+		p.count++
+		p.r, p.g, p.b = p.r+int(c.R), p.g+int(c.G), p.b+int(c.B)
+	}
+	keys := make([]uint16, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if buckets[keys[i]].count != buckets[keys[j]].count {
+			return buckets[keys[i]].count > buckets[keys[j]].count
+		}
+		return keys[i] < keys[j]
+	})
+	var extra color.Palette
+	for _, key := range keys[:min(len(keys), max(maxPaletteSize-len(palette), 0))] {
+		p := buckets[key]
+		extra = append(extra, color.NRGBA{R: uint8(p.r / p.count), G: uint8(p.g / p.count), B: uint8(p.b / p.count), A: 255})
+	}
+
+	// and every pixel takes the closest of them, or the closest colour of the
+	// palette if there is no room for any other
+	result := &backdrop{
+		rect:    rect,
+		source:  palette,
+		palette: append(append(color.Palette(nil), palette...), extra...),
+		pix:     make([]uint8, len(colors))}
+	closest := make(map[uint16]uint8)
+	for i, c := range colors {
+		index, ok := closest[bucket(c)]
+		if !ok {
+			if extra != nil {
+				index = uint8(len(palette) + extra.Index(c))
+			} else {
+				index = uint8(palette.Index(c))
+			}
+			closest[bucket(c)] = index
+		}
+		result.pix[i] = index
+	}
+	return result
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the background image drawn behind the cells of this game
+func (game *Conway) SetBackground(background Background) error {
+
+	if background.Image == nil || background.Image.Bounds().Empty() {
+		return errors.New("The background image can not be empty")
+	}
+	if background.Tint < 0 || background.Tint > 1 {
+		return fmt.Errorf("The tint %v is out of the range [0, 1]", background.Tint)
+	}
+	game.background = &backgroundLayer{Background: background}
+	return nil
+}
+
+// return a copy of the given frame where all dead cells show the background of
+// this game, if any, or the same image otherwise. The backdrop is rendered
+// once and it is reused as long as frames have the same bounds and palette
+func (game *Conway) drawBackground(img *image.Paletted) *image.Paletted {
+
+	layer := game.background
+	if layer == nil {
+		return img
+	}
+	layer.mutex.Lock()
+	if layer.backdrop == nil || layer.backdrop.rect != img.Rect || !samePalette(layer.backdrop.source, img.Palette) {
+		layer.backdrop = layer.render(img.Rect, img.Palette)
+	}
+	backdrop := layer.backdrop
+	layer.mutex.Unlock()
+
+	result := image.NewPaletted(img.Rect, backdrop.palette)
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			index := img.Pix[y*img.Stride+x]
+			if index == 0 {
+				index = backdrop.pix[y*img.Rect.Dx()+x]
+			}
+			result.Pix[y*result.Stride+x] = index
+		}
+	}
+	return result
+}
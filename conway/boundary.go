@@ -0,0 +1,118 @@
+// The boundary of a generation decides the state of the neighbours of cells
+// located at the edges of the grid. By default, cells off the grid are dead.
+// Alternatively, the grid can be wrapped around as a torus, or cells off the
+// grid can be the mirror image of those inside it. Additionally, edges can be
+// identified with a flip as in a Klein bottle or a Möbius strip, so that
+// patterns crossing them come back upside down
+
+package conway
+
+import "image"
+
+// Boundary
+// ----------------------------------------------------------------------------
+
+// type
+
+// The boundary condition applied at the edges of the grid
+type Boundary int
+
+const (
+	// cells off the grid are dead
+	DeadBoundary Boundary = iota
+
+	// the grid wraps around, so that cells off one edge are those at the
+	// opposite edge
+	TorusBoundary
+
+	// cells off the grid are the mirror image of those inside it
+	MirrorBoundary
+
+	// the grid wraps around as a torus, but cells off the top or bottom edges
+	// are those at the opposite edge flipped horizontally
+	KleinBoundary
+
+	// cells off the left or right edges are those at the opposite edge
+	// flipped vertically, and cells off the top or bottom edges are dead
+	MobiusBoundary
+)
+
+// methods
+
+// Return the name of this boundary condition, either dead, torus, mirror,
+// klein or mobius
+func (boundary Boundary) String() string {
+
+	switch boundary {
+	case TorusBoundary:
+		return "torus"
+	case MirrorBoundary:
+		return "mirror"
+	case KleinBoundary:
+		return "klein"
+	case MobiusBoundary:
+		return "mobius"
+	}
+	return "dead"
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the boundary condition of this generation, which is shared with all the
+// following generations. It applies to all automata but Langton's Ant, whose
+// ants always wrap around the edges
+func (g *generation) SetBoundary(boundary Boundary) {
+	g.boundary = boundary
+}
+
+// return the location of the cell at (x, y) taking into account the boundary
+// condition of this generation, and whether it lies on the grid. Note that
+// the grid consists only of those cells visible in the image
+func (g *generation) locate(x, y int) (image.Point, bool) {
+
+	width, height := g.img.Rect.Max.X/g.ratio.X, g.img.Rect.Max.Y/g.ratio.Y
+	switch g.boundary {
+	case TorusBoundary:
+		return image.Point{X: (x%width + width) % width, Y: (y%height + height) % height}, true
+	case MirrorBoundary:
+		mirror := func(value, size int) int {
+			if value < 0 {
+				return min(-1-value, size-1)
+			}
+			if value >= size {
+				return max(2*size-1-value, 0)
+			}
+			return value
+		}
+		return image.Point{X: mirror(x, width), Y: mirror(y, height)}, true
+	case KleinBoundary:
+		if twisted(y, height) {
+			x = width - 1 - x
+		}
+		return image.Point{X: (x%width + width) % width, Y: (y%height + height) % height}, true
+	case MobiusBoundary:
+		if twisted(x, width) {
+			y = height - 1 - y
+		}
+		return image.Point{X: (x%width + width) % width, Y: y}, y >= 0 && y < height
+	}
+	return image.Point{X: x, Y: y}, x >= 0 && x < width && y >= 0 && y < height
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return whether the given coordinate lies an odd number of times the given
+// size away from the grid, so that it crosses a twisted edge an odd number of
+// times
+func twisted(value, size int) bool {
+
+	wraps := value / size
+	if value < 0 {
+		wraps = (value+1)/size - 1
+	}
+	return wraps%2 != 0
+}
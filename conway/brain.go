@@ -0,0 +1,60 @@
+// Brian's Brain is a cellular automaton with three states: cells are either
+// ready, firing or refractory. It is simulated as WireWorld, where the state of
+// every cell is directly its color index, and almost every soup explodes into
+// a chaos of spaceships travelling in all directions
+
+package conway
+
+import "image/color"
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// States of the cells in Brian's Brain. They are used as color indexes as well
+const (
+	BrainReady uint8 = iota
+	BrainFiring
+	BrainRefractory
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the default palette of Brian's Brain: ready cells are black, firing
+// cells are white and refractory cells are blue
+func BrainPalette() color.Palette {
+	return color.Palette{
+		color.RGBA{0x00, 0x00, 0x00, 0xff},
+		color.RGBA{0xff, 0xff, 0xff, 0xff},
+		color.RGBA{0x22, 0x55, 0xdd, 0xff}}
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return the next generation of Brian's Brain. The rules are:
+//
+//  1. Ready cells start firing if exactly two of their neighbours are firing,
+//     otherwise they remain ready
+//  2. Firing cells become refractory
+//  3. Refractory cells become ready
+func (g *generation) nextBrain() *generation {
+
+	next := g.empty(1 + g.nbgeneration)
+	width, height := g.dimensions()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			switch g.ColorIndexAt(x, y) {
+			case BrainReady:
+				if g.nbstate(x, y, BrainFiring) == 2 {
+					next.SetColorIndex(x, y, BrainFiring)
+				}
+			case BrainFiring:
+				next.SetColorIndex(x, y, BrainRefractory)
+			}
+		}
+	}
+	return next
+}
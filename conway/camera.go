@@ -0,0 +1,119 @@
+// Spaceships travel across unbounded boards and they soon leave the original
+// board, while the whole region simulated becomes too large to be shown. A
+// camera can follow the living cells instead: frames show the region around
+// either their centroid or the center of their bounding box, magnified with a
+// fixed zoom or zoomed automatically to fit all of them
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Distance (in cells) between the bounding box of living cells and the edges
+// of frames when the camera zooms automatically
+const cameraPadding = 4
+
+// Camera
+// ----------------------------------------------------------------------------
+
+// type
+
+// The target followed by the camera, either the centroid of all living cells
+// or the center of their bounding box
+type CameraTarget int
+
+const (
+	CentroidTarget CameraTarget = iota
+	BoxTarget
+)
+
+// A camera follows the given target frame by frame and magnifies the cells
+// around it with the given zoom, so that 2 shows half as many cells twice as
+// large and 0.5 twice as many cells. A zoom of 0 fits all living cells
+// automatically
+type Camera struct {
+	Target CameraTarget
+	Zoom   float64
+}
+
+// Functions
+
+// Return the target of the camera with the given name, either "centroid" or
+// "box", along with an error if it is not known
+func NewCameraTarget(name string) (CameraTarget, error) {
+
+	switch name {
+	case "centroid":
+		return CentroidTarget, nil
+	case "box":
+		return BoxTarget, nil
+	}
+	return CentroidTarget, fmt.Errorf("Unknown camera target '%v'", name)
+}
+
+// methods
+
+// return the center (in cells of the given generation) of the region filmed by
+// this camera and the number of cells shown by every cell of a frame with the
+// given width and height. If there is no living cell, the original board is
+// filmed
+func (camera Camera) frame(g *generation, width, height int) (x, y, scale float64) {
+
+	// compute both the centroid and the bounding box of all living cells
+	columns, rows := g.img.Rect.Max.X/g.ratio.X, g.img.Rect.Max.Y/g.ratio.Y
+	xmin, ymin, xmax, ymax := columns, rows, -1, -1
+	var xsum, ysum, population float64
+	for xs := 0; xs < columns; xs++ {
+		for ys := 0; ys < rows; ys++ {
+			if g.ColorIndexAt(xs, ys) != 0 {
+				xmin, ymin, xmax, ymax = min(xmin, xs), min(ymin, ys), max(xmax, xs), max(ymax, ys)
+				xsum, ysum, population = xsum+float64(xs), ysum+float64(ys), population+1
+			}
+		}
+	}
+	scale = 1
+	if camera.Zoom > 0 {
+		scale = 1 / camera.Zoom
+	}
+	if population == 0 {
+		return float64(g.origin.X) + float64(width)/2, float64(g.origin.Y) + float64(height)/2, scale
+	}
+
+	// the center is rounded to whole cells, so that cells are not split
+	// differently from one frame to the next
+	if camera.Target == BoxTarget {
+		x, y = float64(xmin+xmax+1)/2, float64(ymin+ymax+1)/2
+	} else {
+		x, y = xsum/population+0.5, ysum/population+0.5
+	}
+	if camera.Zoom == 0 {
+		scale = max(float64(xmax-xmin+1+2*cameraPadding)/float64(width),
+			float64(ymax-ymin+1+2*cameraPadding)/float64(height))
+	}
+	return math.Round(x), math.Round(y), scale
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Film this game with the given camera, which follows its living cells. Only
+// unbounded games can be filmed with a camera
+func (game *Conway) SetCamera(camera Camera) error {
+
+	if !game.unbounded {
+		return errors.New("Only games over infinite boards can be filmed with a camera")
+	}
+	if camera.Zoom < 0 {
+		return fmt.Errorf("The zoom %v can not be negative", camera.Zoom)
+	}
+	game.view, game.camera = Tracking, camera
+	return nil
+}
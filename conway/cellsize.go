@@ -0,0 +1,147 @@
+// Cells are drawn in frames with a cell size which can be non-integer, e.g.,
+// 1.5x1.5. Boards are then simulated with one pixel per cell and frames are
+// resampled when rendered, so that the size of cells never interferes with the
+// coordinates used by the rules. Every pixel takes the colour of the cell under
+// its center, so that cells span either the floor or the ceiling of their size
+// and the grid is evenly spread across the frame
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CellSize
+// ----------------------------------------------------------------------------
+
+// type
+
+// The size of cells is given by their width X and their height Y, in pixels
+type CellSize struct {
+	X, Y float64
+}
+
+// methods
+
+// Return the aspect ratio equivalent to this cell size and whether there is
+// any, i.e., whether both its width and height are integers
+func (size CellSize) Ratio() (AspectRatio, bool) {
+
+	if size.X != math.Trunc(size.X) || size.Y != math.Trunc(size.Y) {
+		return AspectRatio{}, false
+	}
+	return AspectRatio{X: int(size.X), Y: int(size.Y)}, true
+}
+
+// Return the rectangle of pixels covered by the cell at the given location
+func (size CellSize) Rect(p image.Point) image.Rectangle {
+
+	// pixels are given to the cell under their center
+	edge := func(value int, size float64) int {
+		return int(math.Ceil(float64(value)*size - 0.5))
+	}
+	return image.Rect(edge(p.X, size.X), edge(p.Y, size.Y), edge(p.X+1, size.X), edge(p.Y+1, size.Y))
+}
+
+// Return a copy of the given image, with one pixel per cell, resampled with
+// this cell size
+func (size CellSize) Resample(img *image.Paletted) *image.Paletted {
+
+	bounds := size.Rect(image.Point{}).Union(size.Rect(image.Point{X: img.Rect.Dx() - 1, Y: img.Rect.Dy() - 1}))
+	result := image.NewPaletted(image.Rect(0, 0, bounds.Max.X, bounds.Max.Y), img.Palette)
+	for py := 0; py < bounds.Max.Y; py++ {
+		y := img.Rect.Min.Y + int((float64(py)+0.5)/size.Y)
+		for px := 0; px < bounds.Max.X; px++ {
+			x := img.Rect.Min.X + int((float64(px)+0.5)/size.X)
+			result.Pix[py*result.Stride+px] = img.ColorIndexAt(x, y)
+		}
+	}
+	return result
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the cell size given in spec either as a single size used for both
+// the width and the height of cells, e.g., "1.5", or as WIDTHxHEIGHT, e.g.,
+// "1.5x2". Cells must be at least one pixel wide and tall
+func ParseCellSize(spec string) (CellSize, error) {
+
+	width, height, found := strings.Cut(strings.TrimSpace(spec), "x")
+	if !found {
+		height = width
+	}
+	x, errx := strconv.ParseFloat(strings.TrimSpace(width), 64)
+	y, erry := strconv.ParseFloat(strings.TrimSpace(height), 64)
+	if errx != nil || erry != nil {
+		return CellSize{}, fmt.Errorf("Wrong cell size '%v'", spec)
+	}
+	if x < 1 || y < 1 || math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return CellSize{}, fmt.Errorf("Cells must be at least one pixel wide and tall, but '%v' was given", spec)
+	}
+	return CellSize{X: x, Y: y}, nil
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the size of the cells of this game in its frames. Cell sizes can only be
+// used with square grids whose aspect ratio is 1:1, as they replace it
+func (game *Conway) SetCellSize(size CellSize) error {
+
+	if size.X < 1 || size.Y < 1 {
+		return errors.New("Cells must be at least one pixel wide and tall")
+	}
+	first := game.generations[0]
+	if first.ratio.X != 1 || first.ratio.Y != 1 {
+		return errors.New("The cell size can not be combined with an aspect ratio")
+	}
+	if first.topology != SquareTopology {
+		return errors.New("The cell size can only be used with square grids")
+	}
+	game.cellsize = &size
+	return nil
+}
+
+// return the given image, with one pixel per cell unless an aspect ratio is
+// used, resampled with the cell size of this game, if any
+func (game *Conway) resize(img *image.Paletted) *image.Paletted {
+
+	if game.cellsize == nil {
+		return img
+	}
+	return game.cellsize.Resample(img)
+}
+
+// return the rectangle of pixels covered in frames by the cell at the given
+// location
+func (game *Conway) cellRect(p image.Point) image.Rectangle {
+
+	if game.cellsize != nil {
+		return game.cellsize.Rect(p)
+	}
+	ratio := game.generations[0].ratio
+	return image.Rect(p.X*ratio.X, p.Y*ratio.Y, (p.X+1)*ratio.X, (p.Y+1)*ratio.Y)
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// return the number of cells of the grid of this generation along both axes
+func (g *generation) dimensions() (width, height int) {
+	return g.img.Rect.Dx() / g.ratio.X, g.img.Rect.Dy() / g.ratio.Y
+}
+
+// Return the number of cells of the grid of this generation along both axes
+func (g *generation) Dimensions() (width, height int) {
+	return g.dimensions()
+}
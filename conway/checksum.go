@@ -0,0 +1,135 @@
+// Checksums of frames make it possible to verify that a shared animation
+// matches the one produced by re-simulating a game with a claimed seed and
+// configuration
+
+package conway
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/gif"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the checksum of a paletted image as a string of hexadecimal digits.
+// The checksum takes into account only the bounds of the image and the color
+// index of every pixel, so that it is preserved when encoding and decoding
+// GIF images
+func FrameChecksum(img *image.Paletted) string {
+
+	hash := sha256.New()
+	bounds := img.Bounds()
+	binary.Write(hash, binary.BigEndian, []int64{
+		int64(bounds.Min.X), int64(bounds.Min.Y),
+		int64(bounds.Max.X), int64(bounds.Max.Y)})
+	row := make([]byte, bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			row[x-bounds.Min.X] = img.ColorIndexAt(x, y)
+		}
+		hash.Write(row)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Return the checksums of all frames of a GIF animation
+func GIFChecksums(anim *gif.GIF) []string {
+
+	result := make([]string, len(anim.Image))
+	for i, img := range anim.Image {
+		result[i] = FrameChecksum(img)
+	}
+	return result
+}
+
+// Checksums
+// ----------------------------------------------------------------------------
+
+// type
+
+// The checksums of all frames of an animation are stored along with the seed
+// and the arguments used for generating it, so that the animation can be
+// re-simulated
+type Checksums struct {
+	Seed   int64
+	Args   []string
+	Frames []string
+}
+
+// methods
+
+// Write the checksums to the given writer in a plain text format, with one
+// entry per line:
+//
+//	seed SEED
+//	arg "ARGUMENT"
+//	frame INDEX CHECKSUM
+func (c Checksums) Write(w io.Writer) error {
+
+	writer := bufio.NewWriter(w)
+	fmt.Fprintln(writer, "# conway-game checksums")
+	fmt.Fprintf(writer, "seed %v\n", c.Seed)
+	for _, arg := range c.Args {
+		fmt.Fprintf(writer, "arg %v\n", strconv.Quote(arg))
+	}
+	for index, checksum := range c.Frames {
+		fmt.Fprintf(writer, "frame %v %v\n", index, checksum)
+	}
+	return writer.Flush()
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Read checksums from the given reader in the format produced by Write. Lines
+// starting with '#' are ignored. In case the contents are not well formed an
+// error is returned
+func ReadChecksums(r io.Reader) (c Checksums, err error) {
+
+	scanner := bufio.NewScanner(r)
+	for nbline := 1; scanner.Scan(); nbline++ {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// every line consists of a keyword and its value
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return c, fmt.Errorf("Syntax error in line %v", nbline)
+		}
+		switch fields[0] {
+		case "seed":
+			if c.Seed, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+				return c, fmt.Errorf("Wrong seed in line %v", nbline)
+			}
+		case "arg":
+			arg, err := strconv.Unquote(fields[1])
+			if err != nil {
+				return c, fmt.Errorf("Wrong argument in line %v", nbline)
+			}
+			c.Args = append(c.Args, arg)
+		case "frame":
+			var index int
+			var checksum string
+			if _, err := fmt.Sscanf(fields[1], "%d %s", &index, &checksum); err != nil || index != len(c.Frames) {
+				return c, fmt.Errorf("Wrong frame in line %v", nbline)
+			}
+			c.Frames = append(c.Frames, checksum)
+		default:
+			return c, fmt.Errorf("Unknown keyword '%v' in line %v", fields[0], nbline)
+		}
+	}
+
+	return c, scanner.Err()
+}
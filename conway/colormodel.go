@@ -0,0 +1,88 @@
+// Besides the colour models given by name (gradient and radial), the colour of
+// living cells can be computed by any type implementing the ColorModel
+// interface, which is consulted once for every living cell with information
+// about it, such as its number of neighbours or its age
+
+package conway
+
+import "errors"
+
+// Cell
+// ----------------------------------------------------------------------------
+
+// type
+
+// The information of a living cell given to colour models. Neighbours are
+// counted in the previous generation, i.e., they are the neighbours that made
+// the cell take birth or survive, and the age is the number of consecutive
+// generations it has been alive, 1 for newborn cells
+type Cell struct {
+	X, Y                    int
+	Generation, Generations int
+	Neighbors               int
+	Age                     int
+}
+
+// ColorModel
+// ----------------------------------------------------------------------------
+
+// type
+
+// A colour model returns the color index of a living cell, which must be
+// strictly positive as index 0 is reserved for dead cells
+type ColorModel interface {
+	CellColor(cell Cell) uint8
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the colour model used for computing the colour of living cells, which
+// takes precedence over the colour model given by name. The age of cells is
+// tracked from now on. Only the Conway's Game and other Life-like rules can
+// be coloured with custom colour models
+func (g *generation) SetColorModel(model ColorModel) error {
+
+	if !g.isLife() {
+		return errors.New("Only Life-like rules can be coloured with custom colour models")
+	}
+	g.colorModel = model
+	return nil
+}
+
+// return the age of the cell at location (x, y), i.e., the number of
+// consecutive generations it has been alive, or 0 if it is dead. Cells of
+// generations whose age is not tracked are 1 generation old
+func (g *generation) ageAt(x, y int) int {
+
+	if g.ColorIndexAt(x, y) == 0 {
+		return 0
+	}
+	if g.ages == nil {
+		return 1
+	}
+	return g.ages[y*(1+g.img.Rect.Max.X/g.ratio.X)+x]
+}
+
+// set the age of the cell at location (x, y)
+func (g *generation) setAge(x, y, age int) {
+
+	if g.ages == nil {
+		g.ages = make([]int, (1+g.img.Rect.Max.X/g.ratio.X)*(1+g.img.Rect.Max.Y/g.ratio.Y))
+	}
+	g.ages[y*(1+g.img.Rect.Max.X/g.ratio.X)+x] = age
+}
+
+// return the color index given by the colour model of this generation to a
+// cell at location (x, y) with the given age
+func (g *generation) customColor(x, y, age int) uint8 {
+
+	return max(1, g.colorModel.CellColor(Cell{
+		X: x - g.origin.X, Y: y - g.origin.Y,
+		Generation:  g.nbgeneration,
+		Generations: g.nbgenerations,
+		Neighbors:   g.nbalive(x, y),
+		Age:         age}))
+}
@@ -0,0 +1,340 @@
+// Colour rules are expressions which compute the colour of every living cell,
+// e.g., "age > 10 ? #ff0000 : neighbors == 3 ? #00ff00 : #ffffff". They are
+// compiled once and evaluated for every living cell as a colour model.
+//
+// Expressions consist of integers, colours given as #RRGGBB and the variables
+// age, neighbors, x, y, generation and generations, which take the values of
+// the cell being coloured. They are combined with the arithmetic operators +,
+// -, *, / and %, the comparisons ==, !=, <, <=, > and >=, the logical
+// operators &&, || and !, parentheses and the conditional operator c ? a : b.
+// Comparisons and logical operators yield 1 if they hold and 0 otherwise, and
+// the value of an expression must be a colour
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ColorRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// A compiled expression either yields an integer or a colour, which is given
+// as an index of the colours of its colour rule
+type expression struct {
+	color bool
+	eval  func(cell *Cell) int
+}
+
+// A colour rule consists of the colours found in its expression, which are
+// given consecutive color indexes starting from 1, and the compiled
+// expression
+type ColorRule struct {
+	colors []color.Color
+	expr   expression
+}
+
+// A parser of colour rules keeps the tokens of the expression, along with the
+// colours found so far
+type colorRuleParser struct {
+	tokens []string
+	next   int
+	rule   *ColorRule
+}
+
+// methods
+
+// Return the palette of this colour rule where dead cells are given the color
+// given, and living cells are given the colours of its expression in the same
+// order they appear
+func (rule *ColorRule) Palette(dead color.Color) color.Palette {
+	return append(color.Palette{dead}, rule.colors...)
+}
+
+// Return the color index of the given cell according to this colour rule
+func (rule *ColorRule) CellColor(cell Cell) uint8 {
+	return uint8(rule.expr.eval(&cell))
+}
+
+// return the next token without consuming it, or the empty string if there
+// are no more tokens
+func (p *colorRuleParser) peek() string {
+
+	if p.next < len(p.tokens) {
+		return p.tokens[p.next]
+	}
+	return ""
+}
+
+// consume the next token, which must be the given one
+func (p *colorRuleParser) expect(token string) error {
+
+	if p.peek() != token {
+		return fmt.Errorf("Expected '%v' but found '%v'", token, p.peek())
+	}
+	p.next++
+	return nil
+}
+
+// parse a conditional expression
+func (p *colorRuleParser) conditional() (expression, error) {
+
+	cond, err := p.binary(0)
+	if err != nil || p.peek() != "?" {
+		return cond, err
+	}
+	p.next++
+	then, err := p.conditional()
+	if err != nil {
+		return then, err
+	}
+	if err := p.expect(":"); err != nil {
+		return expression{}, err
+	}
+	otherwise, err := p.conditional()
+	if err != nil {
+		return otherwise, err
+	}
+	if cond.color {
+		return expression{}, errors.New("Conditions can not be colours")
+	}
+	if then.color != otherwise.color {
+		return expression{}, errors.New("Both branches of a conditional must be either colours or integers")
+	}
+	return expression{color: then.color, eval: func(cell *Cell) int {
+		if cond.eval(cell) != 0 {
+			return then.eval(cell)
+		}
+		return otherwise.eval(cell)
+	}}, nil
+}
+
+// binary operators sorted by increasing precedence
+var colorRuleOperators = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// parse an expression with binary operators with the given precedence or
+// higher
+func (p *colorRuleParser) binary(precedence int) (expression, error) {
+
+	if precedence == len(colorRuleOperators) {
+		return p.unary()
+	}
+	left, err := p.binary(1 + precedence)
+	if err != nil {
+		return left, err
+	}
+	for {
+		op := p.peek()
+		found := false
+		for _, candidate := range colorRuleOperators[precedence] {
+			found = found || op == candidate
+		}
+		if !found {
+			return left, nil
+		}
+		p.next++
+		right, err := p.binary(1 + precedence)
+		if err != nil {
+			return right, err
+		}
+		if left.color || right.color {
+			return expression{}, fmt.Errorf("The operator '%v' can not be applied to colours", op)
+		}
+		left = expression{eval: binaryOperator(op, left.eval, right.eval)}
+	}
+}
+
+// parse an expression preceded by unary operators, if any
+func (p *colorRuleParser) unary() (expression, error) {
+
+	op := p.peek()
+	if op != "-" && op != "!" {
+		return p.primary()
+	}
+	p.next++
+	operand, err := p.unary()
+	if err != nil {
+		return operand, err
+	}
+	if operand.color {
+		return expression{}, fmt.Errorf("The operator '%v' can not be applied to colours", op)
+	}
+	if op == "-" {
+		return expression{eval: func(cell *Cell) int { return -operand.eval(cell) }}, nil
+	}
+	return expression{eval: func(cell *Cell) int { return boolToInt(operand.eval(cell) == 0) }}, nil
+}
+
+// parse a number, colour, variable or an expression between parentheses
+func (p *colorRuleParser) primary() (expression, error) {
+
+	token := p.peek()
+	p.next++
+	switch {
+	case token == "":
+		return expression{}, errors.New("Unexpected end of the colour rule")
+	case token == "(":
+		expr, err := p.conditional()
+		if err != nil {
+			return expr, err
+		}
+		return expr, p.expect(")")
+	case strings.HasPrefix(token, "#"):
+		c, err := parseColor(token)
+		if err != nil {
+			return expression{}, err
+		}
+		if len(p.rule.colors) == 255 {
+			return expression{}, errors.New("Colour rules can not use more than 255 colours")
+		}
+		p.rule.colors = append(p.rule.colors, c)
+		index := len(p.rule.colors)
+		return expression{color: true, eval: func(*Cell) int { return index }}, nil
+	case unicode.IsDigit(rune(token[0])):
+		value, err := strconv.Atoi(token)
+		if err != nil {
+			return expression{}, fmt.Errorf("Wrong number '%v'", token)
+		}
+		return expression{eval: func(*Cell) int { return value }}, nil
+	}
+
+	// otherwise, it must be a variable
+	variables := map[string]func(cell *Cell) int{
+		"age":         func(cell *Cell) int { return cell.Age },
+		"neighbors":   func(cell *Cell) int { return cell.Neighbors },
+		"x":           func(cell *Cell) int { return cell.X },
+		"y":           func(cell *Cell) int { return cell.Y },
+		"generation":  func(cell *Cell) int { return cell.Generation },
+		"generations": func(cell *Cell) int { return cell.Generations },
+	}
+	if variable, ok := variables[token]; ok {
+		return expression{eval: variable}, nil
+	}
+	if !unicode.IsLetter(rune(token[0])) {
+		return expression{}, fmt.Errorf("Unexpected '%v' in the colour rule", token)
+	}
+	return expression{}, fmt.Errorf("Unknown variable '%v'", token)
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return 1 if the given condition holds and 0 otherwise
+func boolToInt(condition bool) int {
+
+	if condition {
+		return 1
+	}
+	return 0
+}
+
+// return a function which applies the given binary operator to the values of
+// two expressions. Divisions by zero yield zero
+func binaryOperator(op string, left, right func(cell *Cell) int) func(cell *Cell) int {
+
+	operators := map[string]func(a, b int) int{
+		"||": func(a, b int) int { return boolToInt(a != 0 || b != 0) },
+		"&&": func(a, b int) int { return boolToInt(a != 0 && b != 0) },
+		"==": func(a, b int) int { return boolToInt(a == b) },
+		"!=": func(a, b int) int { return boolToInt(a != b) },
+		"<":  func(a, b int) int { return boolToInt(a < b) },
+		"<=": func(a, b int) int { return boolToInt(a <= b) },
+		">":  func(a, b int) int { return boolToInt(a > b) },
+		">=": func(a, b int) int { return boolToInt(a >= b) },
+		"+":  func(a, b int) int { return a + b },
+		"-":  func(a, b int) int { return a - b },
+		"*":  func(a, b int) int { return a * b },
+		"/": func(a, b int) int {
+			if b == 0 {
+				return 0
+			}
+			return a / b
+		},
+		"%": func(a, b int) int {
+			if b == 0 {
+				return 0
+			}
+			return a % b
+		},
+	}
+	f := operators[op]
+	return func(cell *Cell) int { return f(left(cell), right(cell)) }
+}
+
+// return the colour given in the format #RRGGBB
+func parseColor(token string) (color.Color, error) {
+
+	value, err := strconv.ParseUint(strings.TrimPrefix(token, "#"), 16, 32)
+	if err != nil || len(token) != 7 {
+		return nil, fmt.Errorf("Wrong colour '%v'", token)
+	}
+	return color.RGBA{uint8(value >> 16), uint8(value >> 8), uint8(value), 0xff}, nil
+}
+
+// return the tokens of the given colour rule, or an error if any character is
+// not recognized
+func tokenizeColorRule(spec string) ([]string, error) {
+
+	var tokens []string
+	for i := 0; i < len(spec); {
+		c := rune(spec[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '#' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			j := i + 1
+			for j < len(spec) && (unicode.IsLetter(rune(spec[j])) || unicode.IsDigit(rune(spec[j]))) {
+				j++
+			}
+			tokens = append(tokens, spec[i:j])
+			i = j
+		case i+1 < len(spec) && (spec[i:i+2] == "==" || spec[i:i+2] == "!=" ||
+			spec[i:i+2] == "<=" || spec[i:i+2] == ">=" ||
+			spec[i:i+2] == "&&" || spec[i:i+2] == "||"):
+			tokens = append(tokens, spec[i:i+2])
+			i += 2
+		case strings.ContainsRune("+-*/%<>!?:()", c):
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			return nil, fmt.Errorf("Unexpected character '%c' in the colour rule", c)
+		}
+	}
+	return tokens, nil
+}
+
+// Return the colour rule given in spec compiled, or an error if it is not
+// well formed
+func ParseColorRule(spec string) (*ColorRule, error) {
+
+	tokens, err := tokenizeColorRule(spec)
+	if err != nil {
+		return nil, err
+	}
+	parser := colorRuleParser{tokens: tokens, rule: &ColorRule{}}
+	expr, err := parser.conditional()
+	if err != nil {
+		return nil, err
+	}
+	if parser.peek() != "" {
+		return nil, fmt.Errorf("Unexpected '%v' in the colour rule", parser.peek())
+	}
+	if !expr.color {
+		return nil, errors.New("The colour rule must yield a colour")
+	}
+	parser.rule.expr = expr
+	return parser.rule, nil
+}
@@ -0,0 +1,64 @@
+// Frames of GIF animations are not necessarily complete images: they can cover
+// only part of the animation and they are disposed in different ways before
+// showing the next one. Animations are composited as GIF viewers do, so that
+// they can be written in other formats, e.g., animated PNG or video
+
+package conway
+
+import (
+	"image"
+	"image/draw"
+	"image/gif"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return the dimensions of the given animation, i.e., those of its logical
+// screen if given, or those of its first frame otherwise
+func composedDimensions(anim *gif.GIF) (width, height int) {
+
+	if anim.Config.Width > 0 && anim.Config.Height > 0 {
+		return anim.Config.Width, anim.Config.Height
+	}
+	return GIFDimensions(anim)
+}
+
+// Invoke the given function with the index of every frame of the given
+// animation and the complete image shown at that frame, i.e., the frame drawn
+// over the previous ones according to their disposal methods. Areas not
+// covered by any frame are transparent. The image is reused between
+// invocations, so that it must be copied if it is kept. Composition stops as
+// soon as the function returns an error, which is returned
+func ComposeGIF(anim *gif.GIF, fn func(index int, img *image.NRGBA) error) error {
+
+	width, height := composedDimensions(anim)
+	bounds := image.Rect(0, 0, width, height)
+	canvas := image.NewNRGBA(bounds)
+	for i, frame := range anim.Image {
+
+		// the canvas is restored after showing frames disposed with the
+		// previous contents
+		var disposal byte
+		if i < len(anim.Disposal) {
+			disposal = anim.Disposal[i]
+		}
+		var saved *image.NRGBA
+		if disposal == gif.DisposalPrevious {
+			saved = image.NewNRGBA(bounds)
+			copy(saved.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, frame.Rect, frame, frame.Rect.Min, draw.Over)
+		if err := fn(i, canvas); err != nil {
+			return err
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Rect, image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, saved.Pix)
+		}
+	}
+	return nil
+}
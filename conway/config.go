@@ -0,0 +1,194 @@
+// Configurations describe complete games as plain values: the board, the rule,
+// the boundary, the colour model and the initial population, given either as a
+// random soup, a number of patterns placed on the board, or both. They are
+// meant for library users, e.g., the curated presets of the package examples,
+// which can be run with a single call
+
+package conway
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// Placement
+// ----------------------------------------------------------------------------
+
+// type
+
+// A placement locates a Pattern, either the name of an object of the
+// dictionary, the name of a pattern of the library, an apgcode or rows
+// separated by '/' where living cells are shown as 'o', with its upper-left
+// corner at the cell (X, Y) of the board once flipped and rotated clockwise as
+// requested
+type Placement struct {
+	Pattern  string
+	X, Y     int
+	Rotation Rotation
+	Flip     Flip
+}
+
+// methods
+
+// return the pattern of this placement cropped to its bounding box, along with
+// the location of its upper-left corner, so that cells given as rows keep
+// their position, or an error if the pattern is not known
+func (placement Placement) resolve() (Pattern, image.Point, error) {
+
+	cells, err := lookupPattern(placement.Pattern)
+	if err != nil || len(cells) == 0 {
+		return Pattern{}, image.Point{}, err
+	}
+	pattern := NewPattern(cells)
+	at := image.Point{X: placement.X, Y: placement.Y}.Add(cells[0].Sub(pattern.Cells[0]))
+	return pattern, at, nil
+}
+
+// Config
+// ----------------------------------------------------------------------------
+
+// type
+
+// The configuration of a game. As with Quick, all fields are optional:
+// dimensions and generations default to 100, the rule defaults to the Conway's
+// Game (B3/S23), the model defaults to "gradient", the palette defaults to the
+// one used by Quick and, if no seed is given, a new one is chosen. Density is
+// the fraction of cells initially alive in a random soup, so that boards are
+// initially empty if it is zero, and Patterns are placed on top of the soup.
+// Frames are shown during Delay 100th of a second
+type Config struct {
+	Name, Description string
+	Width, Height     int
+	Generations       int
+	Rule              string
+	Boundary          Boundary
+	Model             string
+	Palette           color.Palette
+	Seed              int64
+	Density           float64
+	Patterns          []Placement
+	Delay             int
+}
+
+// methods
+
+// Return the game described by this configuration ready to be run, along with
+// an error if the configuration is not valid
+func (config Config) Game() (*Conway, error) {
+
+	config = config.defaults()
+	initial, err := config.Generation()
+	if err != nil {
+		return nil, err
+	}
+	game := NewConway(config.Width, config.Height, config.Generations, initial)
+	return &game, nil
+}
+
+// return this configuration with the default values of all fields not given
+func (config Config) defaults() Config {
+
+	if config.Width <= 0 {
+		config.Width = quickSize
+	}
+	if config.Height <= 0 {
+		config.Height = quickSize
+	}
+	if config.Generations <= 0 {
+		config.Generations = quickGenerations
+	}
+	if config.Rule == "" {
+		config.Rule = quickRule
+	}
+	if config.Model == "" {
+		config.Model = quickModel
+	}
+	if config.Palette == nil {
+		config.Palette = GradientPalette(color.Black, color.RGBA{0xff, 0xd7, 0x00, 0xff}, color.RGBA{0xdc, 0x14, 0x3c, 0xff})
+	}
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	return config
+}
+
+// Return the first generation of the game described by this configuration,
+// along with an error if the configuration is not valid. Further generations
+// can be computed one at a time with Next, e.g., by interactive playgrounds
+// which do not know in advance how many generations are shown
+func (config Config) Generation() (*generation, error) {
+
+	config = config.defaults()
+	if config.Model != "gradient" && config.Model != "radial" && config.Model != "bichrome" {
+		return nil, fmt.Errorf("Unknown color model '%v'", config.Model)
+	}
+	if config.Density < 0 || config.Density > 1 {
+		return nil, fmt.Errorf("The density %v is out of the range [0, 1]", config.Density)
+	}
+	rule, err := ParseLifeRule(config.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// create the first generation
+	rng := rand.New(rand.NewSource(config.Seed))
+	initial := NewGeneration(image.Rect(0, 0, config.Width, config.Height),
+		config.Palette,
+		AspectRatio{X: 1, Y: 1},
+		config.Model,
+		1, config.Generations)
+	initial.SetCenter(image.Point{X: config.Width / 2, Y: config.Height / 2})
+	initial.SetBoundary(config.Boundary)
+	if err := initial.SetRule(rule, rng); err != nil {
+		return nil, err
+	}
+
+	// with a random soup, if any
+	contents := make([]bool, (1+config.Width)*(1+config.Height))
+	if config.Density > 0 {
+		for i := 0; i < int(config.Density*float64(config.Width*config.Height)); i++ {
+			contents[i] = true
+		}
+		rng.Shuffle(config.Width*config.Height, func(i, j int) {
+			contents[i], contents[j] = contents[j], contents[i]
+		})
+	}
+
+	if err := initial.Set(contents); err != nil {
+		return nil, err
+	}
+
+	// and all patterns on top of it
+	for _, placement := range config.Patterns {
+		pattern, at, err := placement.resolve()
+		if err != nil {
+			return nil, err
+		}
+		if err := initial.Place(pattern, at, placement.Rotation, placement.Flip); err != nil {
+			return nil, fmt.Errorf("The pattern '%v' does not fit in the board at (%v, %v)", placement.Pattern, placement.X, placement.Y)
+		}
+	}
+
+	return initial, nil
+}
+
+// Run the game described by this configuration and write it to the given writer
+// as a GIF animation
+func (config Config) Run(w io.Writer) error {
+
+	game, err := config.Game()
+	if err != nil {
+		return err
+	}
+	if config.Delay <= 0 {
+		config.Delay = quickDelay
+	}
+	game.Run()
+	anim := game.GetGIF(config.Delay, config.Delay, 1)
+	return gif.EncodeAll(w, &anim)
+}
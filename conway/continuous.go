@@ -0,0 +1,382 @@
+// Continuous automata generalize Life-like rules to cells whose values are real
+// numbers in the range [0, 1]. Instead of counting living neighbours, every
+// cell computes the weighted average of the values of its neighbourhood with
+// one or more convolution kernels, which are disks and rings much larger than
+// the 3x3 neighbourhood of the Conway's Game. Two families are provided:
+//
+//   - SmoothLife, by Rafler, where the filling of an inner disk and an outer
+//     ring decide births and deaths as in the Conway's Game
+//
+//   - Lenia, by Chan, where a single ring-shaped kernel feeds a growth
+//     function which smoothly increases or decreases the value of every cell
+//
+// The values of every generation are stored separately from its image, which
+// shows them with the gradient of the palette, so that the first color is
+// used for empty cells and the last one for full cells
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Kernel
+// ----------------------------------------------------------------------------
+
+// type
+
+// A kernel cell is given by its offset (Dx, Dy) with regard to the cell whose
+// neighbourhood is averaged, and its Weight
+type KernelCell struct {
+	Dx, Dy int
+	Weight float64
+}
+
+// A kernel is a list of cells whose weights add up to 1, so that convolutions
+// return weighted averages in the range [0, 1]
+type Kernel []KernelCell
+
+// Functions
+
+// Return a kernel with all cells whose distance to the center, divided by the
+// given radius, is in the range (0, 1] with the weight given by the shape,
+// which is given that normalized distance. Cells whose weight is zero are
+// discarded
+func NewKernel(radius float64, shape func(r float64) float64) (Kernel, error) {
+
+	var kernel Kernel
+	var total float64
+	extent := int(math.Ceil(radius))
+	for dy := -extent; dy <= extent; dy++ {
+		for dx := -extent; dx <= extent; dx++ {
+			r := math.Hypot(float64(dx), float64(dy)) / radius
+			if r > 1 {
+				continue
+			}
+			if weight := shape(r); weight > 0 {
+				kernel = append(kernel, KernelCell{Dx: dx, Dy: dy, Weight: weight})
+				total += weight
+			}
+		}
+	}
+	if total == 0 {
+		return nil, errors.New("Kernels must have at least a cell with a positive weight")
+	}
+	for i := range kernel {
+		kernel[i].Weight /= total
+	}
+	return kernel, nil
+}
+
+// ContinuousRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// A continuous rule returns the Kernels used for averaging the neighbourhood
+// of every cell, and decides the Next value of a cell given its current value
+// and the averages computed with every kernel, in the same order
+type ContinuousRule interface {
+	Kernels() []Kernel
+	Next(value float64, averages []float64) float64
+}
+
+// Functions
+
+// parse the given specification of a continuous rule as comma-separated pairs
+// KEY=VALUE and store every value in the parameter with the same key. Keys not
+// given keep their current value
+func parseParameters(spec string, parameters map[string]*float64) error {
+
+	if strings.TrimSpace(spec) == "" {
+		return nil
+	}
+	for _, field := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return fmt.Errorf("Syntax error in the parameter '%v'", strings.TrimSpace(field))
+		}
+		parameter, ok := parameters[strings.TrimSpace(key)]
+		if !ok {
+			return fmt.Errorf("Unknown parameter '%v'", strings.TrimSpace(key))
+		}
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("Wrong value of the parameter '%v'", strings.TrimSpace(key))
+		}
+		*parameter = number
+	}
+	return nil
+}
+
+// return the given value clipped to the range [0, 1]
+func clip(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
+
+// SmoothLifeRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// SmoothLife averages the values of a disk of radius Inner (the filling m of
+// the cell) and of the ring between Inner and Outer (the filling n of its
+// neighbourhood). Cells are born if n is in the range [Birth1, Birth2] and
+// survive if n is in the range [Death1, Death2], with smooth transitions of
+// widths AlphaN and AlphaM. The value of cells changes by a fraction Dt of
+// the transition in every generation, so that it becomes discrete if Dt is 1
+type SmoothLifeRule struct {
+	Inner, Outer   float64
+	Birth1, Birth2 float64
+	Death1, Death2 float64
+	AlphaN, AlphaM float64
+	Dt             float64
+}
+
+// Functions
+
+// Return the SmoothLife rule given as comma-separated pairs KEY=VALUE, where
+// the keys are ri, ra, b1, b2, d1, d2, alphan, alpham and dt, e.g.,
+// "ra=12,dt=0.1". Parameters not given take the values proposed by Rafler
+// with an outer radius of 12, along with an error if the rule is not well
+// formed
+func ParseSmoothLifeRule(spec string) (SmoothLifeRule, error) {
+
+	rule := SmoothLifeRule{
+		Inner: 4, Outer: 12,
+		Birth1: 0.278, Birth2: 0.365,
+		Death1: 0.267, Death2: 0.445,
+		AlphaN: 0.028, AlphaM: 0.147,
+		Dt: 1}
+	if err := parseParameters(spec, map[string]*float64{
+		"ri": &rule.Inner, "ra": &rule.Outer,
+		"b1": &rule.Birth1, "b2": &rule.Birth2,
+		"d1": &rule.Death1, "d2": &rule.Death2,
+		"alphan": &rule.AlphaN, "alpham": &rule.AlphaM,
+		"dt": &rule.Dt}); err != nil {
+		return rule, err
+	}
+	if rule.Inner <= 0 || rule.Outer <= rule.Inner {
+		return rule, errors.New("The radii of SmoothLife must be strictly positive, and the outer one larger than the inner one")
+	}
+	if rule.Dt <= 0 || rule.Dt > 1 {
+		return rule, fmt.Errorf("The time step %v is out of the range (0, 1]", rule.Dt)
+	}
+	return rule, nil
+}
+
+// methods
+
+// Return the disk and the ring averaged by SmoothLife
+func (rule SmoothLifeRule) Kernels() []Kernel {
+
+	disk, _ := NewKernel(rule.Inner, func(r float64) float64 { return 1 })
+	ring, _ := NewKernel(rule.Outer, func(r float64) float64 {
+		if r*rule.Outer <= rule.Inner {
+			return 0
+		}
+		return 1
+	})
+	return []Kernel{disk, ring}
+}
+
+// Return the next value of a cell with the given value, and the fillings of
+// its disk and ring
+func (rule SmoothLifeRule) Next(value float64, averages []float64) float64 {
+
+	sigmoid := func(x, a, alpha float64) float64 {
+		return 1 / (1 + math.Exp(-(x-a)*4/alpha))
+	}
+	interval := func(x, a, b, alpha float64) float64 {
+		return sigmoid(x, a, alpha) * (1 - sigmoid(x, b, alpha))
+	}
+	mix := func(x, y, m float64) float64 {
+		alive := sigmoid(m, 0.5, rule.AlphaM)
+		return x*(1-alive) + y*alive
+	}
+
+	m, n := averages[0], averages[1]
+	transition := interval(n, mix(rule.Birth1, rule.Death1, m), mix(rule.Birth2, rule.Death2, m), rule.AlphaN)
+	if rule.Dt == 1 {
+		return transition
+	}
+	return clip(value + rule.Dt*(2*transition-1))
+}
+
+// Return the specification of this rule
+func (rule SmoothLifeRule) String() string {
+	return fmt.Sprintf("ri=%v,ra=%v,b1=%v,b2=%v,d1=%v,d2=%v,alphan=%v,alpham=%v,dt=%v",
+		rule.Inner, rule.Outer, rule.Birth1, rule.Birth2, rule.Death1, rule.Death2, rule.AlphaN, rule.AlphaM, rule.Dt)
+}
+
+// LeniaRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// Lenia averages the values of a smooth ring of the given Radius, and the
+// growth of every cell is a gaussian bump centered at Mu with width Sigma,
+// ranging from -1 to 1. The value of cells changes by a fraction 1/T of the
+// growth in every generation
+type LeniaRule struct {
+	Radius, T, Mu, Sigma float64
+}
+
+// Functions
+
+// Return the Lenia rule given as comma-separated pairs KEY=VALUE, where the
+// keys are R, T, mu and sigma, e.g., "R=13,T=10,mu=0.15,sigma=0.015", which
+// are also the values of the parameters not given, along with an error if the
+// rule is not well formed
+func ParseLeniaRule(spec string) (LeniaRule, error) {
+
+	rule := LeniaRule{Radius: 13, T: 10, Mu: 0.15, Sigma: 0.015}
+	if err := parseParameters(spec, map[string]*float64{
+		"R": &rule.Radius, "T": &rule.T,
+		"mu": &rule.Mu, "sigma": &rule.Sigma}); err != nil {
+		return rule, err
+	}
+	if rule.Radius < 1 {
+		return rule, errors.New("The radius of Lenia must be at least 1")
+	}
+	if rule.T < 1 {
+		return rule, errors.New("The number of steps per unit of time of Lenia must be at least 1")
+	}
+	if rule.Sigma <= 0 {
+		return rule, errors.New("The width of the growth of Lenia must be strictly positive")
+	}
+	return rule, nil
+}
+
+// methods
+
+// Return the smooth ring averaged by Lenia, whose weight peaks halfway from
+// the center
+func (rule LeniaRule) Kernels() []Kernel {
+
+	ring, _ := NewKernel(rule.Radius, func(r float64) float64 {
+		if r <= 0 || r >= 1 {
+			return 0
+		}
+		return math.Exp(4 - 1/(r*(1-r)))
+	})
+	return []Kernel{ring}
+}
+
+// Return the next value of a cell with the given value and average of its
+// ring
+func (rule LeniaRule) Next(value float64, averages []float64) float64 {
+
+	d := (averages[0] - rule.Mu) / rule.Sigma
+	growth := 2*math.Exp(-d*d/2) - 1
+	return clip(value + growth/rule.T)
+}
+
+// Return the specification of this rule
+func (rule LeniaRule) String() string {
+	return fmt.Sprintf("R=%v,T=%v,mu=%v,sigma=%v", rule.Radius, rule.T, rule.Mu, rule.Sigma)
+}
+
+// Continuum
+// ----------------------------------------------------------------------------
+
+// type
+
+// A continuum consists of the values of all cells with the same layout
+// expected by Set, along with the rule used for updating them and its kernels
+type continuum struct {
+	values  []float64
+	rule    ContinuousRule
+	kernels []Kernel
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the values of the cells of a generation of a continuous automaton, which
+// must be in the range [0, 1], along with the rule used for updating them. The
+// slice follows the same layout expected by Set
+func (g *generation) SetValues(values []float64, rule ContinuousRule) error {
+
+	if g.automaton != "smoothlife" && g.automaton != "lenia" {
+		return errors.New("Values can only be given to continuous automata")
+	}
+	if rule == nil {
+		return errors.New("Continuous automata require a rule")
+	}
+	if len(values) != (1+g.img.Rect.Max.Y/g.ratio.Y-g.img.Rect.Min.Y/g.ratio.Y)*
+		(1+g.img.Rect.Max.X/g.ratio.X-g.img.Rect.Min.X/g.ratio.X) {
+		return errors.New("Mismatched dimensions")
+	}
+	for _, value := range values {
+		if value < 0 || value > 1 {
+			return fmt.Errorf("The value %v is out of the range [0, 1]", value)
+		}
+	}
+
+	g.continuum = &continuum{
+		values:  append([]float64(nil), values...),
+		rule:    rule,
+		kernels: rule.Kernels()}
+	g.paint()
+	return nil
+}
+
+// Return the values of the cells of a generation of a continuous automaton.
+// The slice follows the same layout expected by SetValues
+func (g *generation) Values() []float64 {
+	return append([]float64(nil), g.continuum.values...)
+}
+
+// draw the values of this generation over its image with the gradient of its
+// palette
+func (g *generation) paint() {
+
+	last := float64(len(g.img.Palette) - 1)
+	width, height := g.img.Rect.Max.X/g.ratio.X, g.img.Rect.Max.Y/g.ratio.Y
+	for x := 0; x <= width; x++ {
+		for y := 0; y <= height; y++ {
+			g.SetColorIndex(x, y, uint8(math.Round(last*g.continuum.values[y*(1+width)+x])))
+		}
+	}
+}
+
+// Return the next generation of a continuous automaton. The neighbourhood of
+// every cell is averaged with all kernels of its rule, taking into account the
+// boundary condition
+func (g *generation) nextContinuous() *generation {
+
+	// create a new generation with the same features than this one
+	next := g.empty(1 + g.nbgeneration)
+	next.continuum = &continuum{
+		values:  make([]float64, len(g.continuum.values)),
+		rule:    g.continuum.rule,
+		kernels: g.continuum.kernels}
+
+	width, height := g.dimensions()
+	averages := make([]float64, len(g.continuum.kernels))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for i, kernel := range g.continuum.kernels {
+				averages[i] = 0
+				for _, cell := range kernel {
+					if p, ok := g.locate(x+cell.Dx, y+cell.Dy); ok {
+						averages[i] += cell.Weight * g.continuum.values[p.Y*(1+width)+p.X]
+					}
+				}
+			}
+			i := y*(1+width) + x
+			next.continuum.values[i] = clip(g.continuum.rule.Next(g.continuum.values[i], averages))
+		}
+	}
+
+	// and draw its values
+	next.paint()
+	return next
+}
@@ -4,10 +4,15 @@ package conway
 
 import (
 	"errors"
+	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"image/gif"
 	"math"
+	"math/bits"
+	"math/rand"
+	"time"
 )
 
 // Functions
@@ -97,6 +102,20 @@ func getAverage(numbers []uint8) uint8 {
 	return uint8(average / float64(len(numbers)))
 }
 
+// copyFrame
+//
+// return a copy of the given frame. Frames passed from one stage of rendering
+// to the next might be the images of generations themselves, which are also
+// used for computing the following generations and rendering other frames, so
+// that stages which draw over frames do it over a copy returned by this
+// function
+func copyFrame(img *image.Paletted) *image.Paletted {
+
+	result := image.NewPaletted(img.Rect, img.Palette)
+	draw.Draw(result, result.Rect, img, img.Rect.Min, draw.Src)
+	return result
+}
+
 // Generation
 // ----------------------------------------------------------------------------
 
@@ -126,22 +145,62 @@ type AspectRatio struct {
 //
 // Pixels are coloured according to the given color model:
 //
-//    * Gradient: all living cells are coloured with a different color in each
-//    generation
-//
-//    * Radial: living cells are coloured with an RGB combination according to
-//    its distance to the farest corner from a center point
+//   - Gradient: all living cells are coloured with a different color in each
+//     generation
 //
-// In all cases, dead cells are coloured always with the same RGB combination
+//   - Radial: living cells are coloured with an RGB combination according to
+//     its distance to the farest corner from a center point
 //
+// In all cases, dead cells are coloured always with the same RGB combination.
 // Because the radial color model computes distances from a corner, this is
-// stored in each generation as well
+// stored in each generation as well. Anything else specific to the automaton
+// followed is kept in its state
 type generation struct {
+	automatonState
 	img                         image.Paletted
 	ratio                       AspectRatio
 	model                       string
 	nbgeneration, nbgenerations int
 	center                      image.Point
+	origin                      image.Point
+	background                  bool
+	colorModel                  ColorModel
+	ages                        []int
+}
+
+// The state of the automaton followed by a generation besides its colours,
+// e.g., its rules, the ants of Langton's ants or the values of continuous
+// automata. Every new generation starts with a copy of the state of the one
+// it is computed from, so that automata only replace the parts of their state
+// which change from one generation to the next
+type automatonState struct {
+	automaton  string
+	turns      string
+	ants       []Ant
+	wolfram    uint8
+	row        int
+	margolus   *[2]MargolusRule
+	partition  int
+	cyclic     *CyclicRule
+	forest     *ForestFireRule
+	rule       *LifeRule
+	rng        *rand.Rand
+	species    []uint8
+	nbspecies  int
+	boundary   Boundary
+	lifespan   int
+	transition Rule
+	topology   Topology
+	volume     *volume
+	continuum  *continuum
+}
+
+// return a copy of this state for a new generation. Ants are copied since
+// they are moved in place, whereas any other part of the state is replaced
+// by the automata which change it
+func (s automatonState) clone() automatonState {
+	s.ants = append([]Ant(nil), s.ants...)
+	return s
 }
 
 // methods
@@ -211,144 +270,214 @@ func (g *generation) SetCenter(p image.Point) {
 	g.center = p
 }
 
-// return the number of cells alive around the given position
-func (g *generation) nbalive(x, y int) (result int) {
+// Set the automaton followed by this generation. By default, generations
+// follow the rules of the Conway's Game ("life"). Other automata are
+// "wireworld", "ant", "elementary", "immigration" and "quadlife"
+func (g *generation) SetAutomaton(automaton string) {
+	g.automaton = automaton
+}
 
-	// transform the given coordinates according to the aspect ratio
-	xt, yt := x*g.ratio.X, y*g.ratio.Y
+// return whether this generation follows the rules of the Conway's Game
+func (g *generation) isLife() bool {
+	return g.automaton == "" || g.automaton == "life"
+}
 
-	// if (x, y) is not at the top row
-	if yt < g.img.Rect.Max.Y {
+// return the next generation of automata other than the Conway's Game
+func (g *generation) nextAutomaton() *generation {
+
+	switch g.automaton {
+	case "wireworld":
+		return g.nextWireWorld()
+	case "ant":
+		return g.nextAnt()
+	case "elementary":
+		return g.nextElementary()
+	case "immigration", "quadlife":
+		return g.nextSpecies()
+	case "life3d":
+		return g.nextLife3D()
+	case "margolus":
+		return g.nextMargolus()
+	case "cyclic", "rps":
+		return g.nextCyclic()
+	case "forestfire":
+		return g.nextForestFire()
+	case "brain":
+		return g.nextBrain()
+	case "smoothlife", "lenia":
+		return g.nextContinuous()
+	}
+	panic("Unknown automaton '" + g.automaton + "'")
+}
 
-		if g.ColorIndexAt(x, y+1) != 0 {
-			result += 1
-		}
+// return a new empty generation with the same dimensions, palette, aspect
+// ratio, colour model, center and automaton (including its own parameters) than
+// this one, which is given index nbgeneration
+func (g *generation) empty(nbgeneration int) *generation {
 
-		// if this is not the leftmost column
-		if xt > 0 {
-			if g.ColorIndexAt(x-1, y+1) != 0 {
-				result += 1
-			}
-		}
+	return g.emptyWithin(image.Rectangle{
+		Min: image.Point{X: g.img.Rect.Min.X / g.ratio.X, Y: g.img.Rect.Min.Y / g.ratio.Y},
+		Max: image.Point{X: g.img.Rect.Max.X / g.ratio.X, Y: g.img.Rect.Max.Y / g.ratio.Y}},
+		nbgeneration)
+}
+
+// return a new empty generation as those returned by empty but with the
+// dimensions of the given rectangle
+func (g *generation) emptyWithin(rectangle image.Rectangle, nbgeneration int) *generation {
 
-		// if this is not the rightmost column
-		if xt < g.img.Rect.Max.X {
-			if g.ColorIndexAt(x+1, y+1) != 0 {
+	result := NewGeneration(rectangle,
+		g.img.Palette,
+		AspectRatio{X: g.ratio.X, Y: g.ratio.Y},
+		g.model,
+		nbgeneration,
+		g.nbgenerations)
+	result.SetCenter(g.center)
+	result.automatonState = g.automatonState.clone()
+
+	// the location of the original board in unbounded games, the background
+	// and the custom colour model, if any, are shared as well
+	result.origin = g.origin
+	result.background = g.background
+	result.colorModel = g.colorModel
+
+	return result
+}
+
+// return the number of cells around the given position whose color index is
+// equal to the given one
+func (g *generation) nbstate(x, y int, state uint8) (result int) {
+
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+
+			// skip the cell itself and those falling off the grid
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			if p, ok := g.locate(x+dx, y+dy); ok && g.ColorIndexAt(p.X, p.Y) == state {
 				result += 1
 			}
 		}
 	}
+	return
+}
 
-	// if (x, y) is not at the bottom row
-	if yt > 0 {
+// return the color index of a living cell at location (x, y) in this
+// generation according to its colour model:
+//
+//   - Gradient: the color index depends on the index of this generation
+//
+//   - Radial: the color index depends on the distance of the cell to the
+//     center of this generation
+//
+//   - Bichrome: all living cells are given the same color index
+//
+// Living cells are given the first color index after the one used for dead
+// cells under any other colour model
+func (g *generation) cellColor(x, y int) uint8 {
 
-		if g.ColorIndexAt(x, y-1) != 0 {
-			result += 1
-		}
+	// custom colour models take precedence over those given by name
+	if g.colorModel != nil {
+		return g.customColor(x, y, 1+g.ageAt(x, y))
+	}
 
-		// if this is not the leftmost column
-		if xt > 0 {
-			if g.ColorIndexAt(x-1, y-1) != 0 {
-				result += 1
-			}
-		}
+	switch g.model {
 
-		// if this is not the rightmost column
-		if xt < g.img.Rect.Max.X {
-			if g.ColorIndexAt(x+1, y-1) != 0 {
-				result += 1
-			}
+	// compute the color to use for the living cells in this generation in
+	// case this generation uses the gradient color model
+	case "gradient":
+		if 1+g.nbgeneration*255.0/g.nbgenerations > 255 {
+			return 255
 		}
+		return 1 + uint8(g.nbgeneration*255.0/g.nbgenerations)
+
+	// compute the color of this cell in case this generation follows the
+	// radial color model, and make sure that the maximum index is used
+	case "radial":
+
+		// get the farest corner from the center used in this generation, and
+		// also the distance from this cell to the same corner
+		farest, _ := farestPoint(g.center,
+			image.Rectangle{Min: image.Point{
+				X: g.img.Rect.Min.X / g.ratio.X,
+				Y: g.img.Rect.Min.Y / g.ratio.Y},
+				Max: image.Point{
+					X: g.img.Rect.Max.X / g.ratio.X,
+					Y: g.img.Rect.Max.Y / g.ratio.Y}})
+		return uint8(255.0 * EuclideanDistance(g.center, image.Point{X: x, Y: y}) /
+			EuclideanDistance(g.center, farest))
 	}
 
-	// if (x,y) is not at the leftmost column
-	if xt > 0 {
-		if g.ColorIndexAt(x-1, y) != 0 {
-			result += 1
-		}
+	return 1
+}
+
+// return the neighbourhood of living cells around the given position as a
+// mask where every neighbour is given a separate bit (see neighbourBit),
+// taking into account the boundary condition and topology of this generation
+func (g *generation) neighbourhood(x, y int) (result int) {
+
+	if g.topology != SquareTopology {
+		return g.triangularNeighbourhood(x, y)
 	}
 
-	// if (x,y) is not at the rightmost column
-	if xt < g.img.Rect.Max.X {
-		if g.ColorIndexAt(x+1, y) != 0 {
-			result += 1
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+
+			// skip the cell itself and those falling off the grid
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			if p, ok := g.locate(x+dx, y+dy); (ok && g.ColorIndexAt(p.X, p.Y) != 0) || (!ok && g.background) {
+				result |= neighbourBit(dx, dy)
+			}
 		}
 	}
-
-	// and return the number of alive cells around (x, y)
 	return
 }
 
-// Return the next generation, i.e., apply the rules of the Conway's Game
+// return the number of cells alive around the given position, taking into
+// account the boundary condition of this generation
+func (g *generation) nbalive(x, y int) int {
+	return bits.OnesCount(uint(g.neighbourhood(x, y)))
+}
+
+// Return the next generation, i.e., apply the rules of the Conway's Game or
+// the Life-like rule of this generation
 func (g *generation) Next() *generation {
 
-	// color of living cells
-	var c uint8
+	// generations of other automata and those with a transition rule are
+	// computed separately
+	if !g.isLife() {
+		return g.nextAutomaton()
+	}
+	if g.transition != nil {
+		return g.nextTransition()
+	}
 
 	// create a new generation with the same dimensions and palette than this
-	// one following also the same colour model
-	next := NewGeneration(image.Rectangle{
-		Min: image.Point{X: g.img.Rect.Min.X / g.ratio.X, Y: g.img.Rect.Min.Y / g.ratio.Y},
-		Max: image.Point{X: g.img.Rect.Max.X / g.ratio.X, Y: g.img.Rect.Max.Y / g.ratio.Y}},
-		g.img.Palette,
-		AspectRatio{X: g.ratio.X, Y: g.ratio.Y},
-		g.model,
-		1+g.nbgeneration,
-		g.nbgenerations)
-
-	// and also reuse the same center
-	next.SetCenter(g.center)
-
-	// compute the color to use for the living cells in this generation in case
-	// this generation uses the gradient color model
-	if g.model == "gradient" {
-		if 1+uint8(g.nbgeneration*255.0/g.nbgenerations) > 255 {
-			c = 255
-		} else {
-			c = 1 + uint8(g.nbgeneration*255.0/g.nbgenerations)
-		}
-	}
+	// one following also the same colour model and reusing the same center
+	next := g.empty(1 + g.nbgeneration)
+	next.background = g.nextBackground()
 
 	// for all cells in this generation
 	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
 		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
 
-			// get the number of cells alive around cell (x, y)
-			alive := g.nbalive(x, y)
-
-			// compute the color of this cell in case this generation follows
-			// the radial color model, and make sure that the maximum index is
-			// used
-			if g.model == "radial" {
-
-				// get the farest corner from the center used in this
-				// generation, and also the distance from this cell to the same
-				// corner
-				farest, _ := farestPoint(g.center,
-					image.Rectangle{Min: image.Point{
-						X: g.img.Rect.Min.X / g.ratio.X,
-						Y: g.img.Rect.Min.Y / g.ratio.Y},
-						Max: image.Point{
-							X: g.img.Rect.Max.X / g.ratio.X,
-							Y: g.img.Rect.Max.Y / g.ratio.Y}})
-				c = uint8(255.0 * EuclideanDistance(g.center, image.Point{X: x, Y: y}) /
-					EuclideanDistance(g.center, farest))
-			}
-
 			// by default, the next generation is empty, i.e., all of them are
 			// dead and thus, the only rules considered are those that make some
 			// cells take birth or survive
+			// cells older than their lifespan die in any case
+			if g.lives(x, y) {
+				age, ok := g.nextAge(x, y)
+				if !ok {
+					continue
+				}
+				next.SetColorIndex(x, y, g.cellColor(x, y))
 
-			// -- survival: Any live cell with two or three live neighbors
-			// survives
-			if g.ColorIndexAt(x, y) != 0 && (alive == 2 || alive == 3) {
-				next.SetColorIndex(x, y, c)
-			}
-
-			// -- birth: Any dead cell with three live neighbors becomes a live
-			// cell
-			if g.ColorIndexAt(x, y) == 0 && alive == 3 {
-				next.SetColorIndex(x, y, c)
+				// the age of cells is tracked only if it is used
+				if g.tracksAges() {
+					next.setAge(x, y, age)
+				}
 			}
 		}
 	}
@@ -361,49 +490,29 @@ func (g *generation) Next() *generation {
 // given slice and the length of the contents do not match an error is returned
 func (g *generation) Set(contents []bool) error {
 
-	// color of living cells
-	var c uint8
-
 	if len(contents) != (1+g.img.Rect.Max.Y/g.ratio.Y-g.img.Rect.Min.Y/g.ratio.Y)*
 		(1+g.img.Rect.Max.X/g.ratio.X-g.img.Rect.Min.X/g.ratio.X) {
 		return errors.New("Mismatched dimensions")
 	}
 
-	// compute the color to use for the living cells in this generation in case
-	// this generation uses the gradient color model
-	if g.model == "gradient" {
-		if 1+uint8(g.nbgeneration*255.0/g.nbgenerations) > 255 {
-			c = 255
-		} else {
-			c = 1 + uint8(g.nbgeneration*255.0/g.nbgenerations)
-		}
-	}
-
 	// otherwise, just set the contents of the generation to those given in the
 	// slice
 	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
 		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
-			if contents[y*(g.img.Rect.Max.X/g.ratio.X)+x] {
-
-				// compute the color of this cell in case this generation
-				// follows the radial color model, and make sure that the
-				// maximum index is used
-				if g.model == "radial" {
-
-					// get the farest corner from the center used in this
-					// generation, and also the distance from this cell to the
-					// same corner
-					farest, _ := farestPoint(g.center,
-						image.Rectangle{Min: image.Point{
-							X: g.img.Rect.Min.X / g.ratio.X,
-							Y: g.img.Rect.Min.Y / g.ratio.Y},
-							Max: image.Point{
-								X: g.img.Rect.Max.X / g.ratio.X,
-								Y: g.img.Rect.Max.Y / g.ratio.Y}})
-					c = uint8(255.0 * EuclideanDistance(g.center, image.Point{X: x, Y: y}) /
-						EuclideanDistance(g.center, farest))
+			if contents[y*(1+g.img.Rect.Max.X/g.ratio.X)+x] {
+				g.SetColorIndex(x, y, g.cellColor(x, y))
+			}
+		}
+	}
+
+	// custom colour models are given the number of neighbours of every cell,
+	// so that they are recomputed once all cells have been set
+	if g.colorModel != nil {
+		for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+			for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+				if contents[y*(1+g.img.Rect.Max.X/g.ratio.X)+x] {
+					g.SetColorIndex(x, y, g.customColor(x, y, 1))
 				}
-				g.SetColorIndex(x, y, c)
 			}
 		}
 	}
@@ -412,6 +521,177 @@ func (g *generation) Set(contents []bool) error {
 	return nil
 }
 
+// Return the contents of a generation as a slice of booleans, where true
+// stands for living cells. The slice follows the same layout expected by Set
+func (g *generation) Contents() []bool {
+
+	contents := make([]bool, (1+g.img.Rect.Max.Y/g.ratio.Y-g.img.Rect.Min.Y/g.ratio.Y)*
+		(1+g.img.Rect.Max.X/g.ratio.X-g.img.Rect.Min.X/g.ratio.X))
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			contents[y*(1+g.img.Rect.Max.X/g.ratio.X)+x] = g.ColorIndexAt(x, y) != 0
+		}
+	}
+	return contents
+}
+
+// Set the states of all cells of a generation to those given in states, which
+// are directly used as color indexes. It is intended to be used with automata
+// other than the Conway's Game. In case the given slice and the length of the
+// contents do not match an error is returned
+func (g *generation) SetStates(states []uint8) error {
+
+	if len(states) != (1+g.img.Rect.Max.Y/g.ratio.Y-g.img.Rect.Min.Y/g.ratio.Y)*
+		(1+g.img.Rect.Max.X/g.ratio.X-g.img.Rect.Min.X/g.ratio.X) {
+		return errors.New("Mismatched dimensions")
+	}
+
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			g.SetColorIndex(x, y, states[y*(1+g.img.Rect.Max.X/g.ratio.X)+x])
+		}
+	}
+	return nil
+}
+
+// Return the states of all cells of a generation, i.e., their color indexes.
+// The slice follows the same layout expected by SetStates
+func (g *generation) States() []uint8 {
+
+	states := make([]uint8, (1+g.img.Rect.Max.Y/g.ratio.Y-g.img.Rect.Min.Y/g.ratio.Y)*
+		(1+g.img.Rect.Max.X/g.ratio.X-g.img.Rect.Min.X/g.ratio.X))
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			states[y*(1+g.img.Rect.Max.X/g.ratio.X)+x] = g.ColorIndexAt(x, y)
+		}
+	}
+	return states
+}
+
+// Return the number of living cells in this generation
+func (g *generation) Population() (result int) {
+
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			if g.ColorIndexAt(x, y) != 0 {
+				result += 1
+			}
+		}
+	}
+	return
+}
+
+// Return the colours of all cells of this generation as a sequence of bytes in
+// RGBA with one pixel per cell, row by row, as expected by the image data of
+// canvases in browsers
+func (g *generation) RGBA() []byte {
+
+	width, height := g.dimensions()
+	result := make([]byte, 0, 4*width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, gr, b, a := g.img.Palette[g.ColorIndexAt(x, y)].RGBA()
+			result = append(result, byte(r>>8), byte(gr>>8), byte(b>>8), byte(a>>8))
+		}
+	}
+	return result
+}
+
+// Return the next generation without computing the colour of living cells,
+// i.e., all living cells are given the first color index after the one used
+// for dead cells. This is much cheaper than Next and it is intended to be used
+// when generations are not going to be rendered
+func (g *generation) advance() *generation {
+
+	// the states of other automata are their colours, so that they can not be
+	// computed any cheaper, and so are the states given by transition rules
+	if !g.isLife() {
+		return g.nextAutomaton()
+	}
+	if g.transition != nil {
+		return g.nextTransition()
+	}
+
+	next := g.empty(g.nbgeneration)
+	next.background = g.nextBackground()
+
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			if !g.lives(x, y) {
+				continue
+			}
+
+			// cells given a lifespan need their age even during the burn-in
+			if g.lifespan > 0 {
+				age, ok := g.nextAge(x, y)
+				if !ok {
+					continue
+				}
+				next.setAge(x, y, age)
+			}
+			next.SetColorIndex(x, y, 1)
+		}
+	}
+
+	return next
+}
+
+// Frame selection
+// ----------------------------------------------------------------------------
+
+// type
+
+// A frame selector decides whether the generation with index gen (starting
+// from 0 with the first generation of the game) has to be rendered or not. This
+// decouples the simulation tick from the rendered frames, so that long runs can
+// be shown with fewer frames
+type FrameSelector func(gen int) bool
+
+// functions
+
+// Return a frame selector which renders one every n generations, starting with
+// the first one
+func EveryFrame(n int) FrameSelector {
+	if n <= 1 {
+		return func(gen int) bool { return true }
+	}
+	return func(gen int) bool { return gen%n == 0 }
+}
+
+// Return a frame selector which renders generations following a logarithmic
+// scale, so that frames are dense in the first generations and sparse later
+// on. The density is the number of frames rendered every time the number of
+// generations is multiplied by e (roughly 2.718), so that the larger the
+// density the larger the number of frames
+func LogarithmicFrames(density float64) FrameSelector {
+	return func(gen int) bool {
+		if gen == 0 {
+			return true
+		}
+		return int(density*math.Log(float64(1+gen))) > int(density*math.Log(float64(gen)))
+	}
+}
+
+// Frame stages
+// ----------------------------------------------------------------------------
+
+// type
+
+// Frames are rendered in stages, each one returning the frame of the
+// generation with the given index computed from the frame returned by the
+// previous stage, e.g., with the grid or the HUD drawn over it
+type frameStage func(index int, img *image.Paletted) *image.Paletted
+
+// functions
+
+// return a stage which computes frames with the given function, which does
+// not depend on the generation
+func anyGeneration(fn func(img *image.Paletted) *image.Paletted) frameStage {
+	return func(_ int, img *image.Paletted) *image.Paletted {
+		return fn(img)
+	}
+}
+
 // Conway
 // ----------------------------------------------------------------------------
 
@@ -423,6 +703,42 @@ type Conway struct {
 	width, height int
 	nbgenerations int
 	generations   []*generation
+	burnin        int
+	burninStats   BurnInStats
+	selector      FrameSelector
+	unbounded     bool
+	view          View
+	camera        Camera
+	engine        Engine
+	halo          int
+	annotations   []Annotation
+	grid          color.Color
+	glyph         Glyph
+	hud           bool
+	background    *backgroundLayer
+	effects       []Effect
+	trail         int
+	loopcount     int
+	autocrop      *autoCrop
+	schedule      []ScheduledRule
+	rule          *LifeRule
+	noise         *Noise
+	noiseRng      *rand.Rand
+	cellsize      *CellSize
+	cells         []map[image.Point]uint8
+	script        []Cue
+	until         *Condition
+	seeds         *Seeds
+	offset        int
+}
+
+// The first generations of a game can be simulated without being recorded,
+// so that the recorded generations start after the initial chaotic phase.
+// BurnInStats summarizes the burn-in phase
+type BurnInStats struct {
+	Generations                        int
+	InitialPopulation, FinalPopulation int
+	Elapsed                            time.Duration
 }
 
 // methods
@@ -447,82 +763,303 @@ func NewConway(width, height, generations int, contents *generation) Conway {
 	return conway
 }
 
+// Set the number of times the GIF animation of this game is repeated as in
+// gif.GIF, i.e., 0 means forever (the default), -1 means that it is shown
+// only once and any other number n means that it is shown n+1 times
+func (game *Conway) SetLoopCount(count int) error {
+
+	if count < -1 || count > math.MaxUint16 {
+		return fmt.Errorf("The loop count %v is out of the range [-1, %v]", count, math.MaxUint16)
+	}
+	game.loopcount = count
+	return nil
+}
+
+// Set the number of generations to simulate before recording the first one
+func (game *Conway) SetBurnIn(burnin int) {
+	game.burnin = burnin
+}
+
+// Set the frame selector used to decide what generations are rendered. If none
+// is given, all generations are rendered
+func (game *Conway) SetFrameSelector(selector FrameSelector) {
+	game.selector = selector
+}
+
+// Return the frame selector of this game, or nil if all generations are
+// rendered
+func (game *Conway) FrameSelector() FrameSelector {
+	return game.selector
+}
+
+// Return the statistics of the burn-in phase. In case no burn-in was
+// requested, all values are null
+func (game *Conway) BurnInStats() BurnInStats {
+	return game.burninStats
+}
+
+// Simulate the burn-in generations, if any. The last generation simulated
+// becomes the first one of the game
+func (game *Conway) burnIn() {
+
+	if game.burnin <= 0 {
+		return
+	}
+
+	start := time.Now()
+	current := game.generations[0]
+	game.burninStats.InitialPopulation = current.Population()
+
+	// simulate all generations of the burn-in phase without computing colours
+	for igeneration := 0; igeneration < game.burnin; igeneration++ {
+		if game.unbounded {
+			current = current.expand()
+		}
+		current = current.advance()
+	}
+
+	// and now create the first generation of the game with the contents of
+	// the last one, so that its colours are properly computed
+	first := current.empty(game.generations[0].nbgeneration)
+	if current.species != nil {
+		first.SetSpecies(current.Species(), current.nbspecies)
+	} else if current.volume != nil {
+		first.SetVolume(current.Volume(), current.volume.depth, current.volume.rule, current.volume.projection)
+	} else if current.continuum != nil {
+		first.SetValues(current.Values(), current.continuum.rule)
+	} else if !current.isLife() || current.transition != nil {
+		first.SetStates(current.States())
+	} else {
+		first.Set(current.Contents())
+		if current.ages != nil {
+			first.ages = append([]int(nil), current.ages...)
+		}
+	}
+	game.generations[0] = first
+
+	game.burninStats.Generations = game.burnin
+	game.burninStats.FinalPopulation = first.Population()
+	game.burninStats.Elapsed = time.Since(start)
+}
+
 // Run the entire game and generate all generations from the initial population
-// in the given instance of the Conway's Game
+// in the given instance of the Conway's Game. In case a burn-in was requested,
+// the initial population is first evolved the given number of generations
 func (game *Conway) Run() {
 
+	// other engines are simulated separately
+	switch game.engine {
+	case SparseEngine:
+		game.runSparse()
+		return
+	case HashLifeEngine:
+		game.runHashLife()
+		return
+	}
+
+	game.runDense(nil)
+}
+
+// simulate all generations of this game over a dense board, starting with the
+// burn-in phase, if any. If emit is given, it is invoked with the index of
+// every generation as soon as it is computed, and the simulation stops as
+// soon as it returns an error, which is returned
+func (game *Conway) runDense(emit func(index int) error) error {
+
+	if emit == nil {
+		emit = func(int) error { return nil }
+	}
+
+	// simulate the burn-in phase, if any, and direct the first generation
+	game.burnIn()
+	if game.direct(0) || game.satisfied(0) {
+		game.stop(0)
+		return emit(0)
+	}
+	if err := emit(0); err != nil {
+		return err
+	}
+
 	// for all generations but the first one
 	for igeneration := 1; igeneration < game.nbgenerations; igeneration++ {
 
-		// compute the generation next to the previous one
-		game.generations[igeneration] = game.generations[igeneration-1].Next()
+		// compute the generation next to the previous one. In unbounded games,
+		// the previous generation is expanded first if necessary so that
+		// there is room for all births
+		previous := game.generations[igeneration-1]
+		if game.unbounded {
+			previous = previous.expand()
+		}
+		game.generations[igeneration] = game.next(previous)
+		game.perturb(game.generations[igeneration], previous, igeneration)
+		stopped := game.direct(igeneration) || game.satisfied(igeneration)
+		if stopped {
+			game.stop(igeneration)
+		}
+		if err := emit(igeneration); err != nil || stopped {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return the paletted image of the generation with the given index. If average
+// has a value strictly greater than 1 then the color index of each cell (either
+// alive of dead) is averaged over the last "average" generations
+func (game *Conway) frame(index, average int) *image.Paletted {
+
+	// stages are applied in the given order to the image of the generation
+	stages := []frameStage{
+		func(index int, img *image.Paletted) *image.Paletted {
+			return game.drawTrail(index, average, img)
+		},
+		game.shape,
+		anyGeneration(game.crop),
+		anyGeneration(game.resize),
+		anyGeneration(game.drawGlyphs),
+		anyGeneration(game.drawGrid),
+		game.annotate,
+		game.film,
+		anyGeneration(game.cropAutomatically),
+		anyGeneration(game.drawBackground),
+		anyGeneration(game.applyEffects),
+		game.drawHUD,
+	}
+	img := game.uncropped(index, average)
+	for _, stage := range stages {
+		img = stage(index, img)
+	}
+	return img
+}
+
+// Return the frame of the generation with the given index as it is shown in
+// animations, i.e., with the aspect ratio or the cell size of this game and
+// everything drawn over it, along with an error if it has not been computed
+func (game *Conway) Frame(index int) (*image.Paletted, error) {
+
+	if index < 0 || index >= game.nbgenerations || !game.computed(index) {
+		return nil, fmt.Errorf("The generation %v has not been computed", index)
 	}
+	return game.frame(index, 1), nil
+}
+
+// return the paletted image of the generation with the given index as frame
+// does, but including the halo of this game, if any, and without annotations
+func (game *Conway) uncropped(index, average int) *image.Paletted {
+
+	// frames of other engines and unbounded games are rendered separately
+	if game.engine != DenseEngine {
+		return game.averagedFrame(index, average, game.renderCells)
+	}
+	if game.unbounded {
+		return game.averagedFrame(index, average, func(index int) *image.Paletted {
+			return game.render(game.generations[index])
+		})
+	}
+
+	// if no average has been requested then just copy the i-th generation
+	// to the GIF image straight ahead
+	if average <= 1 {
+		return (*image.Paletted)(&game.generations[index].img)
+	}
+
+	// otherwise, update the contents of each pixel with the average of the
+	// contents of the last "average" frames over a new image that is stored in
+	// the GIF. For this, all attributes of the image of this generation are
+	// copied but with a brand new slice of pixels
+	img := &image.Paletted{
+		Pix:     make([]uint8, len(game.generations[index].img.Pix)),
+		Stride:  game.generations[index].img.Stride,
+		Rect:    game.generations[index].img.Rect,
+		Palette: game.generations[index].img.Palette}
+
+	// compute the first and last generation to use for computing the average
+	// of colors over all pixels
+	lower, upper := getInterval(0, game.nbgenerations-1, index, average)
+
+	// for all "logical" positions of this image
+	for x := 0; x <= game.width; x++ {
+		for y := 0; y <= game.height; y++ {
+
+			// get the color to use in pixel (x, y), as the average of the
+			// color index in the same position of all the previous "average"
+			// generations
+			indices := make([]uint8, 1+upper-lower)
+			for i := lower; i <= upper; i++ {
+				indices[i-lower] = game.generations[i].ColorIndexAt(x, y)
+			}
+			c := getAverage(indices)
+
+			// and now set the color using the aspect ratio of this frame
+			for xoffset := 0; xoffset < game.generations[index].ratio.X; xoffset++ {
+				for yoffset := 0; yoffset < game.generations[index].ratio.Y; yoffset++ {
+					img.SetColorIndex(x*game.generations[index].ratio.X+xoffset,
+						y*game.generations[index].ratio.Y+yoffset, c)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// return the paletted image of the generation with the given index rendered
+// with the given function, which is used by games whose generations are not
+// directly stored as images. If average is larger than one, the color index of
+// every pixel is the average of the same pixel in the last average frames
+// which have been computed
+func (game *Conway) averagedFrame(index, average int, render func(index int) *image.Paletted) *image.Paletted {
+
+	if average <= 1 {
+		return render(index)
+	}
+
+	lower, upper := getInterval(0, game.nbgenerations-1, index, average)
+	frames := make([]*image.Paletted, 0, 1+upper-lower)
+	for i := lower; i <= upper; i++ {
+		if game.computed(i) {
+			frames = append(frames, render(i))
+		}
+	}
+
+	// the frame of this generation is overwritten with the average
+	img := frames[len(frames)-1]
+	indices := make([]uint8, len(frames))
+	for p := range img.Pix {
+		for i, frame := range frames {
+			indices[i] = frame.Pix[p]
+		}
+		img.Pix[p] = getAverage(indices)
+	}
+	return img
 }
 
 // return a gif animation of the Conway's Game with the given delay in 100th of
 // a second between frames, and an initial delay equal to delay0 100th of a
 // second. If average has a value strictly greater than 1 then the color index
 // of each cell (either alive of dead) is averaged over the last "average"
-// generations
+// generations. Only those generations chosen by the frame selector of this
+// game (if any) are rendered, and the animation is repeated as many times as
+// given with SetLoopCount
 func (game *Conway) GetGIF(delay0, delay, average int) gif.GIF {
 
 	// create an array of images and delays between successive frames
-	var delays []int = make([]int, game.nbgenerations)
-	var images []*image.Paletted = make([]*image.Paletted, game.nbgenerations)
+	var delays []int
+	var images []*image.Paletted
 
-	// transform each generation of the game into a paletted image
-	for index, generation := range game.generations {
-		if index == 0 {
-			delays[index] = delay0
-		} else {
-			delays[index] = delay
+	// transform each selected generation of the game into a paletted image
+	for index := range game.generations {
+		if game.selector != nil && !game.selector(index) {
+			continue
 		}
-
-		// if no average has been requested then just copy the i-th generation
-		// to the GIF image straight ahead
-		if average <= 1 {
-			images[index] = (*image.Paletted)(&generation.img)
+		if len(images) == 0 {
+			delays = append(delays, delay0)
 		} else {
-
-			// otherwise, update the contents of each pixel with the average of
-			// the contents of the last "average" frames over a new image that
-			// is stored in the GIF. For this, all attributes of the image of
-			// this generation are copied but with a brand new slice of pixels
-			images[index] = &image.Paletted{
-				Pix:     make([]uint8, len(game.generations[index].img.Pix)),
-				Stride:  game.generations[index].img.Stride,
-				Rect:    game.generations[index].img.Rect,
-				Palette: game.generations[index].img.Palette}
-
-			// compute the first and last generation to use for computing the
-			// average of colors over all pixels
-			lower, upper := getInterval(0, game.nbgenerations-1, index, average)
-
-			// for all "logical" positions of this image
-			for x := 0; x <= game.width; x++ {
-				for y := 0; y <= game.height; y++ {
-
-					// get the color to use in pixel (x, y), as the average of
-					// the color index in the same position of all the previous
-					// "average" generations
-					indices := make([]uint8, 1+upper-lower)
-					for i := lower; i <= upper; i++ {
-						indices[i-lower] = game.generations[i].ColorIndexAt(x, y)
-					}
-					c := getAverage(indices)
-
-					// and now set the color using the aspect ratio of this frame
-					for xoffset := 0; xoffset < game.generations[index].ratio.X; xoffset++ {
-						for yoffset := 0; yoffset < game.generations[index].ratio.Y; yoffset++ {
-							images[index].SetColorIndex(x*game.generations[index].ratio.X+xoffset,
-								y*game.generations[index].ratio.Y+yoffset, c)
-						}
-					}
-				}
-			}
+			delays = append(delays, delay)
 		}
+		images = append(images, game.frame(index, average))
 	}
 
 	// and now return the GIF image
-	return gif.GIF{Delay: delays, Image: images}
+	return gif.GIF{Delay: delays, Image: images, LoopCount: game.loopcount}
 }
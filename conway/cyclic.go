@@ -0,0 +1,167 @@
+// The cyclic cellular automaton gives every cell one of k states arranged in
+// a cycle, and a cell is consumed by the next state in the cycle if enough of
+// its neighbours have it. Starting from random soups, it self-organizes into
+// spirals known as demons. Rules are written in the notation of MCell, e.g.,
+// R1/T1/C14/NN, which gives the range of the neighbourhood, the threshold of
+// neighbours with the next state, the number of states and the neighbourhood,
+// either von Neumann (NN) or Moore (NM).
+//
+// The rock-paper-scissors automaton is the cyclic automaton with three species
+// and the Moore neighbourhood, where every species is consumed by its predator
+// if at least as many neighbours as the threshold belong to it
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// CyclicRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// A cyclic rule consists of the number of States in the cycle, and the
+// Threshold of neighbours within the given Range which must have the next
+// state for a cell to be consumed. Neighbourhoods are von Neumann
+// neighbourhoods, i.e., diamonds, if VonNeumann is true, and Moore
+// neighbourhoods, i.e., squares, otherwise
+type CyclicRule struct {
+	Range, Threshold, States int
+	VonNeumann               bool
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the cyclic rule given in spec in the notation of MCell, e.g.,
+// R1/T1/C14/NN, along with an error if it is not well formed
+func ParseCyclicRule(spec string) (rule CyclicRule, err error) {
+
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(spec)), "/")
+	if len(parts) != 4 {
+		return rule, fmt.Errorf("Syntax error in the cyclic rule '%v'", spec)
+	}
+	for i, field := range []*int{&rule.Range, &rule.Threshold, &rule.States} {
+		prefix := "RTC"[i : i+1]
+		value, found := strings.CutPrefix(parts[i], prefix)
+		if !found {
+			return rule, fmt.Errorf("Missing '%v' in the cyclic rule '%v'", prefix, spec)
+		}
+		if *field, err = strconv.Atoi(value); err != nil {
+			return rule, fmt.Errorf("Wrong value '%v' in the cyclic rule '%v'", parts[i], spec)
+		}
+	}
+	switch parts[3] {
+	case "NN":
+		rule.VonNeumann = true
+	case "NM":
+	default:
+		return rule, fmt.Errorf("Unknown neighbourhood '%v' in the cyclic rule '%v'", parts[3], spec)
+	}
+	return rule, nil
+}
+
+// Return the rule of the rock-paper-scissors automaton, where every species is
+// consumed by the next one if it has at least the given number of neighbours of
+// that species
+func RockPaperScissorsRule(threshold int) CyclicRule {
+	return CyclicRule{Range: 1, Threshold: threshold, States: 3}
+}
+
+// Return a palette for a cyclic automaton with the given number of states.
+// If colours are given, the palette samples them evenly, and otherwise states
+// are evenly distributed over the hue wheel
+func CyclicPalette(colors color.Palette, states int) color.Palette {
+
+	if len(colors) == 0 {
+		return AntPalette(states + 1)[1:]
+	}
+	palette := make(color.Palette, states)
+	for i := range palette {
+		palette[i] = colors[i*(len(colors)-1)/max(states-1, 1)]
+	}
+	return palette
+}
+
+// methods
+
+// Return the specification of this rule in the notation of MCell
+func (rule CyclicRule) String() string {
+
+	neighbourhood := "NM"
+	if rule.VonNeumann {
+		neighbourhood = "NN"
+	}
+	return fmt.Sprintf("R%v/T%v/C%v/%v", rule.Range, rule.Threshold, rule.States, neighbourhood)
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the rule of cyclic automata. States are stored directly as colour
+// indexes, so that the palette must have as many colours as states
+func (g *generation) SetCyclicRule(rule CyclicRule) error {
+
+	if rule.States < 2 || rule.States > len(g.img.Palette) {
+		return fmt.Errorf("The number of states must be in the range [2, %v]", len(g.img.Palette))
+	}
+	if rule.Range < 1 {
+		return errors.New("The range of the neighbourhood must be strictly positive")
+	}
+	if rule.Threshold < 1 {
+		return errors.New("The threshold must be strictly positive")
+	}
+	g.cyclic = &rule
+	return nil
+}
+
+// return the next generation of a cyclic automaton, where every cell whose
+// neighbourhood contains at least as many cells with the next state as the
+// threshold takes it
+func (g *generation) nextCyclic() *generation {
+
+	next := g.empty(1 + g.nbgeneration)
+	rule := g.cyclic
+	width, height := g.dimensions()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			state := g.ColorIndexAt(x, y)
+			successor := uint8((int(state) + 1) % rule.States)
+
+			// count the neighbours with the next state, taking into account
+			// the boundary condition
+			count := 0
+			for dy := -rule.Range; dy <= rule.Range && count < rule.Threshold; dy++ {
+				for dx := -rule.Range; dx <= rule.Range; dx++ {
+					if (dx == 0 && dy == 0) || (rule.VonNeumann && abs(dx)+abs(dy) > rule.Range) {
+						continue
+					}
+					if p, ok := g.locate(x+dx, y+dy); ok && g.ColorIndexAt(p.X, p.Y) == successor {
+						count++
+					}
+				}
+			}
+			if count >= rule.Threshold {
+				state = successor
+			}
+			next.SetColorIndex(x, y, state)
+		}
+	}
+	return next
+}
+
+// return the absolute value of the given integer
+func abs(value int) int {
+
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,293 @@
+// Runs can be recorded as deltas: only the cells born and those that died are
+// stored for every generation, so that the living cells of any generation can
+// be reconstructed by replaying all deltas up to it. Recordings can be used
+// to compare arbitrary generations of a run without simulating it again
+
+package conway
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Recording
+// ----------------------------------------------------------------------------
+
+// type
+
+// The changes of a single generation with respect to the previous one
+// recorded
+type delta struct {
+	index      int
+	born, died []image.Point
+}
+
+// A recording stores the deltas of all generations computed in a run. The
+// location of cells is relative to the upper-left corner of the board
+// rendered, with the given dimensions, so that cells in the halo or off the
+// original board of unbounded games have coordinates out of it
+type Recording struct {
+	Width, Height int
+	deltas        []delta
+}
+
+// methods
+
+// Return the indices of all generations recorded, in increasing order
+func (r *Recording) Generations() []int {
+
+	result := make([]int, len(r.deltas))
+	for i, d := range r.deltas {
+		result[i] = d.index
+	}
+	return result
+}
+
+// Return the living cells of the generation with the given index, or an error
+// if it was not recorded
+func (r *Recording) Alive(index int) (map[image.Point]bool, error) {
+
+	alive := make(map[image.Point]bool)
+	for _, d := range r.deltas {
+		if d.index > index {
+			break
+		}
+		for _, p := range d.died {
+			delete(alive, p)
+		}
+		for _, p := range d.born {
+			alive[p] = true
+		}
+		if d.index == index {
+			return alive, nil
+		}
+	}
+	return nil, fmt.Errorf("Generation %v was not recorded", index)
+}
+
+// Return the differences between the generations with the given indices, or
+// an error if any of them was not recorded
+func (r *Recording) Diff(from, to int) (Diff, error) {
+
+	before, err := r.Alive(from)
+	if err != nil {
+		return Diff{}, err
+	}
+	after, err := r.Alive(to)
+	if err != nil {
+		return Diff{}, err
+	}
+
+	diff := Diff{From: from, To: to, Width: r.Width, Height: r.Height}
+	for p := range after {
+		if before[p] {
+			diff.Persisted = append(diff.Persisted, p)
+		} else {
+			diff.Born = append(diff.Born, p)
+		}
+	}
+	for p := range before {
+		if !after[p] {
+			diff.Died = append(diff.Died, p)
+		}
+	}
+	sortPoints(diff.Born)
+	sortPoints(diff.Died)
+	sortPoints(diff.Persisted)
+	return diff, nil
+}
+
+// Write the recording to the given writer in a plain text format, with one
+// entry per line:
+//
+//	size WIDTH HEIGHT
+//	generation INDEX
+//	born X Y
+//	died X Y
+//
+// where the cells born and those that died are listed after the generation
+// they belong to
+func (r *Recording) Write(w io.Writer) error {
+
+	writer := bufio.NewWriter(w)
+	fmt.Fprintln(writer, "# conway-game recording")
+	fmt.Fprintf(writer, "size %v %v\n", r.Width, r.Height)
+	for _, d := range r.deltas {
+		fmt.Fprintf(writer, "generation %v\n", d.index)
+		for _, p := range d.born {
+			fmt.Fprintf(writer, "born %v %v\n", p.X, p.Y)
+		}
+		for _, p := range d.died {
+			fmt.Fprintf(writer, "died %v %v\n", p.X, p.Y)
+		}
+	}
+	return writer.Flush()
+}
+
+// Diff
+// ----------------------------------------------------------------------------
+
+// type
+
+// The differences between two generations of a recording consist of the
+// cells born, those that died and those that stayed alive
+type Diff struct {
+	From, To              int
+	Width, Height         int
+	Born, Died, Persisted []image.Point
+}
+
+// methods
+
+// Return an image of the board where cells born are shown in green, those
+// that died in red and those that persisted in grey. Every cell is drawn with
+// the given aspect ratio, and cells off the board are not shown
+func (d Diff) Image(ratio AspectRatio) *image.Paletted {
+
+	img := image.NewPaletted(image.Rect(0, 0, d.Width*ratio.X, d.Height*ratio.Y),
+		color.Palette{
+			color.Black,
+			color.RGBA{0x00, 0xc8, 0x00, 0xff},
+			color.RGBA{0xdc, 0x14, 0x3c, 0xff},
+			color.RGBA{0x80, 0x80, 0x80, 0xff}})
+	for c, cells := range [][]image.Point{d.Born, d.Died, d.Persisted} {
+		for _, p := range cells {
+			if p.X < 0 || p.X >= d.Width || p.Y < 0 || p.Y >= d.Height {
+				continue
+			}
+			for xpixel := 0; xpixel < ratio.X; xpixel++ {
+				for ypixel := 0; ypixel < ratio.Y; ypixel++ {
+					img.SetColorIndex(p.X*ratio.X+xpixel, p.Y*ratio.Y+ypixel, uint8(1+c))
+				}
+			}
+		}
+	}
+	return img
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// return the living cells of the generation with the given index, which must
+// have been computed, relative to the upper-left corner of the board rendered
+func (game *Conway) living(index int) []image.Point {
+
+	var result []image.Point
+	if game.engine != DenseEngine {
+		origin := game.generations[0].origin
+		for p := range game.cells[index] {
+			result = append(result, p.Sub(origin))
+		}
+		return result
+	}
+
+	g := game.generations[index]
+	width, height := g.img.Rect.Max.X/g.ratio.X, g.img.Rect.Max.Y/g.ratio.Y
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if g.ColorIndexAt(x, y) != 0 {
+				result = append(result, image.Point{X: x, Y: y}.Sub(g.origin))
+			}
+		}
+	}
+	return result
+}
+
+// Return a recording of this game with the deltas of all generations
+// computed. The game must have been run before
+func (game *Conway) Recording() *Recording {
+
+	recording := &Recording{Width: game.width - 2*game.halo, Height: game.height - 2*game.halo}
+	previous := make(map[image.Point]bool)
+	for index := 0; index < game.nbgenerations; index++ {
+		if !game.computed(index) {
+			continue
+		}
+		current := make(map[image.Point]bool)
+		d := delta{index: index}
+		for _, p := range game.living(index) {
+			current[p] = true
+			if !previous[p] {
+				d.born = append(d.born, p)
+			}
+		}
+		for p := range previous {
+			if !current[p] {
+				d.died = append(d.died, p)
+			}
+		}
+		sortPoints(d.born)
+		sortPoints(d.died)
+		recording.deltas = append(recording.deltas, d)
+		previous = current
+	}
+	return recording
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// sort the given points by rows and then by columns
+func sortPoints(points []image.Point) {
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Y < points[j].Y ||
+			(points[i].Y == points[j].Y && points[i].X < points[j].X)
+	})
+}
+
+// Read a recording from the given reader in the format produced by Write.
+// Lines starting with '#' are ignored. In case the contents are not well
+// formed an error is returned
+func ReadRecording(r io.Reader) (*Recording, error) {
+
+	recording := &Recording{}
+	scanner := bufio.NewScanner(r)
+	for nbline := 1; scanner.Scan(); nbline++ {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// every line consists of a keyword and its values
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("Syntax error in line %v", nbline)
+		}
+		switch fields[0] {
+		case "size":
+			if _, err := fmt.Sscanf(fields[1], "%d %d", &recording.Width, &recording.Height); err != nil {
+				return nil, fmt.Errorf("Wrong size in line %v", nbline)
+			}
+		case "generation":
+			var index int
+			if _, err := fmt.Sscanf(fields[1], "%d", &index); err != nil ||
+				(len(recording.deltas) > 0 && index <= recording.deltas[len(recording.deltas)-1].index) {
+				return nil, fmt.Errorf("Wrong generation in line %v", nbline)
+			}
+			recording.deltas = append(recording.deltas, delta{index: index})
+		case "born", "died":
+			var p image.Point
+			if _, err := fmt.Sscanf(fields[1], "%d %d", &p.X, &p.Y); err != nil || len(recording.deltas) == 0 {
+				return nil, fmt.Errorf("Wrong cell in line %v", nbline)
+			}
+			d := &recording.deltas[len(recording.deltas)-1]
+			if fields[0] == "born" {
+				d.born = append(d.born, p)
+			} else {
+				d.died = append(d.died, p)
+			}
+		default:
+			return nil, fmt.Errorf("Unknown keyword '%v' in line %v", fields[0], nbline)
+		}
+	}
+
+	return recording, scanner.Err()
+}
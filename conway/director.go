@@ -0,0 +1,481 @@
+// The director executes a script with a timeline of cues, e.g., seeding the
+// board at generation 0, placing a pattern at generation 50, zooming into a
+// region at generation 200, switching the palette at generation 400 and
+// stopping once the game stabilizes. Edits of the board are applied right
+// after computing the generation they refer to, whereas the camera and the
+// palette are applied when rendering frames. Scripts are written in a subset
+// of YAML: a sequence of mappings with scalar values, e.g.:
+//
+//	# seed the board and place a glider
+//	- at: 0
+//	  seed: 42
+//	  population: 400
+//	- at: 50
+//	  place: glider
+//	  x: 10
+//	  y: 10
+//	- at: 200
+//	  zoom: 20,20,60,60
+//	- at: 400
+//	  palette: "#000000:#0000ff:#ffffff"
+//	- stop: stable
+//
+// Every cue is given the generation it refers to with "at" (0 by default) and
+// exactly one action among seed, place, zoom, palette and stop
+
+package conway
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Cue
+// ----------------------------------------------------------------------------
+
+// type
+
+// The action performed by a cue
+type CueAction int
+
+const (
+	// fill the board with a random soup
+	SeedCue CueAction = iota
+
+	// place a pattern on the board
+	PlaceCue
+
+	// zoom into a region of the board
+	ZoomCue
+
+	// switch the palette
+	PaletteCue
+
+	// stop the game
+	StopCue
+)
+
+// A cue performs its Action at the generation given in At, starting from 0
+// with the first generation of the game:
+//
+//   - SeedCue: the board is filled with a soup of Population living cells
+//     located randomly with the given Seed. If the population is negative, a
+//     quarter of the cells are alive
+//
+//   - PlaceCue: the living Cells of a pattern are added to the board with its
+//     upper-left corner at Location
+//
+//   - ZoomCue: the given Region of the board is magnified to fill the whole
+//     frame from this generation on. An empty region shows the whole board
+//     again
+//
+//   - PaletteCue: frames are rendered with the given Palette from this
+//     generation on
+//
+//   - StopCue: the game stops at this generation or, if Stable is true, at
+//     the first generation from this one on which repeats any of the last two
+type Cue struct {
+	At         int
+	Action     CueAction
+	Seed       int64
+	Population int
+	Cells      []image.Point
+	Location   image.Point
+	Region     image.Rectangle
+	Palette    color.Palette
+	Stable     bool
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the cues of the script given in the reader (see the description of
+// the format above), along with an error if it is not well formed. Patterns
+// are given either as the name of an object of the dictionary, e.g., glider,
+// or as rows separated by '/', where living cells are shown as 'o', e.g.,
+// ".o./..o/ooo". Regions are given as x0,y0,x1,y1, where the last corner is
+// excluded, or none, and palettes as gradients DEAD:FROM:TO in the format
+// #RRGGBB. Soups are seeded with a quarter of the cells alive unless a
+// population is given
+func ReadScript(r io.Reader) ([]Cue, error) {
+
+	// first, read all mappings along with the line where every one starts
+	var items []map[string]string
+	var lines []int
+	scanner := bufio.NewScanner(r)
+	for nbline := 1; scanner.Scan(); nbline++ {
+		line := stripComment(scanner.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		if rest, found := strings.CutPrefix(trimmed, "-"); found {
+			items, lines = append(items, make(map[string]string)), append(lines, nbline)
+			if trimmed = strings.TrimSpace(rest); trimmed == "" {
+				continue
+			}
+		} else if len(items) == 0 || trimmed == line {
+			return nil, fmt.Errorf("Every cue must start with '-' in line %v", nbline)
+		}
+		key, value, found := strings.Cut(trimmed, ":")
+		if !found {
+			return nil, fmt.Errorf("Syntax error in line %v", nbline)
+		}
+		key, value = strings.TrimSpace(key), unquote(strings.TrimSpace(value))
+		if _, ok := items[len(items)-1][key]; ok {
+			return nil, fmt.Errorf("Duplicated key '%v' in line %v", key, nbline)
+		}
+		items[len(items)-1][key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// and now translate every mapping into a cue
+	var cues []Cue
+	for i, item := range items {
+		cue, err := parseCue(item)
+		if err != nil {
+			return nil, fmt.Errorf("%v in the cue starting in line %v", err, lines[i])
+		}
+		cues = append(cues, cue)
+	}
+	return cues, nil
+}
+
+// return the given line without its comment, if any. Hashes within quotes are
+// not taken as comments
+func stripComment(line string) string {
+
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// return the given value without its surrounding quotes, if any
+func unquote(value string) string {
+
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// return the cue given in the mapping of a script, along with an error if it
+// is not well formed
+func parseCue(item map[string]string) (cue Cue, err error) {
+
+	integer := func(key string) (int, error) {
+		value, err := strconv.Atoi(item[key])
+		if err != nil {
+			return 0, fmt.Errorf("Wrong value of '%v'", key)
+		}
+		return value, nil
+	}
+
+	// first, get the generation, if any
+	if _, ok := item["at"]; ok {
+		if cue.At, err = integer("at"); err != nil {
+			return
+		}
+		if cue.At < 0 {
+			return cue, errors.New("Negative generation")
+		}
+	}
+
+	// and verify that there is exactly one action
+	var actions []string
+	for _, action := range []string{"seed", "place", "zoom", "palette", "stop"} {
+		if _, ok := item[action]; ok {
+			actions = append(actions, action)
+		}
+	}
+	if len(actions) != 1 {
+		return cue, fmt.Errorf("Exactly one action is expected but %v were given", len(actions))
+	}
+
+	// keys which do not belong to the action are not allowed
+	allowed := map[string][]string{
+		"seed":  {"population"},
+		"place": {"x", "y"},
+	}
+	for key := range item {
+		if key == "at" || key == actions[0] {
+			continue
+		}
+		found := false
+		for _, other := range allowed[actions[0]] {
+			found = found || key == other
+		}
+		if !found {
+			return cue, fmt.Errorf("Unexpected key '%v'", key)
+		}
+	}
+
+	switch actions[0] {
+	case "seed":
+		cue.Action = SeedCue
+		if cue.Seed, err = strconv.ParseInt(item["seed"], 10, 64); err != nil {
+			return cue, errors.New("Wrong value of 'seed'")
+		}
+		cue.Population = -1
+		if _, ok := item["population"]; ok {
+			if cue.Population, err = integer("population"); err != nil {
+				return
+			}
+			if cue.Population < 0 {
+				return cue, errors.New("Negative population")
+			}
+		}
+	case "place":
+		cue.Action = PlaceCue
+		if cue.Cells, err = lookupPattern(item["place"]); err != nil {
+			return
+		}
+		if _, ok := item["x"]; ok {
+			if cue.Location.X, err = integer("x"); err != nil {
+				return
+			}
+		}
+		if _, ok := item["y"]; ok {
+			if cue.Location.Y, err = integer("y"); err != nil {
+				return
+			}
+		}
+	case "zoom":
+		cue.Action = ZoomCue
+		if item["zoom"] == "none" {
+			break
+		}
+		var corners [4]int
+		values := strings.Split(item["zoom"], ",")
+		if len(values) != len(corners) {
+			return cue, fmt.Errorf("Wrong region '%v'", item["zoom"])
+		}
+		for i, value := range values {
+			if corners[i], err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return cue, fmt.Errorf("Wrong region '%v'", item["zoom"])
+			}
+		}
+		cue.Region = image.Rect(corners[0], corners[1], corners[2], corners[3])
+		if cue.Region.Empty() {
+			return cue, fmt.Errorf("Empty region '%v'", item["zoom"])
+		}
+	case "palette":
+		cue.Action = PaletteCue
+		var colors [3]color.Color
+		values := strings.Split(item["palette"], ":")
+		if len(values) != len(colors) {
+			return cue, fmt.Errorf("Wrong palette '%v'", item["palette"])
+		}
+		for i, value := range values {
+			if colors[i], err = parseColor(strings.TrimSpace(value)); err != nil {
+				return
+			}
+		}
+		cue.Palette = GradientPalette(colors[0], colors[1], colors[2])
+	case "stop":
+		cue.Action = StopCue
+		switch item["stop"] {
+		case "stable":
+			cue.Stable = true
+		case "now":
+		default:
+			return cue, fmt.Errorf("Unknown stop condition '%v'", item["stop"])
+		}
+	}
+	return cue, nil
+}
+
+// return the cells of the given pattern, either the name of an object of the
+// dictionary, the name of a pattern of the library, an apgcode or rows
+// separated by '/' where living cells are shown as 'o'
+func lookupPattern(pattern string) ([]image.Point, error) {
+
+	for _, object := range NewDictionary().Objects() {
+		if strings.EqualFold(object.Name, pattern) {
+			return object.Cells, nil
+		}
+	}
+	if p, err := LibraryPattern(pattern); err == nil {
+		return p.Cells, nil
+	}
+	if IsApgcode(pattern) {
+		p, err := DecodeApgcode(pattern)
+		return p.Cells, err
+	}
+	if strings.Trim(pattern, "o./") != "" {
+		return nil, fmt.Errorf("Unknown pattern '%v'", pattern)
+	}
+	return patternCells(pattern), nil
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// return whether this generation has the same living cells as the given one
+func (g *generation) sameCells(other *generation) bool {
+
+	if g.img.Rect != other.img.Rect {
+		return false
+	}
+	width, height := g.dimensions()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (g.ColorIndexAt(x, y) != 0) != (other.ColorIndexAt(x, y) != 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the script of cues executed by the director. Seeds and patterns can
+// only be placed in Life-like rules, and scripts are only executed by the
+// dense engine
+func (game *Conway) SetScript(cues []Cue) error {
+
+	if game.engine != DenseEngine {
+		return errors.New("Scripts can only be executed by the dense engine")
+	}
+	for _, cue := range cues {
+		if (cue.Action == SeedCue || cue.Action == PlaceCue) && !game.generations[0].isLife() {
+			return errors.New("Seeds and patterns can only be placed in Life-like rules")
+		}
+		if cue.Action == PaletteCue && len(cue.Palette) < 2 {
+			return errors.New("Palettes must have at least two colours")
+		}
+	}
+	game.script = cues
+	return nil
+}
+
+// apply the edits of the script to the generation with the given index, and
+// return whether the game has to stop at it
+func (game *Conway) direct(index int) (stop bool) {
+
+	g := game.generations[index]
+	offset := game.board().Min.Add(g.origin)
+	for _, cue := range game.script {
+		switch {
+		case cue.Action == SeedCue && cue.At == index:
+
+			// the whole board is cleared and filled with a new soup
+			board := game.board()
+			rng := rand.New(rand.NewSource(cue.Seed))
+			cells := rng.Perm(board.Dx() * board.Dy())
+			population := cue.Population
+			if population < 0 {
+				population = len(cells) / 4
+			}
+			for y := 0; y < board.Dy(); y++ {
+				for x := 0; x < board.Dx(); x++ {
+					g.SetColorIndex(offset.X+x, offset.Y+y, 0)
+				}
+			}
+			for _, cell := range cells[:min(population, len(cells))] {
+				x, y := offset.X+cell%board.Dx(), offset.Y+cell/board.Dx()
+				g.SetColorIndex(x, y, g.cellColor(x, y))
+			}
+		case cue.Action == PlaceCue && cue.At == index:
+			for _, cell := range cue.Cells {
+				if p, ok := g.locate(offset.X+cue.Location.X+cell.X, offset.Y+cue.Location.Y+cell.Y); ok {
+					g.SetColorIndex(p.X, p.Y, g.cellColor(p.X, p.Y))
+				}
+			}
+		case cue.Action == StopCue && cue.At == index && !cue.Stable:
+			stop = true
+		case cue.Action == StopCue && cue.At <= index && cue.Stable:
+			for previous := max(index-2, 0); previous < index; previous++ {
+				stop = stop || g.sameCells(game.generations[previous])
+			}
+		}
+	}
+	return
+}
+
+// stop this game at the generation with the given index, which becomes the
+// last one
+func (game *Conway) stop(index int) {
+
+	game.generations = game.generations[:index+1]
+	game.nbgenerations = index + 1
+}
+
+// return the given frame of the generation with the given index as seen by
+// the camera of the script, i.e., magnified to the last region zoomed into and
+// rendered with the last palette switched to, if any. The given image is
+// returned if there is none
+func (game *Conway) film(index int, img *image.Paletted) *image.Paletted {
+
+	var region image.Rectangle
+	var palette color.Palette
+	for _, cue := range game.script {
+		if cue.At > index {
+			continue
+		}
+		switch cue.Action {
+		case ZoomCue:
+			region = cue.Region
+		case PaletteCue:
+			palette = cue.Palette
+		}
+	}
+	if region.Empty() && palette == nil {
+		return img
+	}
+
+	result := img
+	if !region.Empty() {
+
+		// magnify the pixels of the region to the whole frame with the
+		// nearest neighbour
+		pixels := game.cellRect(region.Min).Union(game.cellRect(region.Max.Sub(image.Pt(1, 1)))).Add(img.Rect.Min).Intersect(img.Rect)
+		result = image.NewPaletted(img.Rect, img.Palette)
+		if !pixels.Empty() {
+			for y := 0; y < img.Rect.Dy(); y++ {
+				for x := 0; x < img.Rect.Dx(); x++ {
+					result.Pix[y*result.Stride+x] = img.ColorIndexAt(
+						pixels.Min.X+x*pixels.Dx()/img.Rect.Dx(),
+						pixels.Min.Y+y*pixels.Dy()/img.Rect.Dy())
+				}
+			}
+		}
+	}
+	if palette != nil {
+
+		// the new palette is sampled, so that it has as many colours as the
+		// current one
+		sampled := make(color.Palette, len(img.Palette))
+		for i := range sampled {
+			sampled[i] = palette[i*(len(palette)-1)/max(len(sampled)-1, 1)]
+		}
+		result = &image.Paletted{Pix: result.Pix, Stride: result.Stride, Rect: result.Rect, Palette: sampled}
+	}
+	return result
+}
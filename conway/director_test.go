@@ -0,0 +1,110 @@
+package conway
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadScript(t *testing.T) {
+
+	script := `# seed the board and place a glider
+- at: 0
+  seed: 42
+  population: 400
+- at: 50
+  place: glider
+  x: 10
+  y: 10
+- at: 60   # a pattern given by rows
+  place: ".o./..o/ooo"
+-
+  at: 200
+  zoom: 20, 20, 60, 60
+- at: 300
+  zoom: none
+- at: 400
+  palette: "#000000:#0000ff:#ffffff"
+- seed: 7
+- stop: stable
+`
+	cues, err := ReadScript(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("ReadScript: %v", err)
+	}
+	glider := []image.Point{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}
+	want := []Cue{
+		{At: 0, Action: SeedCue, Seed: 42, Population: 400},
+		{At: 50, Action: PlaceCue, Location: image.Point{X: 10, Y: 10}},
+		{At: 60, Action: PlaceCue, Cells: glider},
+		{At: 200, Action: ZoomCue, Region: image.Rect(20, 20, 60, 60)},
+		{At: 300, Action: ZoomCue},
+		{At: 400, Action: PaletteCue, Palette: GradientPalette(color.RGBA{0, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}, color.RGBA{0xff, 0xff, 0xff, 0xff})},
+		{At: 0, Action: SeedCue, Seed: 7, Population: -1},
+		{At: 0, Action: StopCue, Stable: true},
+	}
+	if len(cues) != len(want) {
+		t.Fatalf("ReadScript = %v cues, want %v", len(cues), len(want))
+	}
+
+	// the glider of the dictionary might be given in any phase
+	if len(cues[1].Cells) != len(glider) {
+		t.Errorf("The glider placed has %v cells, want %v", len(cues[1].Cells), len(glider))
+	}
+	cues[1].Cells = nil
+	cues[2].Cells = sortCells(cues[2].Cells)
+	for i := range want {
+		if !reflect.DeepEqual(cues[i], want[i]) {
+			t.Errorf("cue %v = %+v, want %+v", i, cues[i], want[i])
+		}
+	}
+}
+
+func TestReadScriptErrors(t *testing.T) {
+
+	for _, test := range []struct {
+		name, script string
+	}{
+		{"missing dash", "at: 0\n"},
+		{"missing indentation", "- at: 0\nseed: 1\n"},
+		{"missing colon", "- at 0\n"},
+		{"duplicated key", "- at: 0\n  at: 1\n  stop: now\n"},
+		{"negative generation", "- at: -1\n  stop: now\n"},
+		{"wrong generation", "- at: one\n  stop: now\n"},
+		{"no action", "- at: 1\n"},
+		{"two actions", "- seed: 1\n  stop: now\n"},
+		{"unexpected key", "- seed: 1\n  x: 2\n"},
+		{"wrong seed", "- seed: many\n"},
+		{"negative population", "- seed: 1\n  population: -5\n"},
+		{"unknown pattern", "- place: nothing-like-this\n"},
+		{"wrong location", "- place: glider\n  x: left\n"},
+		{"wrong region", "- zoom: 1,2,3\n"},
+		{"empty region", "- zoom: 10,10,10,20\n"},
+		{"wrong palette", "- palette: \"#000000:#ffffff\"\n"},
+		{"wrong colour", "- palette: \"#000000:#ffffff:white\"\n"},
+		{"unknown stop", "- stop: later\n"},
+	} {
+		if cues, err := ReadScript(strings.NewReader(test.script)); err == nil {
+			t.Errorf("ReadScript of %v = %+v, want an error", test.name, cues)
+		}
+	}
+}
+
+// hashes start comments unless they are quoted or they are part of a word
+func TestStripComment(t *testing.T) {
+
+	for line, want := range map[string]string{
+		"# comment":                    "",
+		"- at: 0 # comment":            "- at: 0 ",
+		`  palette: "#000:#00f:#fff"`:  `  palette: "#000:#00f:#fff"`,
+		`  palette: '#000' # comment`:  `  palette: '#000' `,
+		"  place: a#b":                 "  place: a#b",
+		"\tstop: now\t# tab before it": "\tstop: now\t",
+	} {
+		if got := stripComment(line); got != want {
+			t.Errorf("stripComment(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Previews of generations (e.g., thumbnails, terminal renderers or the
+// previews served to web players) are downscaled by an integer factor, so that
+// every block of cells is mapped to a single pixel. Downscaling is computed
+// directly over the cells of the board rather than over their images, which
+// are magnified by the aspect ratio, and it preserves the palette
+
+package conway
+
+import (
+	"errors"
+	"image"
+)
+
+// DownscalePolicy
+// ----------------------------------------------------------------------------
+
+// type
+
+// The policy used for deciding whether a block of cells is shown alive
+type DownscalePolicy int
+
+const (
+	// blocks are alive if more than half of their cells are alive
+	MajorityPolicy DownscalePolicy = iota
+
+	// blocks are alive if any of their cells is alive
+	AnyAlivePolicy
+)
+
+// methods
+
+// return whether a block with the given number of cells, of which the given
+// number are alive, is shown alive according to this policy
+func (policy DownscalePolicy) shows(alive, cells int) bool {
+	return (policy == AnyAlivePolicy && alive > 0) ||
+		(policy == MajorityPolicy && 2*alive > cells)
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// return the cells of this generation within the given region downscaled by
+// the given factor with the given policy. Every block of factor x factor cells
+// is mapped to a single pixel, which takes the most frequent colour among the
+// living cells of the block, if it is alive
+func (g *generation) downscale(region image.Rectangle, factor int, policy DownscalePolicy) *image.Paletted {
+
+	width, height := (region.Dx()+factor-1)/factor, (region.Dy()+factor-1)/factor
+	result := image.NewPaletted(image.Rect(0, 0, width, height), g.img.Palette)
+	var counts [256]int
+	for by := 0; by < height; by++ {
+		for bx := 0; bx < width; bx++ {
+
+			// count the colours of the living cells of this block. Blocks at
+			// the edges of the region might be smaller
+			block := image.Rect(bx*factor, by*factor, (bx+1)*factor, (by+1)*factor).Add(region.Min).Intersect(region)
+			alive, colour := 0, uint8(0)
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				for x := block.Min.X; x < block.Max.X; x++ {
+					if c := g.ColorIndexAt(x, y); c != 0 {
+						alive++
+						if counts[c]++; counts[c] > counts[colour] {
+							colour = c
+						}
+					}
+				}
+			}
+			if alive > 0 {
+				counts = [256]int{}
+			}
+
+			// and decide whether it is shown alive
+			if policy.shows(alive, block.Dx()*block.Dy()) {
+				result.Pix[by*result.Stride+bx] = colour
+			}
+		}
+	}
+	return result
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return a preview of the generation with the given index, where the board
+// rendered (i.e., without the halo, if any) is downscaled by the given factor
+// with the given policy. Previews of unbounded games show only the original
+// board
+func (game *Conway) Preview(index, factor int, policy DownscalePolicy) (*image.Paletted, error) {
+
+	if factor < 1 {
+		return nil, errors.New("The downscaling factor must be strictly positive")
+	}
+	if index < 0 || index >= game.nbgenerations {
+		return nil, errors.New("Generation out of range")
+	}
+	g := game.generations[0]
+	if game.engine != DenseEngine {
+		g = game.materialize(index)
+	} else if index > 0 {
+		g = game.generations[index]
+	}
+	return g.downscale(game.board().Add(g.origin), factor, policy), nil
+}
+
+// return the region of cells of the board rendered, i.e., without the halo
+func (game *Conway) board() image.Rectangle {
+	return image.Rect(game.halo, game.halo, game.width-game.halo, game.height-game.halo)
+}
+
+// Scrubber
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return a preview of the generation with the given index, starting from 0,
+// which is downscaled by the given factor with the given policy. Annotations
+// are not drawn over previews
+func (s *Scrubber) Preview(index, factor int, policy DownscalePolicy) (*image.Paletted, error) {
+
+	if factor < 1 {
+		return nil, errors.New("The downscaling factor must be strictly positive")
+	}
+	g, err := s.generation(index)
+	if err != nil {
+		return nil, err
+	}
+	return g.downscale(s.board, factor, policy), nil
+}
@@ -0,0 +1,271 @@
+// Frames can be post-processed with effects for a more polished look, e.g., a
+// glow around living cells, the scanlines of old monitors or a vignette which
+// darkens the corners. Effects are filters applied one after the other to
+// every frame. Since frames are paletted images, the colours they produce are
+// added to the palette while there is room for them, and they take the
+// closest colour of the palette otherwise
+
+package conway
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Number of levels effects are quantized to, so that they do not produce more
+// colours than necessary
+const effectLevels = 16
+
+// Opacity of the glow right next to living cells
+const glowIntensity = 0.6
+
+// Effect
+// ----------------------------------------------------------------------------
+
+// type
+
+// An effect is a filter which returns a new frame computed from the given one
+type Effect interface {
+	Apply(img *image.Paletted) *image.Paletted
+}
+
+// Glow blends the pixels of dead cells within the given radius (in pixels) of
+// living cells with their colour, fading out with the distance
+type Glow struct {
+	Radius int
+}
+
+// Scanlines darken every other row of pixels with the given intensity in the
+// range [0, 1]
+type Scanlines struct {
+	Intensity float64
+}
+
+// Vignette darkens pixels away from the center of frames, so that the corners
+// are darkened with the given intensity in the range [0, 1]
+type Vignette struct {
+	Intensity float64
+}
+
+// a shader draws colours blended from the colours of a palette, which is
+// extended with them while there is room for them. Colours are blended at one
+// of a number of levels and they are computed only once
+type shader struct {
+	palette color.Palette
+	indices map[color.NRGBA]uint8
+	blends  map[[3]uint8]uint8
+}
+
+// Functions
+
+// Return the effects given in spec as a comma-separated list of effects
+// NAME[:VALUE], which are applied in the same order, where NAME is either
+// "glow" (VALUE is the radius in pixels, 4 by default), "scanlines" or
+// "vignette" (VALUE is the intensity in the range [0, 1], 0.5 by default),
+// e.g., "glow:6,vignette"
+func ParseEffects(spec string) ([]Effect, error) {
+
+	var effects []Effect
+	for _, item := range strings.Split(spec, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(item), ":")
+		switch name {
+		case "glow":
+			radius := 4
+			if found {
+				var err error
+				if radius, err = strconv.Atoi(value); err != nil || radius < 1 {
+					return nil, fmt.Errorf("Wrong radius of the glow '%v'", value)
+				}
+			}
+			effects = append(effects, Glow{Radius: radius})
+		case "scanlines", "vignette":
+			intensity := 0.5
+			if found {
+				var err error
+				if intensity, err = strconv.ParseFloat(value, 64); err != nil || intensity < 0 || intensity > 1 {
+					return nil, fmt.Errorf("Wrong intensity of the effect '%v'", item)
+				}
+			}
+			if name == "scanlines" {
+				effects = append(effects, Scanlines{Intensity: intensity})
+			} else {
+				effects = append(effects, Vignette{Intensity: intensity})
+			}
+		default:
+			return nil, fmt.Errorf("Unknown effect '%v'", name)
+		}
+	}
+	return effects, nil
+}
+
+// return a new shader of the given palette
+func newShader(palette color.Palette) *shader {
+
+	result := &shader{
+		palette: append(color.Palette(nil), palette...),
+		indices: make(map[color.NRGBA]uint8),
+		blends:  make(map[[3]uint8]uint8)}
+	for i := len(palette) - 1; i >= 0; i-- {
+		result.indices[color.NRGBAModel.Convert(palette[i]).(color.NRGBA)] = uint8(i)
+	}
+	return result
+}
+
+// methods
+
+// return the index of the colour which is at the given level of the way from
+// the colour with index from to the colour with index to
+func (s *shader) blend(from, to uint8, level int) uint8 {
+
+	if level <= 0 {
+		return from
+	}
+	key := [3]uint8{from, to, uint8(level)}
+	if index, ok := s.blends[key]; ok {
+		return index
+	}
+	c := color.NRGBAModel.Convert(blendColors(s.palette[from], s.palette[to], float64(level)/effectLevels)).(color.NRGBA)
+	index, ok := s.indices[c]
+	if !ok {
+		if len(s.palette) < maxPaletteSize {
+			s.palette = append(s.palette, c)
+			index = uint8(len(s.palette) - 1)
+		} else {
+			index = uint8(s.palette.Index(c))
+		}
+		s.indices[c] = index
+	}
+	s.blends[key] = index
+	return index
+}
+
+// return the index of the colour which is at the given level of the way from
+// the colour with index from to black
+func (s *shader) darken(from uint8, level int) uint8 {
+
+	black, ok := s.indices[color.NRGBA{A: 255}]
+	if !ok {
+		if len(s.palette) < maxPaletteSize {
+			s.palette = append(s.palette, color.NRGBA{A: 255})
+			black = uint8(len(s.palette) - 1)
+		} else {
+			black = uint8(s.palette.Index(color.Black))
+		}
+		s.indices[color.NRGBA{A: 255}] = black
+	}
+	return s.blend(from, black, level)
+}
+
+// return the level of the given fraction in the range [0, 1]
+func quantize(fraction float64) int {
+	return int(math.Round(max(0, min(1, fraction)) * effectLevels))
+}
+
+// Apply the glow to the pixels of dead cells of the given frame, i.e., those
+// drawn with the colour of dead cells, which take the colour of the closest
+// living cell
+func (glow Glow) Apply(img *image.Paletted) *image.Paletted {
+
+	// the distance to the closest living pixel is computed first along rows
+	// and then along columns, keeping the location of that pixel
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	rows := make([]int, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			rows[y*width+x] = -1
+			for dx := 0; dx <= glow.Radius; dx++ {
+				if x-dx >= 0 && img.Pix[y*img.Stride+x-dx] != 0 {
+					rows[y*width+x] = x - dx
+					break
+				}
+				if x+dx < width && img.Pix[y*img.Stride+x+dx] != 0 {
+					rows[y*width+x] = x + dx
+					break
+				}
+			}
+		}
+	}
+
+	s := newShader(img.Palette)
+	pix := make([]uint8, len(img.Pix))
+	copy(pix, img.Pix)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if img.Pix[y*img.Stride+x] != 0 {
+				continue
+			}
+			closest, distance := -1, glow.Radius*glow.Radius+1
+			for ys := max(0, y-glow.Radius); ys <= min(height-1, y+glow.Radius); ys++ {
+				if xs := rows[ys*width+x]; xs >= 0 {
+					if d := (xs-x)*(xs-x) + (ys-y)*(ys-y); d < distance {
+						closest, distance = ys*img.Stride+xs, d
+					}
+				}
+			}
+			if closest >= 0 {
+				fraction := glowIntensity * (1 - math.Sqrt(float64(distance))/float64(glow.Radius+1))
+				pix[y*img.Stride+x] = s.blend(0, img.Pix[closest], quantize(fraction))
+			}
+		}
+	}
+	return &image.Paletted{Pix: pix, Stride: img.Stride, Rect: img.Rect, Palette: s.palette}
+}
+
+// Apply the scanlines to the given frame, which darken its odd rows of pixels
+func (scanlines Scanlines) Apply(img *image.Paletted) *image.Paletted {
+
+	s := newShader(img.Palette)
+	pix := make([]uint8, len(img.Pix))
+	copy(pix, img.Pix)
+	for y := 1; y < img.Rect.Dy(); y += 2 {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			pix[y*img.Stride+x] = s.darken(img.Pix[y*img.Stride+x], quantize(scanlines.Intensity))
+		}
+	}
+	return &image.Paletted{Pix: pix, Stride: img.Stride, Rect: img.Rect, Palette: s.palette}
+}
+
+// Apply the vignette to the given frame, which darkens every pixel with the
+// square of its distance to the center relative to the distance of the
+// corners
+func (vignette Vignette) Apply(img *image.Paletted) *image.Paletted {
+
+	s := newShader(img.Palette)
+	pix := make([]uint8, len(img.Pix))
+	cx, cy := float64(img.Rect.Dx())/2, float64(img.Rect.Dy())/2
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			dx, dy := (float64(x)+0.5-cx)/cx, (float64(y)+0.5-cy)/cy
+			fraction := vignette.Intensity * (dx*dx + dy*dy) / 2
+			pix[y*img.Stride+x] = s.darken(img.Pix[y*img.Stride+x], quantize(fraction))
+		}
+	}
+	return &image.Paletted{Pix: pix, Stride: img.Stride, Rect: img.Rect, Palette: s.palette}
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the effects applied to every frame of this game, in the given order
+func (game *Conway) SetEffects(effects []Effect) {
+	game.effects = effects
+}
+
+// return the given frame with all the effects of this game applied to it, or
+// the same image if there is none
+func (game *Conway) applyEffects(img *image.Paletted) *image.Paletted {
+
+	for _, effect := range game.effects {
+		img = effect.Apply(img)
+	}
+	return img
+}
@@ -0,0 +1,68 @@
+// Elementary cellular automata are one-dimensional automata where the state of
+// every cell in the next generation depends only on its state and the state of
+// its left and right neighbours. The 256 possible rules are numbered following
+// Wolfram's code, e.g., Rule 30 or Rule 110.
+//
+// Generations of elementary automata are rendered as space-time diagrams: the
+// first row of the grid shows the first generation and every new generation is
+// drawn in the next row. Once the last row is reached, the diagram scrolls up
+
+package conway
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the rule, given in Wolfram's code, to use in elementary automata. The
+// first generation is given by the first row of the grid
+func (g *generation) SetWolframRule(rule uint8) {
+	g.wolfram = rule
+}
+
+// return whether the cell at location x of the given row is alive or not,
+// taking into account the boundary condition of this generation
+func (g *generation) aliveInRow(x, row int) uint8 {
+	if p, ok := g.locate(x, row); !ok || g.ColorIndexAt(p.X, row) == 0 {
+		return 0
+	}
+	return 1
+}
+
+// Return the next generation of an elementary automaton. The contents of all
+// rows are copied and a new row is computed from the last one. In case the
+// grid is full, all rows are moved up
+func (g *generation) nextElementary() *generation {
+
+	// create a new generation with the same features than this one
+	next := g.empty(1 + g.nbgeneration)
+
+	// copy the contents of this generation, scrolling up in case the diagram
+	// is full
+	width := 1 + g.img.Rect.Max.X/g.ratio.X
+	_, rows := g.dimensions()
+	states := g.States()
+	if g.row+1 >= rows {
+		copy(states, states[width:])
+		for x := 0; x < width; x++ {
+			states[(rows-1)*width+x] = 0
+		}
+		next.row = rows - 1
+	} else {
+		next.row = g.row + 1
+	}
+	next.SetStates(states)
+
+	// and compute the new row from the last one of this generation
+	for x := 0; x < width; x++ {
+		pattern := g.aliveInRow(x-1, g.row)<<2 |
+			g.aliveInRow(x, g.row)<<1 |
+			g.aliveInRow(x+1, g.row)
+		if (g.wolfram>>pattern)&1 == 1 {
+			next.SetColorIndex(x, next.row, g.cellColor(x, next.row))
+		}
+	}
+
+	// and return the next generation
+	return next
+}
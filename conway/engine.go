@@ -0,0 +1,90 @@
+// Games can be simulated with different engines. By default, every generation
+// is stored as a dense image. Sparse boards store only the location of living
+// cells, and HashLife computes generations over a quadtree whose nodes are
+// evolved only once, no matter how many times they appear
+
+package conway
+
+import "errors"
+
+// Engine
+// ----------------------------------------------------------------------------
+
+// type
+
+// The engine used for simulating a game
+type Engine int
+
+const (
+	// every generation is stored as a dense image
+	DenseEngine Engine = iota
+
+	// only the location of living cells is stored
+	SparseEngine
+
+	// generations are computed with HashLife and only those rendered are
+	// stored
+	HashLifeEngine
+)
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the engine used for simulating this game. Only the Conway's Game and
+// other Life-like rules can be simulated with engines other than the dense
+// one. Besides, HashLife requires deterministic rules where dead cells with no
+// living neighbours stay dead, and it does not acknowledge boundary conditions
+func (game *Conway) SetEngine(engine Engine) error {
+
+	first := game.generations[0]
+	if engine != DenseEngine && !first.isLife() {
+		return errors.New("Only Life-like rules can be simulated with this engine")
+	}
+	if engine != DenseEngine && first.colorModel != nil {
+		return errors.New("Custom colour models can only be used with the dense engine")
+	}
+	if engine != DenseEngine && first.transition != nil {
+		return errors.New("Transition rules can only be used with the dense engine")
+	}
+	if engine != DenseEngine && first.lifespan > 0 {
+		return errors.New("Lifespans can only be used with the dense engine")
+	}
+	if engine != DenseEngine && game.script != nil {
+		return errors.New("Scripts can only be executed by the dense engine")
+	}
+	if engine != DenseEngine && game.until != nil {
+		return errors.New("Stop conditions can only be checked by the dense engine")
+	}
+	if engine != DenseEngine && first.topology != SquareTopology {
+		return errors.New("Triangular lattices can only be simulated with the dense engine")
+	}
+	if engine != DenseEngine && game.strobes() {
+		return errors.New("Rules with B0 can only be simulated with the dense engine")
+	}
+	if engine == HashLifeEngine && first.rule != nil && first.rule.Stochastic() {
+		return errors.New("HashLife can not simulate stochastic rules")
+	}
+	if engine == HashLifeEngine && game.schedule != nil {
+		return errors.New("HashLife can not simulate scheduled rules")
+	}
+	if engine == HashLifeEngine && game.noise != nil {
+		return errors.New("HashLife can not simulate noise")
+	}
+	if engine == HashLifeEngine && first.boundary != DeadBoundary {
+		return errors.New("HashLife simulates infinite boards which have no boundary")
+	}
+	game.engine = engine
+	return nil
+}
+
+// return whether the generation with the given index has been computed. Note
+// that HashLife computes only those generations that are rendered
+func (game *Conway) computed(index int) bool {
+
+	if game.engine == DenseEngine {
+		return game.generations[index] != nil
+	}
+	return game.cells != nil && game.cells[index] != nil
+}
@@ -0,0 +1,152 @@
+// Animations where large areas change their brightness several times per
+// second can trigger seizures in people with photosensitive epilepsy. Following
+// the WCAG guidelines, a flash happens when the relative luminance of at least
+// a quarter of the image changes by 10% or more between consecutive frames,
+// and animations should not have more than three flashes in any one second
+// period
+
+package conway
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"math"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Maximum number of flashes allowed in any one second period
+const MaxFlashesPerSecond = 3
+
+// Minimum fraction of the image whose luminance has to change for a flash to
+// happen
+const FlashArea = 0.25
+
+// Minimum change in relative luminance of a pixel for it to be considered in
+// a flash
+const FlashLuminance = 0.1
+
+// Minimum delay (in 100th of a second) of frames that produce a flash, so
+// that no more than MaxFlashesPerSecond happen in one second
+const FlashDelay = 1 + 100/MaxFlashesPerSecond
+
+// FlashReport
+// ----------------------------------------------------------------------------
+
+// type
+
+// A flash report contains the indices of those frames that produce a flash
+// (i.e., they differ from the previous one in a large area) and the maximum
+// number of flashes found in any one second period
+type FlashReport struct {
+	Frames       []int
+	MaxPerSecond int
+}
+
+// methods
+
+// Return whether the animation analyzed might be problematic for people with
+// photosensitivity
+func (report FlashReport) Hazardous() bool {
+	return report.MaxPerSecond > MaxFlashesPerSecond
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the relative luminance of a color in the range [0, 1]
+func luminance(c color.Color) float64 {
+
+	// linearize each component of the sRGB color
+	linear := func(component uint32) float64 {
+		value := float64(component) / 0xffff
+		if value <= 0.03928 {
+			return value / 12.92
+		}
+		return math.Pow((value+0.055)/1.055, 2.4)
+	}
+	r, g, b, _ := c.RGBA()
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+// Return the fraction of pixels whose relative luminance changes at least
+// FlashLuminance between both images, which are assumed to have the same
+// bounds
+func flashArea(previous, current *image.Paletted) float64 {
+
+	// precompute the luminance of all colors in both palettes
+	lprevious := make([]float64, len(previous.Palette))
+	for i, c := range previous.Palette {
+		lprevious[i] = luminance(c)
+	}
+	lcurrent := make([]float64, len(current.Palette))
+	for i, c := range current.Palette {
+		lcurrent[i] = luminance(c)
+	}
+
+	var changed int
+	bounds := current.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i, j := previous.ColorIndexAt(x, y), current.ColorIndexAt(x, y)
+			if int(i) < len(lprevious) && int(j) < len(lcurrent) &&
+				math.Abs(lprevious[i]-lcurrent[j]) >= FlashLuminance {
+				changed++
+			}
+		}
+	}
+	if bounds.Empty() {
+		return 0
+	}
+	return float64(changed) / float64(bounds.Dx()*bounds.Dy())
+}
+
+// Return a report of all the flashes found in the given animation
+func AnalyzeFlashes(anim *gif.GIF) (report FlashReport) {
+
+	// compute the time (in 100th of a second) when every frame is shown, and
+	// find those frames that produce a flash
+	var times []int
+	var elapsed int
+	for i := range anim.Image {
+		if i > 0 && flashArea(anim.Image[i-1], anim.Image[i]) >= FlashArea {
+			report.Frames = append(report.Frames, i)
+			times = append(times, elapsed)
+		}
+		if i < len(anim.Delay) {
+			elapsed += anim.Delay[i]
+		}
+	}
+
+	// and compute the maximum number of flashes in any window of one second
+	for first, last := 0, 0; last < len(times); last++ {
+		for times[last]-times[first] >= 100 {
+			first++
+		}
+		if 1+last-first > report.MaxPerSecond {
+			report.MaxPerSecond = 1 + last - first
+		}
+	}
+
+	return
+}
+
+// Reduce the flicker of the given animation by making sure that all frames are
+// shown at least minDelay 100th of a second, and that the frames preceding a
+// flash are shown long enough so that no more than MaxFlashesPerSecond happen
+// in one second
+func ReduceFlicker(anim *gif.GIF, minDelay int) {
+
+	for i := range anim.Delay {
+		if anim.Delay[i] < minDelay {
+			anim.Delay[i] = minDelay
+		}
+	}
+	for _, i := range AnalyzeFlashes(anim).Frames {
+		if anim.Delay[i-1] < FlashDelay {
+			anim.Delay[i-1] = FlashDelay
+		}
+	}
+}
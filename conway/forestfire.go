@@ -0,0 +1,102 @@
+// The forest-fire model of Drossel and Schwabl has three states: empty cells,
+// trees and burning trees. Burning trees burn down leaving an empty cell,
+// trees catch fire if any of their neighbours is burning or, with a small
+// probability, when they are struck by lightning, and empty cells grow a new
+// tree with a given probability. As in WireWorld, the state of every cell is
+// directly its color index
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math/rand"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// States of the cells in the forest-fire model. They are used as color indexes
+// as well
+const (
+	ForestEmpty uint8 = iota
+	ForestTree
+	ForestFire
+)
+
+// ForestFireRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// The rule of the forest-fire model is given by the probability of Growth of
+// a new tree in an empty cell, and the probability of Lightning striking a
+// tree in every generation
+type ForestFireRule struct {
+	Growth, Lightning float64
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the default palette of the forest-fire model: empty cells are black,
+// trees are green and burning trees are orange
+func ForestFirePalette() color.Palette {
+	return color.Palette{
+		color.RGBA{0x00, 0x00, 0x00, 0xff},
+		color.RGBA{0x22, 0x8b, 0x22, 0xff},
+		color.RGBA{0xff, 0x66, 0x00, 0xff}}
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the rule of the forest-fire model along with the random number generator
+// used for deciding growths and lightnings. Both probabilities must be in the
+// range [0, 1]
+func (g *generation) SetForestFire(rule ForestFireRule, rng *rand.Rand) error {
+
+	if rule.Growth < 0 || rule.Growth > 1 {
+		return fmt.Errorf("The probability of growth %v is out of the range [0, 1]", rule.Growth)
+	}
+	if rule.Lightning < 0 || rule.Lightning > 1 {
+		return fmt.Errorf("The probability of lightning %v is out of the range [0, 1]", rule.Lightning)
+	}
+	if rng == nil {
+		return errors.New("The forest-fire model requires a random number generator")
+	}
+	g.forest, g.rng = &rule, rng
+	return nil
+}
+
+// Return the next generation of the forest-fire model. The rules are:
+//
+//  1. Burning trees become empty cells
+//  2. Trees catch fire if any of their neighbours is burning, or with the
+//     probability of lightning otherwise
+//  3. Empty cells grow a tree with the probability of growth
+func (g *generation) nextForestFire() *generation {
+
+	next := g.empty(1 + g.nbgeneration)
+	width, height := g.dimensions()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			switch g.ColorIndexAt(x, y) {
+			case ForestTree:
+				if g.nbstate(x, y, ForestFire) > 0 || g.rng.Float64() < g.forest.Lightning {
+					next.SetColorIndex(x, y, ForestFire)
+				} else {
+					next.SetColorIndex(x, y, ForestTree)
+				}
+			case ForestEmpty:
+				if g.rng.Float64() < g.forest.Growth {
+					next.SetColorIndex(x, y, ForestTree)
+				}
+			}
+		}
+	}
+	return next
+}
@@ -0,0 +1,158 @@
+// Living cells are drawn as filled blocks by default, but they can also be
+// drawn with other glyphs, e.g., circles, diamonds or rounded squares, which
+// give a softer look to presentations. Glyphs are only drawn when cells are
+// large enough, because small cells would look the same anyway
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Minimum size of cells in pixels along both axes for drawing glyphs
+const GlyphMinCellSize = 4
+
+// Glyph
+// ----------------------------------------------------------------------------
+
+// type
+
+// The glyph used for drawing living cells within the pixels they cover. Dead
+// cells are always drawn as filled blocks
+type Glyph int
+
+const (
+	SquareGlyph Glyph = iota
+	CircleGlyph
+	DiamondGlyph
+	RoundedGlyph
+)
+
+// Functions
+
+// Return the glyph with the given name, either "square", "circle", "diamond"
+// or "rounded", along with an error if it is not known
+func NewGlyph(name string) (Glyph, error) {
+
+	switch name {
+	case "square":
+		return SquareGlyph, nil
+	case "circle":
+		return CircleGlyph, nil
+	case "diamond":
+		return DiamondGlyph, nil
+	case "rounded":
+		return RoundedGlyph, nil
+	}
+	return SquareGlyph, fmt.Errorf("Unknown glyph '%v'", name)
+}
+
+// methods
+
+// return whether the pixel at the given location of a cell with the given
+// width and height is covered by this glyph. Pixels are tested by their center
+func (glyph Glyph) covers(x, y, width, height int) bool {
+
+	// coordinates are given relative to the center of the cell and
+	// normalized to the range [-1, 1]
+	u := 2*(float64(x)+0.5)/float64(width) - 1
+	v := 2*(float64(y)+0.5)/float64(height) - 1
+	switch glyph {
+	case CircleGlyph:
+		return u*u+v*v <= 1
+	case DiamondGlyph:
+		return math.Abs(u)+math.Abs(v) <= 1
+	case RoundedGlyph:
+
+		// corners are rounded with a radius of a quarter of the shortest
+		// side
+		radius := float64(min(width, height)) / 4
+		dx := math.Max(0, math.Abs(float64(x)+0.5-float64(width)/2)-(float64(width)/2-radius))
+		dy := math.Max(0, math.Abs(float64(y)+0.5-float64(height)/2)-(float64(height)/2-radius))
+		return dx*dx+dy*dy <= radius*radius
+	}
+	return true
+}
+
+// return the mask of the pixels covered by this glyph in a cell with the
+// given width and height, row by row
+func (glyph Glyph) mask(width, height int) []bool {
+
+	result := make([]bool, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			result[y*width+x] = glyph.covers(x, y, width, height)
+		}
+	}
+	return result
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the glyph used for drawing the living cells of this game. Glyphs can
+// only be drawn in square grids
+func (game *Conway) SetGlyph(glyph Glyph) error {
+
+	if glyph != SquareGlyph && game.generations[0].topology != SquareTopology {
+		return errors.New("Glyphs can only be drawn in square grids")
+	}
+	game.glyph = glyph
+	return nil
+}
+
+// return a copy of the given frame where the living cells are drawn with the
+// glyph of this game, i.e., the pixels of living cells not covered by it are
+// given the colour of dead cells. If cells are drawn as filled blocks or they
+// are too small, the same image is returned
+func (game *Conway) drawGlyphs(img *image.Paletted) *image.Paletted {
+
+	cell := game.cellRect(image.Point{})
+	if game.glyph == SquareGlyph || cell.Dx() < GlyphMinCellSize || cell.Dy() < GlyphMinCellSize {
+		return img
+	}
+
+	// masks are computed once for every size of cells, which can differ with
+	// non-integer cell sizes
+	result := copyFrame(img)
+	bounds := result.Rect
+	masks := make(map[image.Point][]bool)
+	for row := 0; ; row++ {
+		if game.cellRect(image.Point{Y: row}).Min.Y >= bounds.Dy() {
+			break
+		}
+		for column := 0; ; column++ {
+			rect := game.cellRect(image.Point{X: column, Y: row}).Add(bounds.Min)
+			if rect.Min.X >= bounds.Max.X {
+				break
+			}
+			rect = rect.Intersect(bounds)
+			center := result.PixOffset((rect.Min.X+rect.Max.X)/2, (rect.Min.Y+rect.Max.Y)/2)
+			if result.Pix[center] == 0 {
+				continue
+			}
+			size := rect.Size()
+			mask, ok := masks[size]
+			if !ok {
+				mask = game.glyph.mask(size.X, size.Y)
+				masks[size] = mask
+			}
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					if !mask[y*size.X+x] {
+						result.Pix[result.PixOffset(rect.Min.X+x, rect.Min.Y+y)] = 0
+					}
+				}
+			}
+		}
+	}
+	return result
+}
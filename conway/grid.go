@@ -0,0 +1,77 @@
+// Grid lines can be drawn between cells, so that cells can be counted when
+// they are magnified, e.g., with an aspect ratio of 8:8 living cells are drawn
+// as solid blocks which are hard to tell apart. Lines are only drawn along the
+// axes where cells are large enough, so that small cells are not hidden by them
+
+package conway
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Minimum size of cells in pixels along an axis for drawing grid lines across
+// it
+const GridMinCellSize = 4
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Draw grid lines with the given colour between the cells of all frames of
+// this game, or none if nil is given. Since frames are paletted images, lines
+// are drawn with the colour of the palette closest to the given one. Grid
+// lines can only be drawn in square grids
+func (game *Conway) SetGrid(c color.Color) error {
+
+	if c != nil && game.generations[0].topology != SquareTopology {
+		return errors.New("Grid lines can only be drawn in square grids")
+	}
+	game.grid = c
+	return nil
+}
+
+// return a copy of the given frame with grid lines drawn between its cells
+// along the axes where they are at least GridMinCellSize pixels large. If no
+// grid has been requested or cells are too small, the same image is returned
+func (game *Conway) drawGrid(img *image.Paletted) *image.Paletted {
+
+	if game.grid == nil {
+		return img
+	}
+	cell := game.cellRect(image.Point{})
+	vertical, horizontal := cell.Dx() >= GridMinCellSize, cell.Dy() >= GridMinCellSize
+	if !vertical && !horizontal {
+		return img
+	}
+
+	// every line takes the first column or row of pixels of the cells to its
+	// right or below, but the first ones
+	result := copyFrame(img)
+	c := uint8(img.Palette.Index(game.grid))
+	bounds := result.Rect
+	for column := 1; vertical; column++ {
+		x := bounds.Min.X + game.cellRect(image.Point{X: column}).Min.X
+		if x >= bounds.Max.X {
+			break
+		}
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			result.Pix[result.PixOffset(x, y)] = c
+		}
+	}
+	for row := 1; horizontal; row++ {
+		y := bounds.Min.Y + game.cellRect(image.Point{Y: row}).Min.Y
+		if y >= bounds.Max.Y {
+			break
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			result.Pix[result.PixOffset(x, y)] = c
+		}
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+// Games can be padded with a halo of dead rows and columns around the board
+// which are simulated but not rendered, so that the artifacts produced when
+// living cells reach the edges of the board do not show up in the frames
+
+package conway
+
+import (
+	"errors"
+	"image"
+)
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// return a copy of this generation with the given number of dead columns and
+// rows added to its left, top, right and bottom. The location of the original
+// board and the center are shifted accordingly
+func (g *generation) pad(left, top, right, bottom int) *generation {
+
+	// create the new generation and copy the contents of this one. Note that
+	// the last row and column of cells lie outside the image and thus, they
+	// are always dead
+	width, height := g.img.Rect.Max.X/g.ratio.X, g.img.Rect.Max.Y/g.ratio.Y
+	result := g.emptyWithin(image.Rect(0, 0, left+width+right, top+height+bottom), g.nbgeneration)
+	result.origin = g.origin.Add(image.Point{X: left, Y: top})
+	result.center = g.center.Add(image.Point{X: left, Y: top})
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			result.SetColorIndex(left+x, top+y, g.ColorIndexAt(x, y))
+		}
+	}
+
+	// and also their ages and species, if any
+	if g.ages != nil {
+		for x := 0; x < width; x++ {
+			for y := 0; y < height; y++ {
+				if age := g.ageAt(x, y); age > 0 {
+					result.setAge(left+x, top+y, age)
+				}
+			}
+		}
+	}
+	if g.species != nil {
+		result.species = make([]uint8, (1+left+width+right)*(1+top+height+bottom))
+		for x := 0; x <= width; x++ {
+			for y := 0; y <= height; y++ {
+				result.species[(top+y)*(1+left+width+right)+left+x] = g.speciesAt(x, y)
+			}
+		}
+	}
+
+	return result
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Pad this game with a halo of the given number of dead rows and columns
+// around its board. The halo is simulated as any other cell, but it is not
+// rendered, so that frames keep the dimensions of the original board. Only
+// Life-like automata (including the multi-colour variants) can be padded, and
+// the halo has to be set before running the game
+func (game *Conway) SetHalo(halo int) error {
+
+	if halo < 0 {
+		return errors.New("The halo can not be negative")
+	}
+	if g := game.generations[0]; !g.isLife() && g.species == nil {
+		return errors.New("Only Life-like automata can be padded with a halo")
+	}
+	if game.unbounded {
+		return errors.New("Infinite boards can not be padded with a halo")
+	}
+	if game.halo > 0 {
+		return errors.New("The halo of this game has been already set")
+	}
+
+	// the board simulated grows by the halo in every direction
+	game.generations[0] = game.generations[0].pad(halo, halo, halo, halo)
+	game.width, game.height = game.width+2*halo, game.height+2*halo
+	game.halo = halo
+	return nil
+}
+
+// Return the halo of this game, i.e., the number of rows and columns
+// simulated around the board which are not rendered
+func (game *Conway) Halo() int {
+	return game.halo
+}
+
+// return a copy of the given image with the halo of this game removed. In
+// case the game has no halo, the same image is returned
+func (game *Conway) crop(img *image.Paletted) *image.Paletted {
+
+	if game.halo == 0 {
+		return img
+	}
+	ratio := game.generations[0].ratio
+	offset := image.Point{X: game.halo * ratio.X, Y: game.halo * ratio.Y}
+	result := image.NewPaletted(image.Rect(0, 0,
+		(game.width-2*game.halo)*ratio.X, (game.height-2*game.halo)*ratio.Y), img.Palette)
+	for x := result.Rect.Min.X; x < result.Rect.Max.X; x++ {
+		for y := result.Rect.Min.Y; y < result.Rect.Max.Y; y++ {
+			result.SetColorIndex(x, y, img.ColorIndexAt(x+offset.X, y+offset.Y))
+		}
+	}
+	return result
+}
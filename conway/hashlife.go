@@ -0,0 +1,338 @@
+// HashLife simulates Life-like rules over an infinite board represented as a
+// quadtree. Identical nodes are stored only once and the evolution of every
+// node is memoized, so that large structured patterns (e.g., guns or breeders)
+// can be simulated for tens of thousands of generations in seconds. Only the
+// generations rendered are extracted from the quadtree
+
+package conway
+
+import (
+	"image"
+	"time"
+)
+
+// hashNode
+// ----------------------------------------------------------------------------
+
+// type
+
+// A node of the quadtree covers a square of 2^level cells per side and it
+// consists of four quadrants. Leaves (with level 0) are single cells
+type hashNode struct {
+	nw, ne, sw, se *hashNode
+	level          int
+	population     int
+}
+
+// hashLife
+// ----------------------------------------------------------------------------
+
+// type
+
+// The key used for memoizing the evolution of a node 2^j generations ahead
+type hashStep struct {
+	node *hashNode
+	j    int
+}
+
+// A HashLife universe consists of the root of the quadtree along with the
+// location of its upper-left corner. Nodes are unique, so that they are
+// always created with join, and the generation used as a template provides
+// the rule to apply
+type hashLife struct {
+	template    *generation
+	dead, alive *hashNode
+	nodes       map[[4]*hashNode]*hashNode
+	empties     []*hashNode
+	results     map[hashStep]*hashNode
+	root        *hashNode
+	origin      image.Point
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return a new HashLife universe with the given living cells which follows
+// the rule of the given generation
+func newHashLife(template *generation, cells []image.Point) *hashLife {
+
+	life := &hashLife{
+		template: template,
+		dead:     &hashNode{},
+		alive:    &hashNode{population: 1},
+		nodes:    make(map[[4]*hashNode]*hashNode),
+		results:  make(map[hashStep]*hashNode)}
+	life.empties = []*hashNode{life.dead}
+
+	// compute the region that contains all cells and create a root large
+	// enough
+	var region image.Rectangle
+	for _, p := range cells {
+		region = region.Union(image.Rect(p.X, p.Y, p.X+1, p.Y+1))
+	}
+	level := 3
+	for 1<<level < max(region.Dx(), region.Dy()) {
+		level++
+	}
+	life.root, life.origin = life.empty(level), region.Min
+	for _, p := range cells {
+		life.root = life.set(life.root, p.X-region.Min.X, p.Y-region.Min.Y)
+	}
+	return life
+}
+
+// methods
+
+// return the unique node with the given quadrants
+func (life *hashLife) join(nw, ne, sw, se *hashNode) *hashNode {
+
+	key := [4]*hashNode{nw, ne, sw, se}
+	if node, ok := life.nodes[key]; ok {
+		return node
+	}
+	node := &hashNode{
+		nw: nw, ne: ne, sw: sw, se: se,
+		level:      1 + nw.level,
+		population: nw.population + ne.population + sw.population + se.population}
+	life.nodes[key] = node
+	return node
+}
+
+// return the empty node with the given level
+func (life *hashLife) empty(level int) *hashNode {
+
+	for len(life.empties) <= level {
+		e := life.empties[len(life.empties)-1]
+		life.empties = append(life.empties, life.join(e, e, e, e))
+	}
+	return life.empties[level]
+}
+
+// return a copy of the given node where the cell at location (x, y), relative
+// to its upper-left corner, is alive
+func (life *hashLife) set(node *hashNode, x, y int) *hashNode {
+
+	if node.level == 0 {
+		return life.alive
+	}
+	half := 1 << (node.level - 1)
+	switch {
+	case x < half && y < half:
+		return life.join(life.set(node.nw, x, y), node.ne, node.sw, node.se)
+	case y < half:
+		return life.join(node.nw, life.set(node.ne, x-half, y), node.sw, node.se)
+	case x < half:
+		return life.join(node.nw, node.ne, life.set(node.sw, x, y-half), node.se)
+	}
+	return life.join(node.nw, node.ne, node.sw, life.set(node.se, x-half, y-half))
+}
+
+// return whether the cell at location (x, y), relative to the upper-left
+// corner of the given node, is alive
+func (life *hashLife) get(node *hashNode, x, y int) bool {
+
+	for node.level > 0 {
+		half := 1 << (node.level - 1)
+		switch {
+		case x < half && y < half:
+			node = node.nw
+		case y < half:
+			node, x = node.ne, x-half
+		case x < half:
+			node, y = node.sw, y-half
+		default:
+			node, x, y = node.se, x-half, y-half
+		}
+	}
+	return node == life.alive
+}
+
+// return a node with a level more than the given one, which is located at its
+// center
+func (life *hashLife) center(node *hashNode) *hashNode {
+
+	e := life.empty(node.level - 1)
+	return life.join(
+		life.join(e, e, e, node.nw),
+		life.join(e, e, node.ne, e),
+		life.join(e, node.sw, e, e),
+		life.join(node.se, e, e, e))
+}
+
+// return whether all living cells of the given node are located in the
+// central half of it
+func (life *hashLife) padded(node *hashNode) bool {
+
+	return node.population == node.nw.se.population+node.ne.sw.population+
+		node.sw.ne.population+node.se.nw.population
+}
+
+// return the center of a node with level 2 (i.e., 4x4 cells) one generation
+// ahead
+func (life *hashLife) step(node *hashNode) *hashNode {
+
+	var result [4]*hashNode
+	for i, p := range []image.Point{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 2}, {X: 2, Y: 2}} {
+		var neighbourhood int
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if (dx != 0 || dy != 0) && life.get(node, p.X+dx, p.Y+dy) {
+					neighbourhood |= neighbourBit(dx, dy)
+				}
+			}
+		}
+		result[i] = life.dead
+		if life.template.decide(life.get(node, p.X, p.Y), neighbourhood) {
+			result[i] = life.alive
+		}
+	}
+	return life.join(result[0], result[1], result[2], result[3])
+}
+
+// return the center of the given node 2^j generations ahead, where j can not
+// exceed the level of the node minus 2
+func (life *hashLife) successor(node *hashNode, j int) *hashNode {
+
+	j = min(j, node.level-2)
+	if node.population == 0 {
+		return life.empty(node.level - 1)
+	}
+	if result, ok := life.results[hashStep{node, j}]; ok {
+		return result
+	}
+
+	var result *hashNode
+	if node.level == 2 {
+		result = life.step(node)
+	} else {
+
+		// compute the nine overlapping subnodes of half the size of this
+		// node, and evolve all of them
+		nw, ne, sw, se := node.nw, node.ne, node.sw, node.se
+		c1 := life.successor(nw, j)
+		c2 := life.successor(life.join(nw.ne, ne.nw, nw.se, ne.sw), j)
+		c3 := life.successor(ne, j)
+		c4 := life.successor(life.join(nw.sw, nw.se, sw.nw, sw.ne), j)
+		c5 := life.successor(life.join(nw.se, ne.sw, sw.ne, se.nw), j)
+		c6 := life.successor(life.join(ne.sw, ne.se, se.nw, se.ne), j)
+		c7 := life.successor(sw, j)
+		c8 := life.successor(life.join(sw.ne, se.nw, sw.se, se.sw), j)
+		c9 := life.successor(se, j)
+
+		// if the time step is less than the maximum, the center is just
+		// assembled with them. Otherwise, they are evolved once more
+		if j < node.level-2 {
+			result = life.join(
+				life.join(c1.se, c2.sw, c4.ne, c5.nw),
+				life.join(c2.se, c3.sw, c5.ne, c6.nw),
+				life.join(c4.se, c5.sw, c7.ne, c8.nw),
+				life.join(c5.se, c6.sw, c8.ne, c9.nw))
+		} else {
+			result = life.join(
+				life.successor(life.join(c1, c2, c4, c5), j),
+				life.successor(life.join(c2, c3, c5, c6), j),
+				life.successor(life.join(c4, c5, c7, c8), j),
+				life.successor(life.join(c5, c6, c8, c9), j))
+		}
+	}
+
+	life.results[hashStep{node, j}] = result
+	return result
+}
+
+// advance the universe the given number of generations
+func (life *hashLife) advance(generations int) {
+
+	for j := 0; generations > 0; j, generations = j+1, generations>>1 {
+		if generations&1 == 0 {
+			continue
+		}
+
+		// make sure that the root is large enough so that no living cell
+		// escapes from its center after 2^j generations
+		for life.root.level < j+2 || !life.padded(life.root) {
+			life.origin = life.origin.Sub(image.Point{X: 1 << (life.root.level - 1), Y: 1 << (life.root.level - 1)})
+			life.root = life.center(life.root)
+		}
+		life.origin = life.origin.Sub(image.Point{X: 1 << (life.root.level - 1), Y: 1 << (life.root.level - 1)})
+		life.root = life.center(life.root)
+
+		// and evolve it
+		life.origin = life.origin.Add(image.Point{X: 1 << (life.root.level - 2), Y: 1 << (life.root.level - 2)})
+		life.root = life.successor(life.root, j)
+	}
+}
+
+// return all living cells of the universe along with their colours, which
+// are computed with the given template
+func (life *hashLife) cells(template *generation) map[image.Point]uint8 {
+
+	result := make(map[image.Point]uint8, life.root.population)
+	var collect func(node *hashNode, x, y int)
+	collect = func(node *hashNode, x, y int) {
+		if node.population == 0 {
+			return
+		}
+		if node.level == 0 {
+
+			// as in dense boards, cells given the color of dead cells are
+			// dead
+			if c := template.cellColor(x, y); c != 0 {
+				result[image.Point{X: x, Y: y}] = c
+			}
+			return
+		}
+		half := 1 << (node.level - 1)
+		collect(node.nw, x, y)
+		collect(node.ne, x+half, y)
+		collect(node.sw, x, y+half)
+		collect(node.se, x+half, y+half)
+	}
+	collect(life.root, life.origin.X, life.origin.Y)
+	return result
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Run the entire game with HashLife, simulating first the burn-in phase, if
+// any. Only the generations rendered are extracted
+func (game *Conway) runHashLife() {
+
+	// create the universe with the living cells of the first generation
+	first := game.generations[0]
+	var cells []image.Point
+	for x := 0; x < game.width; x++ {
+		for y := 0; y < game.height; y++ {
+			if first.ColorIndexAt(x, y) != 0 {
+				cells = append(cells, image.Point{X: x, Y: y})
+			}
+		}
+	}
+	life := newHashLife(first, cells)
+
+	// simulate the burn-in phase, if any
+	if game.burnin > 0 {
+		start := time.Now()
+		game.burninStats.InitialPopulation = life.root.population
+		life.advance(game.burnin)
+		game.burninStats.Generations = game.burnin
+		game.burninStats.FinalPopulation = life.root.population
+		game.burninStats.Elapsed = time.Since(start)
+	}
+
+	// and now extract all generations rendered. As in dense boards, the
+	// colours of living cells are computed with the previous generation
+	game.cells = make([]map[image.Point]uint8, game.nbgenerations)
+	var current int
+	for index := 0; index < game.nbgenerations; index++ {
+		if game.selector != nil && !game.selector(index) {
+			continue
+		}
+		life.advance(index - current)
+		current = index
+		game.cells[index] = life.cells(game.template(first.nbgeneration + max(0, index-1)))
+	}
+}
@@ -0,0 +1,92 @@
+package conway
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// return a game of the given dimensions with the given pattern of the library
+// at its center, which has been run for the given number of generations with
+// the given engine, rendering only the generations accepted by the given
+// selector, if any
+func libraryGame(t *testing.T, name string, width, height, generations int, engine Engine, selector FrameSelector) *Conway {
+
+	pattern, err := LibraryPattern(name)
+	if err != nil {
+		t.Fatalf("LibraryPattern(%q): %v", name, err)
+	}
+	contents, err := pattern.Contents(width, height, image.Point{X: (width - pattern.Width) / 2, Y: (height - pattern.Height) / 2})
+	if err != nil {
+		t.Fatalf("Contents: %v", err)
+	}
+	initial := NewGeneration(image.Rect(0, 0, width, height),
+		GradientPalette(color.Black, color.RGBA{0, 0xff, 0, 0xff}, color.RGBA{0xff, 0, 0, 0xff}),
+		AspectRatio{X: 1, Y: 1}, "gradient", 1, generations)
+	if err := initial.Set(contents); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	game := NewConway(width, height, generations, initial)
+	if err := game.SetEngine(engine); err != nil {
+		t.Fatalf("SetEngine: %v", err)
+	}
+	game.SetFrameSelector(selector)
+	game.Run()
+	return &game
+}
+
+// HashLife simulates infinite boards, so that patterns are run on boards large
+// enough that they never reach their boundary, and then the living cells of
+// every generation rendered must be the same computed by the dense engine
+func TestHashLife(t *testing.T) {
+
+	every := func(n int) FrameSelector {
+		return func(gen int) bool { return gen%n == 0 }
+	}
+	tests := []struct {
+		pattern     string
+		generations int
+		selector    FrameSelector
+	}{
+		{"gosper-gun", 150, nil},
+		{"gosper-gun", 150, every(7)},
+		{"r-pentomino", 200, nil},
+		{"r-pentomino", 200, every(16)},
+		{"acorn", 120, every(5)},
+	}
+	const width, height = 128, 128
+	dense := make(map[string]*Conway)
+	for _, test := range tests {
+		if dense[test.pattern] == nil {
+			dense[test.pattern] = libraryGame(t, test.pattern, width, height, test.generations, DenseEngine, nil)
+		}
+		for _, engine := range []struct {
+			name   string
+			engine Engine
+		}{{"sparse", SparseEngine}, {"hashlife", HashLifeEngine}} {
+			game := libraryGame(t, test.pattern, width, height, test.generations, engine.engine, test.selector)
+		generations:
+			for index := 0; index < test.generations; index++ {
+				if test.selector != nil && !test.selector(index) {
+					continue
+				}
+				want, got := sortCells(dense[test.pattern].living(index)), sortCells(game.living(index))
+				for _, cell := range want {
+					if cell.X == 0 || cell.Y == 0 || cell.X == width-1 || cell.Y == height-1 {
+						t.Fatalf("%v reached the boundary of the board at generation %v", test.pattern, index)
+					}
+				}
+				if len(got) != len(want) {
+					t.Errorf("%v with the %v engine: %v living cells at generation %v, want %v", test.pattern, engine.name, len(got), index, len(want))
+					break
+				}
+				for i := range want {
+					if got[i] != want[i] {
+						t.Errorf("%v with the %v engine: cell %v alive at generation %v, want %v", test.pattern, engine.name, got[i], index, want[i])
+						break generations
+					}
+				}
+			}
+		}
+	}
+}
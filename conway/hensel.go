@@ -0,0 +1,219 @@
+// Isotropic non-totalistic rules take into account not only the number of
+// living neighbours but also their arrangement, up to rotations and
+// reflections. They are written in Hensel notation, where every number of
+// neighbours can be followed by letters which select some of their
+// arrangements, e.g., B2a/S12 or B2-a/S12, where a minus sign excludes the
+// arrangements given. Arrangements of 5, 6 and 7 neighbours are named after
+// the arrangement of dead cells of 3, 2 and 1 neighbours respectively
+
+package conway
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// The neighbourhood of a cell is represented as a mask where the neighbour at
+// offset (dx, dy) is given the bit 3(dy+1)+(dx+1), so that bit 4 (the cell
+// itself) is never set:
+//
+//	0 1 2
+//	3 4 5
+//	6 7 8
+const neighbourhoods = 1 << 9
+
+// The letters of the arrangements of 1, 2, 3 and 4 living neighbours in
+// Hensel notation, along with a representative of each one
+var henselLetters = [5]string{"", "ce", "ceaikn", "ceaiknjqry", "ceaiknjqrtwyz"}
+var henselRepresentatives = [5][]int{
+	{},
+	{0x001, 0x002},
+	{0x005, 0x00a, 0x003, 0x028, 0x021, 0x044},
+	{0x045, 0x02a, 0x00b, 0x007, 0x062, 0x00d, 0x00e, 0x046, 0x029, 0x061},
+	{0x145, 0x0aa, 0x00f, 0x02d, 0x063, 0x047, 0x06a, 0x066, 0x02b, 0x069, 0x04e, 0x065, 0x06c},
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return the bit of the given neighbour in a neighbourhood
+func neighbourBit(dx, dy int) int {
+	return 1 << (3*(dy+1) + dx + 1)
+}
+
+// return the neighbourhood resulting from rotating the given one a quarter
+// turn clockwise
+func rotateNeighbourhood(mask int) (result int) {
+
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if mask&neighbourBit(dx, dy) != 0 {
+				result |= neighbourBit(-dy, dx)
+			}
+		}
+	}
+	return
+}
+
+// return the neighbourhood resulting from reflecting the given one along the
+// vertical axis
+func reflectNeighbourhood(mask int) (result int) {
+
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if mask&neighbourBit(dx, dy) != 0 {
+				result |= neighbourBit(-dx, dy)
+			}
+		}
+	}
+	return
+}
+
+// return all neighbourhoods equivalent to the given one under rotations and
+// reflections
+func symmetricNeighbourhoods(mask int) (result []int) {
+
+	for _, m := range []int{mask, reflectNeighbourhood(mask)} {
+		for i := 0; i < 4; i++ {
+			result = append(result, m)
+			m = rotateNeighbourhood(m)
+		}
+	}
+	return
+}
+
+// return the letters of the arrangements of n living neighbours in Hensel
+// notation
+func henselArrangements(n int) string {
+	return henselLetters[min(n, 8-n)]
+}
+
+// return all neighbourhoods with n living neighbours whose arrangement is
+// given by the given letter in Hensel notation
+func henselNeighbourhoods(n int, letter byte) []int {
+
+	representative := henselRepresentatives[min(n, 8-n)][strings.IndexByte(henselArrangements(n), letter)]
+	if n > 4 {
+		representative ^= (neighbourhoods - 1) &^ neighbourBit(0, 0)
+	}
+	return symmetricNeighbourhoods(representative)
+}
+
+// return the transitions of a Life-like rule with the given numbers of
+// living neighbours, i.e., whether every neighbourhood makes a cell live or
+// not
+func totalisticTransitions(counts [9]bool) (result [neighbourhoods]bool) {
+
+	for mask := range result {
+		result[mask] = mask&neighbourBit(0, 0) == 0 && counts[bits.OnesCount(uint(mask))]
+	}
+	return
+}
+
+// parse the numbers of neighbours, along with their arrangements, given in
+// Hensel notation in spec, and return the transitions they define along with
+// the numbers of neighbours that appear in them
+func parseHensel(spec, rule string) (transitions [neighbourhoods]bool, counts [9]bool, err error) {
+
+	for i := 0; i < len(spec); {
+		if spec[i] < '0' || spec[i] > '8' {
+			return transitions, counts, fmt.Errorf("Wrong number of neighbours '%c' in the rule '%v'", spec[i], rule)
+		}
+		n := int(spec[i] - '0')
+		i++
+
+		// get the letters following this number, if any, and whether they are
+		// excluded
+		negated := i < len(spec) && spec[i] == '-'
+		if negated {
+			i++
+		}
+		j := i
+		for j < len(spec) && spec[j] >= 'a' && spec[j] <= 'z' {
+			j++
+		}
+		letters := spec[i:j]
+		i = j
+		if negated && letters == "" {
+			return transitions, counts, fmt.Errorf("Missing arrangements after '%v-' in the rule '%v'", n, rule)
+		}
+
+		// by default, all arrangements are selected unless some are given
+		// explicitly
+		selected := henselArrangements(n)
+		for _, letter := range letters {
+			if !strings.ContainsRune(henselArrangements(n), letter) {
+				return transitions, counts, fmt.Errorf("Wrong arrangement '%v%c' in the rule '%v'", n, letter, rule)
+			}
+		}
+		if letters != "" && !negated {
+			selected = letters
+		} else if negated {
+			selected = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(letters, r) {
+					return -1
+				}
+				return r
+			}, selected)
+		}
+
+		// and now add all neighbourhoods of the arrangements selected
+		if henselArrangements(n) == "" {
+			for mask := range transitions {
+				if mask&neighbourBit(0, 0) == 0 && bits.OnesCount(uint(mask)) == n {
+					transitions[mask] = true
+				}
+			}
+			counts[n] = true
+		}
+		for _, letter := range []byte(selected) {
+			for _, mask := range henselNeighbourhoods(n, letter) {
+				transitions[mask] = true
+			}
+			counts[n] = true
+		}
+	}
+	return
+}
+
+// return the numbers of neighbours, along with their arrangements, of the
+// given transitions in Hensel notation. Arrangements are given explicitly
+// only if some of them are missing, and they are negated when that is shorter
+func henselString(transitions *[neighbourhoods]bool) string {
+
+	var result strings.Builder
+	for n := 0; n <= 8; n++ {
+		letters := henselArrangements(n)
+		if letters == "" {
+			for mask := range transitions {
+				if transitions[mask] && bits.OnesCount(uint(mask)) == n {
+					fmt.Fprint(&result, n)
+					break
+				}
+			}
+			continue
+		}
+		var included, excluded strings.Builder
+		for _, letter := range []byte(letters) {
+			if transitions[henselNeighbourhoods(n, letter)[0]] {
+				included.WriteByte(letter)
+			} else {
+				excluded.WriteByte(letter)
+			}
+		}
+		switch {
+		case included.Len() == 0:
+		case excluded.Len() == 0:
+			fmt.Fprint(&result, n)
+		case included.Len() <= excluded.Len():
+			fmt.Fprintf(&result, "%v%v", n, included.String())
+		default:
+			fmt.Fprintf(&result, "%v-%v", n, excluded.String())
+		}
+	}
+	return result.String()
+}
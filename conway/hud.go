@@ -0,0 +1,76 @@
+// A small heads-up display (HUD) can be drawn over every frame with the
+// generation shown, its population and the rule used for computing it, so
+// that animations shared elsewhere are self-explanatory. The HUD is drawn with
+// the built-in bitmap font over a dark box at the upper-left corner of frames
+
+package conway
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Margin in pixels between the box of the HUD and its text
+const hudMargin = 2
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set whether a HUD with the generation, the population and the rule is drawn
+// over all frames of this game
+func (game *Conway) SetHUD(hud bool) {
+	game.hud = hud
+}
+
+// return the name of the rule used in the generation with the given index,
+// either the rule of the Conway's Game or the name of the automaton simulated
+func (game *Conway) ruleName(index int) string {
+
+	first := game.generations[0]
+	if rule := game.scheduledRule(index); rule != nil {
+		return rule.String()
+	}
+	if first.rule != nil {
+		return first.rule.String()
+	}
+	if first.isLife() {
+		return "B3/S23"
+	}
+	return first.automaton
+}
+
+// return a copy of the given frame of the generation with the given index with
+// the HUD of this game drawn over it. If no HUD has been requested, the same
+// image is returned
+func (game *Conway) drawHUD(index int, img *image.Paletted) *image.Paletted {
+
+	if !game.hud {
+		return img
+	}
+	population, _ := LookupMetric("population")
+	lines := []string{
+		fmt.Sprintf("Gen %v", game.offset+index),
+		fmt.Sprintf("Pop %v", int(game.measure(population, index))),
+		game.ruleName(index)}
+
+	result := copyFrame(img)
+	width := 0
+	for _, line := range lines {
+		width = max(width, TextWidth(line, 1))
+	}
+	height := len(lines)*(TextHeight(1)+hudMargin) + hudMargin
+	box := image.Rect(0, 0, width+2*hudMargin, height).Add(img.Rect.Min)
+	draw.Draw(result, box, image.NewUniform(img.Palette[img.Palette.Index(color.Black)]), image.Point{}, draw.Src)
+	text := img.Palette[img.Palette.Index(color.White)]
+	for i, line := range lines {
+		DrawText(result, box.Min.Add(image.Point{X: hudMargin, Y: hudMargin + i*(TextHeight(1)+hudMargin)}), line, text, 1)
+	}
+	return result
+}
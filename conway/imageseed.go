@@ -0,0 +1,61 @@
+// Any image, e.g., a photograph, can be used as the first generation once it
+// is thresholded: the image is scaled to the grid, and cells are alive where
+// the brightness of the image is at least a given cutoff, so that bright
+// regions are alive and dark regions are dead as they are usually shown
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return the brightness of the given color in the range [0, 1], i.e., its luma
+// computed over the gamma-encoded components, so that mid grey is 0.5
+func brightness(c color.Color) float64 {
+
+	r, g, b, _ := c.RGBA()
+	return (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 0xffff
+}
+
+// Return the contents of a grid with the given dimensions with the same
+// layout expected by Set, where the given image is scaled to the grid and
+// every cell is alive if the average brightness of the pixels it covers is at
+// least the given threshold, which must be in the range [0, 1], along with an
+// error otherwise
+func ImageContents(img image.Image, width, height int, threshold float64) ([]bool, error) {
+
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("The threshold %v is out of the range [0, 1]", threshold)
+	}
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, errors.New("Empty images can not be used as the first generation")
+	}
+
+	contents := make([]bool, (1+width)*(1+height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+
+			// the pixels covered by this cell, at least one
+			region := image.Rect(
+				bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height,
+				bounds.Min.X+(x+1)*bounds.Dx()/width, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+			region.Max.X, region.Max.Y = max(region.Max.X, region.Min.X+1), max(region.Max.Y, region.Min.Y+1)
+
+			var total float64
+			for py := region.Min.Y; py < region.Max.Y; py++ {
+				for px := region.Min.X; px < region.Max.X; px++ {
+					total += brightness(img.At(px, py))
+				}
+			}
+			contents[y*(1+width)+x] = total/float64(region.Dx()*region.Dy()) >= threshold
+		}
+	}
+	return contents, nil
+}
@@ -0,0 +1,86 @@
+// Animations can be made smoother by inserting interpolated frames between
+// consecutive generations, where newborn cells fade in and dying cells fade
+// out. Since frames are paletted images, every interpolated pixel takes the
+// colour of the palette closest to the blend of its colours in both frames
+
+package conway
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return the colour which is at the given fraction of the way from the first
+// colour to the second one
+func blendColors(from, to color.Color, fraction float64) color.Color {
+
+	c0 := color.NRGBAModel.Convert(from).(color.NRGBA)
+	c1 := color.NRGBAModel.Convert(to).(color.NRGBA)
+	blend := func(a, b uint8) uint8 {
+		return uint8(float64(a) + fraction*(float64(b)-float64(a)) + 0.5)
+	}
+	return color.NRGBA{R: blend(c0.R, c1.R), G: blend(c0.G, c1.G), B: blend(c0.B, c1.B), A: blend(c0.A, c1.A)}
+}
+
+// return the frame which is at the given fraction of the way from the first
+// frame to the second one, which must have the same bounds. The new frame is
+// given the palette of the first one
+func interpolateFrame(from, to *image.Paletted, fraction float64) *image.Paletted {
+
+	result := image.NewPaletted(from.Rect, from.Palette)
+	closest := make(map[[2]uint8]uint8)
+	for y := from.Rect.Min.Y; y < from.Rect.Max.Y; y++ {
+		for x := from.Rect.Min.X; x < from.Rect.Max.X; x++ {
+			key := [2]uint8{from.Pix[from.PixOffset(x, y)], to.Pix[to.PixOffset(x, y)]}
+			index, ok := closest[key]
+			if !ok {
+				index = key[0]
+				if c0, c1 := from.Palette[key[0]], to.Palette[key[1]]; c0 != c1 {
+					index = uint8(from.Palette.Index(blendColors(c0, c1, fraction)))
+				}
+				closest[key] = index
+			}
+			result.Pix[result.PixOffset(x, y)] = index
+		}
+	}
+	return result
+}
+
+// Insert the given number of interpolated frames between every pair of
+// consecutive frames of the given animation, which are shown delay 100th of a
+// second each, so that cells fade in and out smoothly. Frames with different
+// bounds are not interpolated
+func InterpolateGIF(anim *gif.GIF, steps, delay int) {
+
+	if steps < 1 || len(anim.Image) < 2 {
+		return
+	}
+	var images []*image.Paletted
+	var delays []int
+	var disposals []byte
+	for i, img := range anim.Image {
+		images = append(images, img)
+		delays = append(delays, anim.Delay[i])
+		if anim.Disposal != nil {
+			disposals = append(disposals, anim.Disposal[i])
+		}
+		if i+1 == len(anim.Image) || anim.Image[i+1].Rect != img.Rect {
+			continue
+		}
+		for step := 1; step <= steps; step++ {
+			images = append(images, interpolateFrame(img, anim.Image[i+1], float64(step)/float64(steps+1)))
+			delays = append(delays, delay)
+			if anim.Disposal != nil {
+				disposals = append(disposals, anim.Disposal[i])
+			}
+		}
+	}
+	anim.Image, anim.Delay = images, delays
+	if anim.Disposal != nil {
+		anim.Disposal = disposals
+	}
+}
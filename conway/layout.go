@@ -0,0 +1,138 @@
+// Layouts compose initial populations declaratively from any number of
+// patterns, every one located on the board, rotated, flipped and repeated
+// along a fixed offset, e.g., two glider guns facing each other or a salvo of
+// gliders. They are given in JSON, optionally along with the dimensions of the
+// board and the rule, e.g.:
+//
+//	{
+//	  "width": 200, "height": 100, "rule": "B3/S23",
+//	  "patterns": [
+//	    {"pattern": "gosper-gun", "x": 5, "y": 5},
+//	    {"pattern": "gosper-gun", "x": 158, "y": 5, "flip": "horizontal"},
+//	    {"pattern": "glider", "x": 20, "y": 70, "rotate": 90,
+//	     "repeat": 5, "dx": 12, "dy": 0}
+//	  ]
+//	}
+//
+// Patterns are given as in placements, i.e., the name of an object of the
+// dictionary, the name of a pattern of the library, an apgcode or rows
+// separated by '/'
+
+package conway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Layout
+// ----------------------------------------------------------------------------
+
+// type
+
+// A layout consists of the Placements of all its patterns, once repeated, and
+// optionally the dimensions (Width, Height) of the board and the Rule it was
+// designed for, which are zero and empty if not given
+type Layout struct {
+	Width, Height int
+	Rule          string
+	Placements    []Placement
+}
+
+// an item of a layout as given in JSON, which places Repeat copies of the same
+// pattern, every one shifted (Dx, Dy) cells with regard to the previous one
+type layoutItem struct {
+	Pattern string `json:"pattern"`
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	Rotate  int    `json:"rotate"`
+	Flip    string `json:"flip"`
+	Repeat  *int   `json:"repeat"`
+	Dx      int    `json:"dx"`
+	Dy      int    `json:"dy"`
+}
+
+// Functions
+
+// Return the layout given in the reader in JSON (see the description of the
+// format above), along with an error if it is not well formed. Unknown keys
+// are reported as errors, and every pattern is placed once unless a repetition
+// is given
+func ReadLayout(r io.Reader) (Layout, error) {
+
+	var spec struct {
+		Width    int          `json:"width"`
+		Height   int          `json:"height"`
+		Rule     string       `json:"rule"`
+		Patterns []layoutItem `json:"patterns"`
+	}
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return Layout{}, fmt.Errorf("Syntax error in the layout: %v", err)
+	}
+	if spec.Width < 0 || spec.Height < 0 {
+		return Layout{}, errors.New("The dimensions of the board must be positive")
+	}
+
+	layout := Layout{Width: spec.Width, Height: spec.Height, Rule: spec.Rule}
+	for i, item := range spec.Patterns {
+		if item.Pattern == "" {
+			return Layout{}, fmt.Errorf("Missing pattern in the item %v", 1+i)
+		}
+		if _, err := lookupPattern(item.Pattern); err != nil {
+			return Layout{}, fmt.Errorf("%v in the item %v", err, 1+i)
+		}
+		rotation, err := NewRotation(item.Rotate)
+		if err != nil {
+			return Layout{}, fmt.Errorf("%v in the item %v", err, 1+i)
+		}
+		flip, err := NewFlip(item.Flip)
+		if err != nil {
+			return Layout{}, fmt.Errorf("%v in the item %v", err, 1+i)
+		}
+		repeat := 1
+		if item.Repeat != nil {
+			repeat = *item.Repeat
+		}
+		if repeat < 1 {
+			return Layout{}, fmt.Errorf("The number of repetitions must be at least 1 in the item %v", 1+i)
+		}
+		for j := 0; j < repeat; j++ {
+			layout.Placements = append(layout.Placements, Placement{
+				Pattern:  item.Pattern,
+				X:        item.X + j*item.Dx,
+				Y:        item.Y + j*item.Dy,
+				Rotation: rotation,
+				Flip:     flip})
+		}
+	}
+	return layout, nil
+}
+
+// methods
+
+// Return the contents of a grid with the given dimensions with the same
+// layout expected by Set, where all patterns of this layout are placed, along
+// with an error if any does not fit in the grid. Its placements can also be
+// given to configurations as their patterns
+func (layout Layout) Contents(width, height int) ([]bool, error) {
+
+	contents := make([]bool, (1+width)*(1+height))
+	for _, placement := range layout.Placements {
+		pattern, at, err := placement.resolve()
+		if err != nil {
+			return nil, err
+		}
+		for _, cell := range pattern.Transform(placement.Rotation, placement.Flip).Cells {
+			p := cell.Add(at)
+			if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+				return nil, fmt.Errorf("The pattern '%v' does not fit in a grid of dimensions %vx%v at (%v, %v)", placement.Pattern, width, height, placement.X, placement.Y)
+			}
+			contents[p.Y*(1+width)+p.X] = true
+		}
+	}
+	return contents, nil
+}
@@ -0,0 +1,45 @@
+// The library provides a curated collection of classic patterns, e.g.,
+// spaceships, oscillators, guns and methuselahs, which are embedded in the
+// package as RLE files, so that they can be used by name
+
+package conway
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Library of patterns in RLE format, one per file named after the pattern
+//
+//go:embed library/*.rle
+var library embed.FS
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the pattern of the library with the given name, e.g., "gosper-gun",
+// along with an error if there is none
+func LibraryPattern(name string) (Pattern, error) {
+
+	f, err := library.Open(path.Join("library", name+".rle"))
+	if err != nil {
+		return Pattern{}, fmt.Errorf("Unknown pattern '%v'", name)
+	}
+	defer f.Close()
+	return LoadRLE(f)
+}
+
+// Return the names of all patterns of the library in alphabetical order
+func LibraryNames() []string {
+
+	var names []string
+	entries, _ := library.ReadDir("library")
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".rle"))
+	}
+	sort.Strings(names)
+	return names
+}
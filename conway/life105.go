@@ -0,0 +1,147 @@
+// The Life 1.05 format is found in many archived collections of patterns.
+// After the header "#Life 1.05", lines starting with "#D" describe the
+// pattern, "#N" states that it follows the Conway's Game and "#R" gives its
+// rule in S/B notation, e.g., "#R 23/36". Cells are given in blocks, every
+// one starting with "#P x y", which locates the upper-left corner of the
+// block, followed by its rows, where living cells are shown as '*' and dead
+// cells as '.'
+
+package conway
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Pattern
+// ----------------------------------------------------------------------------
+
+// Functions
+
+// Return the pattern read from the given reader in Life 1.05 format, cropped
+// to its bounding box, along with an error if any line is malformed
+func LoadLife105(r io.Reader) (Pattern, error) {
+
+	var pattern Pattern
+	var cells []image.Point
+	scanner := bufio.NewScanner(r)
+	header, block := false, false
+	var corner image.Point
+	row := 0
+	for nbline := 1; scanner.Scan(); nbline++ {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !header {
+			if line != "#Life 1.05" {
+				return Pattern{}, fmt.Errorf("Missing header '#Life 1.05' in line %v", nbline)
+			}
+			header = true
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			text := ""
+			if len(line) > 2 {
+				text = strings.TrimSpace(line[2:])
+			}
+			switch line[:min(2, len(line))] {
+			case "#D", "#C":
+				pattern.Comments = append(pattern.Comments, text)
+			case "#N":
+				pattern.Rule = "B3/S23"
+			case "#R":
+				survival, birth, ok := strings.Cut(text, "/")
+				if !ok || strings.Trim(survival+birth, "012345678") != "" {
+					return Pattern{}, fmt.Errorf("Wrong rule '%v' in line %v", text, nbline)
+				}
+				pattern.Rule = "B" + birth + "/S" + survival
+			case "#P":
+				fields := strings.Fields(text)
+				if len(fields) != 2 {
+					return Pattern{}, fmt.Errorf("Syntax error in the block given in line %v", nbline)
+				}
+				x, errx := strconv.Atoi(fields[0])
+				y, erry := strconv.Atoi(fields[1])
+				if errx != nil || erry != nil {
+					return Pattern{}, fmt.Errorf("Wrong coordinates of the block given in line %v", nbline)
+				}
+				corner, row, block = image.Point{X: x, Y: y}, 0, true
+			default:
+				return Pattern{}, fmt.Errorf("Unknown line '%v' in line %v", line, nbline)
+			}
+			continue
+		}
+
+		// rows are only acknowledged within blocks
+		if !block {
+			return Pattern{}, fmt.Errorf("Row given out of a block in line %v", nbline)
+		}
+		for column, char := range line {
+			switch char {
+			case '*':
+				cells = append(cells, corner.Add(image.Point{X: column, Y: row}))
+			case '.':
+			default:
+				return Pattern{}, fmt.Errorf("Unknown character '%c' in line %v, column %v", char, nbline, 1+column)
+			}
+		}
+		row++
+	}
+	if err := scanner.Err(); err != nil {
+		return Pattern{}, err
+	}
+	if !header {
+		return Pattern{}, errors.New("Missing header '#Life 1.05'")
+	}
+
+	result := NewPattern(cells)
+	result.Comments, result.Rule = pattern.Comments, pattern.Rule
+	return result, nil
+}
+
+// methods
+
+// Write this pattern to the given writer in Life 1.05 format, along with its
+// comments and rule, as a single block whose upper-left corner is the origin.
+// Note that the name and author of the pattern are not written since this
+// format does not acknowledge them, and neither are rules which can not be
+// given in S/B notation, e.g., non-totalistic rules
+func (pattern Pattern) ExportLife105(w io.Writer) error {
+
+	var result strings.Builder
+	result.WriteString("#Life 1.05\n")
+	for _, comment := range pattern.Comments {
+		fmt.Fprintf(&result, "#D %v\n", comment)
+	}
+	if rule, err := ParseLifeRule(pattern.Rule); err == nil && !rule.NonTotalistic() && !rule.Stochastic() {
+		if spec := rule.String(); spec == "B3/S23" {
+			result.WriteString("#N\n")
+		} else {
+			birth, survival, _ := strings.Cut(spec, "/")
+			fmt.Fprintf(&result, "#R %v/%v\n", survival[1:], birth[1:])
+		}
+	}
+
+	result.WriteString("#P 0 0\n")
+	rows := make([][]byte, pattern.Height)
+	for y := range rows {
+		rows[y] = []byte(strings.Repeat(".", pattern.Width))
+	}
+	for _, cell := range pattern.Cells {
+		rows[cell.Y][cell.X] = '*'
+	}
+	for _, row := range rows {
+		result.WriteString(strings.TrimRight(string(row), ".") + "\n")
+	}
+
+	_, err := io.WriteString(w, result.String())
+	return err
+}
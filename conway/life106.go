@@ -0,0 +1,79 @@
+// The Life 1.06 format is the simplest one for exchanging patterns: after the
+// header "#Life 1.06", every line gives the coordinates "x y" of a living
+// cell. Coordinates can be negative, so that patterns are usually centered at
+// the origin
+
+package conway
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Pattern
+// ----------------------------------------------------------------------------
+
+// Functions
+
+// Return the pattern read from the given reader in Life 1.06 format, cropped
+// to its bounding box, along with an error if it is not well formed
+func LoadLife106(r io.Reader) (Pattern, error) {
+
+	scanner := bufio.NewScanner(r)
+	header := false
+	var cells []image.Point
+	for nbline := 1; scanner.Scan(); nbline++ {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !header {
+			if line != "#Life 1.06" {
+				return Pattern{}, fmt.Errorf("Missing header '#Life 1.06' in line %v", nbline)
+			}
+			header = true
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return Pattern{}, fmt.Errorf("Syntax error in line %v", nbline)
+		}
+		x, errx := strconv.Atoi(fields[0])
+		y, erry := strconv.Atoi(fields[1])
+		if errx != nil || erry != nil {
+			return Pattern{}, fmt.Errorf("Wrong coordinates in line %v", nbline)
+		}
+		cells = append(cells, image.Point{X: x, Y: y})
+	}
+	if err := scanner.Err(); err != nil {
+		return Pattern{}, err
+	}
+	if !header {
+		return Pattern{}, errors.New("Missing header '#Life 1.06'")
+	}
+	return NewPattern(cells), nil
+}
+
+// methods
+
+// Write this pattern to the given writer in Life 1.06 format. Cells are given
+// relative to the upper-left corner of the pattern. Note that the name,
+// author, comments and rule of the pattern are not written since this format
+// does not acknowledge them
+func (pattern Pattern) ExportLife106(w io.Writer) error {
+
+	var result strings.Builder
+	result.WriteString("#Life 1.06\n")
+	for _, cell := range pattern.Cells {
+		fmt.Fprintf(&result, "%v %v\n", cell.X, cell.Y)
+	}
+	_, err := io.WriteString(w, result.String())
+	return err
+}
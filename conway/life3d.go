@@ -0,0 +1,275 @@
+// 3D Life generalizes the Conway's Game to a volume of cells, where every cell
+// has 26 neighbours. Rules are written in Bays' notation ElEuFlFu: living
+// cells survive if they have between El and Eu living neighbours, and dead
+// cells take birth if they have between Fl and Fu living neighbours, e.g.,
+// 4555 or 5766.
+//
+// The volume is stored separately from the image of every generation, which
+// shows its projection over the grid. Depth is encoded by the intensity of
+// colours, so that cells closer to the viewer are brighter
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Life3DRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// A 3D Life rule is given by the minimum and maximum number of living
+// neighbours that make living cells survive and dead cells take birth
+type Life3DRule struct {
+	SurvivalMin, SurvivalMax int
+	BirthMin, BirthMax       int
+}
+
+// Functions
+
+// Return the 3D Life rule given in Bays' notation, either as four digits,
+// e.g., 4555, or as four comma-separated numbers, e.g., 4,5,5,5, along with
+// an error if it is not well formed
+func ParseLife3DRule(spec string) (rule Life3DRule, err error) {
+
+	fields := strings.Split(strings.TrimSpace(spec), ",")
+	if len(fields) == 1 && len(fields[0]) == 4 {
+		fields = strings.Split(fields[0], "")
+	}
+	if len(fields) != 4 {
+		return rule, fmt.Errorf("Syntax error in the 3D rule '%v'", spec)
+	}
+
+	values := make([]int, 4)
+	for i, field := range fields {
+		if values[i], err = strconv.Atoi(strings.TrimSpace(field)); err != nil || values[i] < 0 || values[i] > 26 {
+			return rule, fmt.Errorf("Wrong number of neighbours '%v' in the 3D rule '%v'", field, spec)
+		}
+	}
+	if values[0] > values[1] || values[2] > values[3] {
+		return rule, fmt.Errorf("Empty range of neighbours in the 3D rule '%v'", spec)
+	}
+	return Life3DRule{SurvivalMin: values[0], SurvivalMax: values[1], BirthMin: values[2], BirthMax: values[3]}, nil
+}
+
+// methods
+
+// Return the specification of this rule in Bays' notation
+func (rule Life3DRule) String() string {
+
+	if max(rule.SurvivalMax, rule.BirthMax) > 9 {
+		return fmt.Sprintf("%v,%v,%v,%v", rule.SurvivalMin, rule.SurvivalMax, rule.BirthMin, rule.BirthMax)
+	}
+	return fmt.Sprintf("%v%v%v%v", rule.SurvivalMin, rule.SurvivalMax, rule.BirthMin, rule.BirthMax)
+}
+
+// Projection
+// ----------------------------------------------------------------------------
+
+// type
+
+// The projection decides how the volume is shown over the grid
+type Projection int
+
+const (
+	// the volume is seen from the front, so that only the closest living cell
+	// of every column is shown
+	OrthographicProjection Projection = iota
+
+	// the volume is seen at an angle, so that every layer is shifted half a
+	// cell up and right with respect to the one in front of it
+	ObliqueProjection
+)
+
+// Volume
+// ----------------------------------------------------------------------------
+
+// type
+
+// A volume consists of layers of cells with the same layout expected by Set,
+// the first one being the closest to the viewer, along with the rule and the
+// projection used for rendering it
+type volume struct {
+	depth      int
+	cells      []bool
+	rule       Life3DRule
+	projection Projection
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return a palette for 3D Life with the given depth, where dead cells are
+// given the first colour and living cells in every layer fade from the given
+// colour, in the closest layer, to a quarter of its brightness, in the
+// farthest one
+func Life3DPalette(dead, alive color.Color, depth int) color.Palette {
+
+	palette := color.Palette{dead}
+	r, g, b, _ := alive.RGBA()
+	for z := 0; z < depth; z++ {
+		fade := 1.0
+		if depth > 1 {
+			fade -= 0.75 * float64(z) / float64(depth-1)
+		}
+		palette = append(palette, color.RGBA{
+			uint8(fade * float64(r>>8)),
+			uint8(fade * float64(g>>8)),
+			uint8(fade * float64(b>>8)),
+			255})
+	}
+	return palette
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the volume of a generation of 3D Life with the given depth. The slice
+// consists of depth layers, each one with the same layout expected by Set,
+// where the first one is the closest to the viewer. The palette must have at
+// least a colour for every layer besides the colour of dead cells
+func (g *generation) SetVolume(cells []bool, depth int, rule Life3DRule, projection Projection) error {
+
+	if g.automaton != "life3d" {
+		return errors.New("Volumes can only be given to 3D Life")
+	}
+	if depth < 1 || depth >= len(g.img.Palette) {
+		return errors.New("The depth must be strictly positive and less than the number of colours of the palette")
+	}
+	if len(cells) != depth*(1+g.img.Rect.Max.Y/g.ratio.Y-g.img.Rect.Min.Y/g.ratio.Y)*
+		(1+g.img.Rect.Max.X/g.ratio.X-g.img.Rect.Min.X/g.ratio.X) {
+		return errors.New("Mismatched dimensions")
+	}
+
+	g.volume = &volume{
+		depth:      depth,
+		cells:      append([]bool(nil), cells...),
+		rule:       rule,
+		projection: projection}
+	g.project()
+	return nil
+}
+
+// Return the cells of the volume of a generation of 3D Life. The slice follows
+// the same layout expected by SetVolume
+func (g *generation) Volume() []bool {
+	return append([]bool(nil), g.volume.cells...)
+}
+
+// return the location in the volume of the cell at (x, y, z) taking into
+// account the boundary condition of this generation, and whether it lies in
+// the volume
+func (g *generation) locate3D(x, y, z int) (int, bool) {
+
+	p, ok := g.locate(x, y)
+	if !ok {
+		return 0, false
+	}
+	depth := g.volume.depth
+	switch g.boundary {
+	case TorusBoundary:
+		z = (z%depth + depth) % depth
+	case MirrorBoundary:
+		if z < 0 {
+			z = min(-1-z, depth-1)
+		} else if z >= depth {
+			z = max(2*depth-1-z, 0)
+		}
+	default:
+		if z < 0 || z >= depth {
+			return 0, false
+		}
+	}
+	width, height := 1+g.img.Rect.Max.X/g.ratio.X, 1+g.img.Rect.Max.Y/g.ratio.Y
+	return (z*height+p.Y)*width + p.X, true
+}
+
+// return whether the cell at location (x, y, z) is alive
+func (g *generation) alive3D(x, y, z int) bool {
+
+	i, ok := g.locate3D(x, y, z)
+	return ok && g.volume.cells[i]
+}
+
+// draw the projection of the volume of this generation over its image
+func (g *generation) project() {
+
+	width, height := g.img.Rect.Max.X/g.ratio.X, g.img.Rect.Max.Y/g.ratio.Y
+	for x := 0; x <= width; x++ {
+		for y := 0; y <= height; y++ {
+			g.SetColorIndex(x, y, 0)
+		}
+	}
+
+	// layers are drawn from the farthest to the closest one, so that closer
+	// cells hide those behind them
+	for z := g.volume.depth - 1; z >= 0; z-- {
+		shift := 0
+		if g.volume.projection == ObliqueProjection {
+			shift = (z - g.volume.depth/2) / 2
+		}
+		for x := 0; x < width; x++ {
+			for y := 0; y < height; y++ {
+				if !g.alive3D(x, y, z) {
+					continue
+				}
+				if px, py := x+shift, y-shift; px >= 0 && px < width && py >= 0 && py < height {
+					g.SetColorIndex(px, py, uint8(1+z))
+				}
+			}
+		}
+	}
+}
+
+// Return the next generation of 3D Life. Births and survivals follow the 3D
+// rule of this generation
+func (g *generation) nextLife3D() *generation {
+
+	// create a new generation with the same features than this one
+	next := g.empty(1 + g.nbgeneration)
+	next.volume = &volume{
+		depth:      g.volume.depth,
+		cells:      make([]bool, len(g.volume.cells)),
+		rule:       g.volume.rule,
+		projection: g.volume.projection}
+
+	rule := g.volume.rule
+	width, height := g.img.Rect.Max.X/g.ratio.X, g.img.Rect.Max.Y/g.ratio.Y
+	for z := 0; z < g.volume.depth; z++ {
+		for x := 0; x < width; x++ {
+			for y := 0; y < height; y++ {
+
+				// count the living neighbours of this cell
+				neighbours := 0
+				for dz := -1; dz <= 1; dz++ {
+					for dx := -1; dx <= 1; dx++ {
+						for dy := -1; dy <= 1; dy++ {
+							if (dx != 0 || dy != 0 || dz != 0) && g.alive3D(x+dx, y+dy, z+dz) {
+								neighbours++
+							}
+						}
+					}
+				}
+
+				// and decide whether it is alive in the next generation
+				i, _ := g.locate3D(x, y, z)
+				if g.volume.cells[i] {
+					next.volume.cells[i] = neighbours >= rule.SurvivalMin && neighbours <= rule.SurvivalMax
+				} else {
+					next.volume.cells[i] = neighbours >= rule.BirthMin && neighbours <= rule.BirthMax
+				}
+			}
+		}
+	}
+
+	// and draw its projection
+	next.project()
+	return next
+}
@@ -0,0 +1,70 @@
+// The age of living cells, i.e., the number of consecutive generations they
+// have been alive, can be tracked and queried. Optionally, cells can be given
+// a maximum lifespan, so that they die once they grow older than it regardless
+// of their neighbours
+
+package conway
+
+import "errors"
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the maximum number of consecutive generations that cells can be alive,
+// which is shared with all the following generations. Cells older than it die
+// in the next generation, and a lifespan equal to 0 means that cells can live
+// forever. The age of cells is tracked from now on. Only the Conway's Game
+// and other Life-like rules can be given a lifespan
+func (g *generation) SetLifespan(lifespan int) error {
+
+	if !g.isLife() {
+		return errors.New("Only Life-like rules can be given a lifespan")
+	}
+	if lifespan < 0 {
+		return errors.New("The lifespan can not be negative")
+	}
+	g.lifespan = lifespan
+	return nil
+}
+
+// Return the maximum lifespan of cells, or 0 if they can live forever
+func (g *generation) Lifespan() int {
+	return g.lifespan
+}
+
+// Return the age of the cell at location (x, y), i.e., the number of
+// consecutive generations it has been alive, or 0 if it is dead. Ages are
+// only tracked when cells are given a lifespan or a custom colour model, and
+// otherwise all living cells are 1 generation old
+func (g *generation) Age(x, y int) int {
+	return g.ageAt(x, y)
+}
+
+// Return the ages of all cells of a generation. The slice follows the same
+// layout expected by Set
+func (g *generation) Ages() []int {
+
+	width := 1 + g.img.Rect.Max.X/g.ratio.X
+	ages := make([]int, width*(1+g.img.Rect.Max.Y/g.ratio.Y))
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			ages[y*width+x] = g.ageAt(x, y)
+		}
+	}
+	return ages
+}
+
+// return whether the age of cells is tracked in this generation
+func (g *generation) tracksAges() bool {
+	return g.colorModel != nil || g.lifespan > 0
+}
+
+// return the age that the cell at location (x, y) would have in the next
+// generation if it were alive, and whether it can live that long
+func (g *generation) nextAge(x, y int) (int, bool) {
+
+	age := 1 + g.ageAt(x, y)
+	return age, g.lifespan == 0 || age <= g.lifespan
+}
@@ -0,0 +1,72 @@
+// Animations that end up in a cycle can be trimmed to a single period of it,
+// so that they loop seamlessly. Cycles are detected by comparing the checksums
+// of frames, so that a cycle is found only if frames repeat exactly, including
+// their colours. Any other animation can loop seamlessly by playing it forward
+// and then backward
+
+package conway
+
+import "image/gif"
+
+// Loop
+// ----------------------------------------------------------------------------
+
+// type
+
+// A loop starts at the frame with index Start and it repeats every Period
+// frames
+type Loop struct {
+	Start, Period int
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the first loop of the given animation, i.e., the first frame which is
+// repeated later along with the number of frames until its first repetition,
+// and whether any was found
+func FindLoop(anim *gif.GIF) (Loop, bool) {
+
+	seen := make(map[string]int)
+	for index, checksum := range GIFChecksums(anim) {
+		if start, ok := seen[checksum]; ok {
+			return Loop{Start: start, Period: index - start}, true
+		}
+		seen[checksum] = index
+	}
+	return Loop{}, false
+}
+
+// Trim the given animation to a single period of the given loop, so that it
+// ends exactly one frame before the loop repeats and it loops seamlessly. The
+// first frame is given the delay of the frame where the loop repeats, if any,
+// as it is shown right after the last one
+func TrimLoop(anim *gif.GIF, loop Loop) {
+
+	end := loop.Start + loop.Period
+	delay := anim.Delay[loop.Start]
+	if end < len(anim.Delay) {
+		delay = anim.Delay[end]
+	}
+	anim.Image = anim.Image[loop.Start:end]
+	anim.Delay = anim.Delay[loop.Start:end]
+	if anim.Disposal != nil {
+		anim.Disposal = anim.Disposal[loop.Start:end]
+	}
+	anim.Delay[0] = delay
+}
+
+// Append the frames of the given animation in reverse order, so that it is
+// played forward and then backward and it loops seamlessly. Neither the last
+// frame nor the first one are repeated, and every frame keeps its delay when
+// played backward
+func PingPong(anim *gif.GIF) {
+
+	for i := len(anim.Image) - 2; i > 0; i-- {
+		anim.Image = append(anim.Image, anim.Image[i])
+		anim.Delay = append(anim.Delay, anim.Delay[i])
+		if anim.Disposal != nil {
+			anim.Disposal = append(anim.Disposal, anim.Disposal[i])
+		}
+	}
+}
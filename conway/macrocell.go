@@ -0,0 +1,202 @@
+// The macrocell format of Golly stores patterns as the quadtree used by
+// HashLife, so that identical regions are written only once and enormous
+// patterns can be exchanged. After the header "[M2]", lines starting with '#'
+// give the rule ("#R") and comments ("#C" or "#D"), and every other line
+// defines a node of the quadtree: nodes of 8x8 cells are given as rows of '.'
+// (dead cells) and '*' (living cells) ended with '$', and larger nodes are
+// given as "k nw ne sw se", where 2^k is the size of the node and its
+// quadrants are the numbers of the lines where they were defined, starting
+// from 1, or 0 if they are empty. The last node is the root of the quadtree
+
+package conway
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Pattern
+// ----------------------------------------------------------------------------
+
+// Functions
+
+// Return the pattern read from the given reader in macrocell format, cropped
+// to its bounding box, along with an error if any line is malformed
+func LoadMacrocell(r io.Reader) (Pattern, error) {
+
+	var pattern Pattern
+	life := newHashLife(nil, nil)
+	var nodes []*hashNode
+	scanner := bufio.NewScanner(r)
+	header := false
+	for nbline := 1; scanner.Scan(); nbline++ {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !header {
+			if !strings.HasPrefix(line, "[M2]") {
+				return Pattern{}, fmt.Errorf("Missing header '[M2]' in line %v", nbline)
+			}
+			header = true
+			continue
+		}
+
+		// rules and comments
+		if strings.HasPrefix(line, "#") {
+			text := ""
+			if len(line) > 2 {
+				text = strings.TrimSpace(line[2:])
+			}
+			switch line[:min(2, len(line))] {
+			case "#R":
+				pattern.Rule = text
+			case "#C", "#D":
+				pattern.Comments = append(pattern.Comments, text)
+			}
+			continue
+		}
+
+		// nodes of 8x8 cells
+		if line[0] == '.' || line[0] == '*' || line[0] == '$' {
+			node, x, y := life.empty(3), 0, 0
+			for column, char := range line {
+				switch char {
+				case '.':
+					x++
+				case '*':
+					if x >= 8 || y >= 8 {
+						return Pattern{}, fmt.Errorf("The node exceeds 8x8 cells in line %v, column %v", nbline, 1+column)
+					}
+					node = life.set(node, x, y)
+					x++
+				case '$':
+					x, y = 0, y+1
+				default:
+					return Pattern{}, fmt.Errorf("Unknown character '%c' in line %v, column %v", char, nbline, 1+column)
+				}
+			}
+			nodes = append(nodes, node)
+			continue
+		}
+
+		// and larger nodes
+		fields := strings.Fields(line)
+		if len(fields) != 5 {
+			return Pattern{}, fmt.Errorf("Syntax error in line %v", nbline)
+		}
+		level, err := strconv.Atoi(fields[0])
+		if err != nil || level < 4 || level > 62 {
+			return Pattern{}, fmt.Errorf("Wrong size of the node in line %v", nbline)
+		}
+		var quadrants [4]*hashNode
+		for i, field := range fields[1:] {
+			index, err := strconv.Atoi(field)
+			if err != nil || index < 0 || index > len(nodes) {
+				return Pattern{}, fmt.Errorf("Unknown node '%v' in line %v", field, nbline)
+			}
+			if index == 0 {
+				quadrants[i] = life.empty(level - 1)
+			} else if quadrants[i] = nodes[index-1]; quadrants[i].level != level-1 {
+				return Pattern{}, fmt.Errorf("The node '%v' has a wrong size in line %v", field, nbline)
+			}
+		}
+		nodes = append(nodes, life.join(quadrants[0], quadrants[1], quadrants[2], quadrants[3]))
+	}
+	if err := scanner.Err(); err != nil {
+		return Pattern{}, err
+	}
+	if !header {
+		return Pattern{}, errors.New("Missing header '[M2]'")
+	}
+	if len(nodes) == 0 {
+		return pattern, nil
+	}
+
+	// the cells of the pattern are those of the root
+	var cells []image.Point
+	var collect func(node *hashNode, corner image.Point)
+	collect = func(node *hashNode, corner image.Point) {
+		if node.population == 0 {
+			return
+		}
+		if node.level == 0 {
+			cells = append(cells, corner)
+			return
+		}
+		half := 1 << (node.level - 1)
+		collect(node.nw, corner)
+		collect(node.ne, corner.Add(image.Point{X: half}))
+		collect(node.sw, corner.Add(image.Point{Y: half}))
+		collect(node.se, corner.Add(image.Point{X: half, Y: half}))
+	}
+	collect(nodes[len(nodes)-1], image.Point{})
+
+	result := NewPattern(cells)
+	result.Comments, result.Rule = pattern.Comments, pattern.Rule
+	return result, nil
+}
+
+// methods
+
+// Write this pattern to the given writer in macrocell format, along with its
+// comments and rule, if they are known. The quadtree is built with the same
+// nodes used by HashLife, so that identical regions are written only once
+func (pattern Pattern) ExportMacrocell(w io.Writer) error {
+
+	var result strings.Builder
+	result.WriteString("[M2] (conway-game)\n")
+	if pattern.Rule != "" {
+		fmt.Fprintf(&result, "#R %v\n", pattern.Rule)
+	}
+	for _, comment := range pattern.Comments {
+		fmt.Fprintf(&result, "#C %v\n", comment)
+	}
+
+	// nodes are written after their quadrants, and they are numbered in the
+	// same order
+	life := newHashLife(nil, pattern.Cells)
+	indexes := make(map[*hashNode]int)
+	var write func(node *hashNode) int
+	write = func(node *hashNode) int {
+		if node.population == 0 {
+			return 0
+		}
+		if index, ok := indexes[node]; ok {
+			return index
+		}
+		if node.level == 3 {
+			var rows []string
+			for y := 0; y < 8; y++ {
+				var row strings.Builder
+				for x := 0; x < 8; x++ {
+					if life.get(node, x, y) {
+						row.WriteByte('*')
+					} else {
+						row.WriteByte('.')
+					}
+				}
+				rows = append(rows, strings.TrimRight(row.String(), "."))
+			}
+			for len(rows) > 0 && rows[len(rows)-1] == "" {
+				rows = rows[:len(rows)-1]
+			}
+			result.WriteString(strings.Join(rows, "$") + "$\n")
+		} else {
+			nw, ne, sw, se := write(node.nw), write(node.ne), write(node.sw), write(node.se)
+			fmt.Fprintf(&result, "%v %v %v %v %v\n", node.level, nw, ne, sw, se)
+		}
+		indexes[node] = 1 + len(indexes)
+		return indexes[node]
+	}
+	write(life.root)
+
+	_, err := io.WriteString(w, result.String())
+	return err
+}
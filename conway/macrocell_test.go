@@ -0,0 +1,97 @@
+package conway
+
+import (
+	"bytes"
+	"image"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// return the given cells sorted by rows
+func sortCells(cells []image.Point) []image.Point {
+
+	result := append([]image.Point(nil), cells...)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Y < result[j].Y || result[i].Y == result[j].Y && result[i].X < result[j].X
+	})
+	return result
+}
+
+func TestLoadMacrocell(t *testing.T) {
+
+	// a glider in the lower-right quadrant of a node of 16x16 cells, and the
+	// same glider repeated in the four quadrants of a node of 32x32 cells
+	glider := "[M2] (golly 2.0)\n#R B3/S23\n#C A glider\n.*$..*$***$\n4 0 0 0 1\n"
+	pattern, err := LoadMacrocell(strings.NewReader(glider))
+	if err != nil {
+		t.Fatalf("LoadMacrocell: %v", err)
+	}
+	want := []image.Point{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}
+	if got := sortCells(pattern.Cells); !reflect.DeepEqual(got, want) || pattern.Width != 3 || pattern.Height != 3 {
+		t.Errorf("LoadMacrocell = %v (%vx%v), want %v (3x3)", got, pattern.Width, pattern.Height, want)
+	}
+	if pattern.Rule != "B3/S23" || !reflect.DeepEqual(pattern.Comments, []string{"A glider"}) {
+		t.Errorf("LoadMacrocell = rule %q and comments %q, want \"B3/S23\" and [\"A glider\"]", pattern.Rule, pattern.Comments)
+	}
+
+	pattern, err = LoadPattern(strings.NewReader(glider + "5 2 2 2 2\n"))
+	if err != nil {
+		t.Fatalf("LoadPattern: %v", err)
+	}
+	if len(pattern.Cells) != 20 || pattern.Width != 19 || pattern.Height != 19 {
+		t.Errorf("LoadPattern = %v cells (%vx%v), want 20 cells (19x19)", len(pattern.Cells), pattern.Width, pattern.Height)
+	}
+}
+
+func TestLoadMacrocellErrors(t *testing.T) {
+
+	for _, test := range []struct {
+		name, contents string
+	}{
+		{"missing header", ".*$\n"},
+		{"unknown character", "[M2]\n.o$\n"},
+		{"wide leaf", "[M2]\n........*$\n"},
+		{"tall leaf", "[M2]\n$$$$$$$$*$\n"},
+		{"syntax error", "[M2]\n.*$\n4 1 0 0\n"},
+		{"small node", "[M2]\n3 0 0 0 0\n"},
+		{"unknown node", "[M2]\n.*$\n4 0 0 0 2\n"},
+		{"wrong quadrant", "[M2]\n.*$\n4 0 0 0 1\n5 0 0 0 1\n"},
+	} {
+		if _, err := LoadMacrocell(strings.NewReader(test.contents)); err == nil {
+			t.Errorf("LoadMacrocell of %v did not fail", test.name)
+		}
+	}
+}
+
+// patterns written in macrocell format are read back with the same cells,
+// rule and comments
+func TestExportMacrocell(t *testing.T) {
+
+	for _, name := range LibraryNames() {
+		pattern, err := LibraryPattern(name)
+		if err != nil {
+			t.Fatalf("LibraryPattern(%q): %v", name, err)
+		}
+		pattern.Rule, pattern.Comments = "B3/S23", []string{"Exported from the library", name}
+
+		var buffer bytes.Buffer
+		if err := pattern.ExportMacrocell(&buffer); err != nil {
+			t.Fatalf("ExportMacrocell of %v: %v", name, err)
+		}
+		loaded, err := LoadMacrocell(&buffer)
+		if err != nil {
+			t.Fatalf("LoadMacrocell of %v: %v\n%s", name, err, buffer.String())
+		}
+		if got, want := sortCells(loaded.Cells), sortCells(pattern.Cells); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: cells %v, want %v", name, got, want)
+		}
+		if loaded.Width != pattern.Width || loaded.Height != pattern.Height {
+			t.Errorf("%v: dimensions %vx%v, want %vx%v", name, loaded.Width, loaded.Height, pattern.Width, pattern.Height)
+		}
+		if loaded.Rule != pattern.Rule || !reflect.DeepEqual(loaded.Comments, pattern.Comments) {
+			t.Errorf("%v: rule %q and comments %q, want %q and %q", name, loaded.Rule, loaded.Comments, pattern.Rule, pattern.Comments)
+		}
+	}
+}
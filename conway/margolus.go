@@ -0,0 +1,199 @@
+// Block automata use the Margolus neighbourhood: the grid is partitioned into
+// blocks of 2x2 cells and every block is replaced as a whole according to a
+// rule. Partitions alternate between generations, so that blocks are aligned
+// with even coordinates in even generations and shifted by one cell along both
+// axes in odd generations. Reversible rules such as Critters and the
+// billiard-ball model (BBM) conserve particles only over grids with even
+// dimensions wrapped around as a torus.
+//
+// Rules which complement empty blocks, such as Critters, would make the whole
+// grid flicker. Instead, they are simulated with every other generation
+// complemented, which keeps empty regions empty
+
+package conway
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MargolusRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// A Margolus rule maps every block of 2x2 cells to the block which replaces
+// it. Blocks are given as 4-bit patterns where the upper-left cell is the
+// least significant bit, followed by the upper-right, the lower-left and the
+// lower-right cells
+type MargolusRule [16]uint8
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the rule of Critters: blocks with exactly two living cells are left
+// unchanged, and all others are complemented. Blocks with three living cells
+// are additionally rotated a half turn
+func CrittersRule() (rule MargolusRule) {
+
+	for block := range rule {
+		switch ones := countBlock(uint8(block)); ones {
+		case 2:
+			rule[block] = uint8(block)
+		case 3:
+			rule[block] = rotateBlock(^uint8(block) & 0xf)
+		default:
+			rule[block] = ^uint8(block) & 0xf
+		}
+	}
+	return
+}
+
+// Return the rule of the billiard-ball model: a single living cell moves to
+// the opposite corner of its block, two living cells on a diagonal move to the
+// other diagonal, and all other blocks are left unchanged
+func BBMRule() (rule MargolusRule) {
+
+	for block := range rule {
+		switch {
+		case countBlock(uint8(block)) == 1:
+			rule[block] = rotateBlock(uint8(block))
+		case block == 0b1001:
+			rule[block] = 0b0110
+		case block == 0b0110:
+			rule[block] = 0b1001
+		default:
+			rule[block] = uint8(block)
+		}
+	}
+	return
+}
+
+// Return the rule of Tron: blocks whose cells are all alive or all dead are
+// complemented, and all others are left unchanged
+func TronRule() (rule MargolusRule) {
+
+	for block := range rule {
+		rule[block] = uint8(block)
+	}
+	rule[0b0000], rule[0b1111] = 0b1111, 0b0000
+	return
+}
+
+// Return the Margolus rule given in spec, either as the name of a predefined
+// rule (critters, bbm or tron) or as the 16 blocks which replace every block,
+// from 0 to 15, separated by commas, e.g., "15,14,13,3,11,5,6,1,7,9,10,2,12,4,8,0"
+// for Critters
+func ParseMargolusRule(spec string) (MargolusRule, error) {
+
+	switch strings.ToLower(strings.TrimSpace(spec)) {
+	case "critters":
+		return CrittersRule(), nil
+	case "bbm":
+		return BBMRule(), nil
+	case "tron":
+		return TronRule(), nil
+	}
+
+	var rule MargolusRule
+	blocks := strings.Split(spec, ",")
+	if len(blocks) != len(rule) {
+		return rule, fmt.Errorf("Margolus rules require exactly %v blocks, but %v were given in the rule '%v'", len(rule), len(blocks), spec)
+	}
+	for i, block := range blocks {
+		value, err := strconv.Atoi(strings.TrimSpace(block))
+		if err != nil || value < 0 || value >= len(rule) {
+			return rule, fmt.Errorf("Wrong block '%v' in the rule '%v'", block, spec)
+		}
+		rule[i] = uint8(value)
+	}
+	return rule, nil
+}
+
+// return the number of living cells of the given block
+func countBlock(block uint8) (result int) {
+
+	for ; block != 0; block >>= 1 {
+		result += int(block & 1)
+	}
+	return
+}
+
+// return the given block rotated a half turn, i.e., with the upper-left and
+// lower-right cells swapped, and also the upper-right and lower-left cells
+func rotateBlock(block uint8) uint8 {
+	return (block&1)<<3 | (block&2)<<1 | (block&4)>>1 | (block&8)>>3
+}
+
+// methods
+
+// Return whether this rule is reversible, i.e., whether every block is the
+// replacement of exactly one block
+func (rule MargolusRule) Reversible() bool {
+
+	var seen [16]bool
+	for _, block := range rule {
+		if seen[block] {
+			return false
+		}
+		seen[block] = true
+	}
+	return true
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the rule of block automata which use the Margolus neighbourhood
+func (g *generation) SetMargolusRule(rule MargolusRule) {
+
+	// rules are applied separately in every partition. If empty and full
+	// blocks are swapped, then generations with odd indices are stored
+	// complemented: blocks of even generations are complemented after
+	// applying the rule, and those of odd generations before
+	phases := [2]MargolusRule{rule, rule}
+	if rule[0b0000] == 0b1111 && rule[0b1111] == 0b0000 {
+		for block := range rule {
+			phases[0][block] = ^rule[block] & 0xf
+			phases[1][block] = rule[^block&0xf]
+		}
+	}
+	g.margolus = &phases
+}
+
+// return the next generation of a block automaton. Blocks are aligned with
+// even coordinates in the first generation and they alternate with those
+// shifted by one cell in the following ones, also across the burn-in phase.
+// Cells of blocks which fall off the grid are dead unless the boundary
+// condition says otherwise
+func (g *generation) nextMargolus() *generation {
+
+	next := g.empty(1 + g.nbgeneration)
+	offset := g.partition
+	next.partition = 1 - offset
+	width, height := g.dimensions()
+	for y := -offset; y < height; y += 2 {
+		for x := -offset; x < width; x += 2 {
+
+			// get the pattern of the current block
+			var block uint8
+			for bit := 0; bit < 4; bit++ {
+				if p, ok := g.locate(x+bit%2, y+bit/2); ok && g.ColorIndexAt(p.X, p.Y) != 0 {
+					block |= 1 << bit
+				}
+			}
+
+			// and replace it in the next generation
+			replacement := g.margolus[offset][block]
+			for bit := 0; bit < 4; bit++ {
+				if p, ok := g.locate(x+bit%2, y+bit/2); ok && replacement&(1<<bit) != 0 {
+					next.SetColorIndex(p.X, p.Y, g.cellColor(p.X, p.Y))
+				}
+			}
+		}
+	}
+	return next
+}
@@ -0,0 +1,54 @@
+// Conway's Games retain all generations in memory, so that large grids or
+// long runs can easily exhaust the available memory. The functions given here
+// estimate the peak memory used by a game before running it
+
+package conway
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Size in bytes of the buffers used by the GIF encoder besides the frame being
+// encoded (LZW tables and output buffers)
+const encoderOverhead = 64 * 1024
+
+// MemoryEstimate
+// ----------------------------------------------------------------------------
+
+// type
+
+// An estimation of the peak memory (in bytes) used by a game, broken down into
+// the memory used for retaining generations, the memory used for frames that
+// are computed separately (e.g., when averaging), and the buffers of the GIF
+// encoder
+type MemoryEstimate struct {
+	Generations uint64
+	Frames      uint64
+	Encoder     uint64
+	Total       uint64
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return an estimation of the peak memory used by a game over a grid with the
+// given dimensions and aspect ratio which retains the given number of
+// generations and renders the given number of frames. If average is strictly
+// greater than 1, frames are computed separately from the generations
+func EstimateMemory(width, height int, ratio AspectRatio, generations, frames, average int) MemoryEstimate {
+
+	// every generation and frame takes one byte per pixel
+	pixels := uint64(1+width) * uint64(ratio.X) * uint64(1+height) * uint64(ratio.Y)
+
+	var estimate MemoryEstimate
+	estimate.Generations = uint64(generations) * pixels
+	if average > 1 {
+		estimate.Frames = uint64(frames) * pixels
+	}
+
+	// the encoder processes a frame at a time
+	estimate.Encoder = pixels + encoderOverhead
+
+	// and return the estimation
+	estimate.Total = estimate.Generations + estimate.Frames + estimate.Encoder
+	return estimate
+}
@@ -0,0 +1,287 @@
+// Metrics measure how interesting every generation is, e.g., the number of
+// cells which changed or the number of objects on the board. They are kept in
+// a registry, so that the same implementation serves all subsystems which
+// need them: stop conditions, which end games once they become boring,
+// adaptive frame selection, which renders only interesting generations, and
+// any explorer scoring runs. New metrics can be registered by users of this
+// package
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metric
+// ----------------------------------------------------------------------------
+
+// type
+
+// The input of metrics consists of the Index of the generation measured,
+// starting from 0 with the first generation of the game, the size of the
+// Board rendered, and the locations of its Living cells and of those of the
+// Previous generation, relative to the upper-left corner of the board. The
+// previous generation of the first one is empty
+type MetricInput struct {
+	Index            int
+	Board            image.Point
+	Living, Previous []image.Point
+}
+
+// A metric returns a measure of the generation given in its input
+type Metric func(input MetricInput) float64
+
+// Registry of metrics
+var metrics = map[string]Metric{
+	"population": populationMetric,
+	"derivative": derivativeMetric,
+	"activity":   activityMetric,
+	"entropy":    entropyMetric,
+	"objects":    objectsMetric,
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Register the given metric with the given name, which must not be in use
+func RegisterMetric(name string, metric Metric) error {
+
+	if metric == nil {
+		return errors.New("Metrics can not be nil")
+	}
+	if _, ok := metrics[name]; ok {
+		return fmt.Errorf("The metric '%v' is already registered", name)
+	}
+	metrics[name] = metric
+	return nil
+}
+
+// Return the metric registered with the given name, along with an error if
+// there is none
+func LookupMetric(name string) (Metric, error) {
+
+	metric, ok := metrics[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown metric '%v'", name)
+	}
+	return metric, nil
+}
+
+// Return the names of all metrics registered in alphabetical order
+func MetricNames() []string {
+
+	var names []string
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// return the number of living cells
+func populationMetric(input MetricInput) float64 {
+	return float64(len(input.Living))
+}
+
+// return the absolute change of the population with regard to the previous
+// generation
+func derivativeMetric(input MetricInput) float64 {
+	return math.Abs(float64(len(input.Living) - len(input.Previous)))
+}
+
+// return the fraction of cells of the board which were either born or died
+func activityMetric(input MetricInput) float64 {
+
+	cells := input.Board.X * input.Board.Y
+	if cells == 0 {
+		return 0
+	}
+	previous := make(map[image.Point]bool, len(input.Previous))
+	for _, p := range input.Previous {
+		previous[p] = true
+	}
+	changes := len(input.Previous)
+	for _, p := range input.Living {
+		if previous[p] {
+			changes--
+		} else {
+			changes++
+		}
+	}
+	return float64(changes) / float64(cells)
+}
+
+// return the Shannon entropy, in bits, of the distribution of the patterns of
+// the blocks of 2x2 cells which tile the board. It ranges from 0, for uniform
+// boards, to 4
+func entropyMetric(input MetricInput) float64 {
+
+	blocks := make(map[image.Point]uint8)
+	for _, p := range input.Living {
+		blocks[image.Point{X: p.X / 2, Y: p.Y / 2}] |= 1 << (2*(p.Y%2) + p.X%2)
+	}
+	var counts [16]int
+	counts[0] = ((input.Board.X + 1) / 2) * ((input.Board.Y + 1) / 2)
+	for _, pattern := range blocks {
+		counts[0]--
+		counts[pattern]++
+	}
+
+	var total, result float64
+	for _, count := range counts {
+		total += float64(count)
+	}
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / total
+			result -= p * math.Log2(p)
+		}
+	}
+	return result
+}
+
+// return the number of objects, i.e., groups of living cells which are close
+// enough to interact
+func objectsMetric(input MetricInput) float64 {
+	return float64(len(groupCells(input.Living)))
+}
+
+// Condition
+// ----------------------------------------------------------------------------
+
+// type
+
+// A condition compares the value of a Metric with a Threshold with one of the
+// operators <, <=, > or >=
+type Condition struct {
+	Metric    string
+	Operator  string
+	Threshold float64
+	metric    Metric
+}
+
+// Functions
+
+// Return the condition given in spec as METRIC OPERATOR THRESHOLD, e.g.,
+// "activity<0.001", along with an error if it is not well formed or the
+// metric is not registered
+func ParseCondition(spec string) (condition Condition, err error) {
+
+	index := strings.IndexAny(spec, "<>")
+	if index < 0 {
+		return condition, fmt.Errorf("Missing operator in the condition '%v'", spec)
+	}
+	condition.Metric, condition.Operator = strings.TrimSpace(spec[:index]), spec[index:index+1]
+	threshold := spec[index+1:]
+	if strings.HasPrefix(threshold, "=") {
+		condition.Operator, threshold = condition.Operator+"=", threshold[1:]
+	}
+	if condition.Threshold, err = strconv.ParseFloat(strings.TrimSpace(threshold), 64); err != nil {
+		return condition, fmt.Errorf("Wrong threshold in the condition '%v'", spec)
+	}
+	if condition.metric, err = LookupMetric(condition.Metric); err != nil {
+		return condition, err
+	}
+	return condition, nil
+}
+
+// methods
+
+// Return whether the given value satisfies this condition
+func (condition Condition) Holds(value float64) bool {
+
+	switch condition.Operator {
+	case "<":
+		return value < condition.Threshold
+	case "<=":
+		return value <= condition.Threshold
+	case ">":
+		return value > condition.Threshold
+	case ">=":
+		return value >= condition.Threshold
+	}
+	return false
+}
+
+// Return the specification of this condition
+func (condition Condition) String() string {
+	return fmt.Sprintf("%v%v%v", condition.Metric, condition.Operator, condition.Threshold)
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return the value of the metric registered with the given name for the
+// generation with the given index, along with an error if the metric is not
+// registered or the generation has not been computed
+func (game *Conway) Measure(name string, index int) (float64, error) {
+
+	metric, err := LookupMetric(name)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 || index >= game.nbgenerations || !game.computed(index) {
+		return 0, fmt.Errorf("The generation %v has not been computed", index)
+	}
+	return game.measure(metric, index), nil
+}
+
+// return the value of the given metric for the generation with the given
+// index, which must have been computed
+func (game *Conway) measure(metric Metric, index int) float64 {
+
+	// the living cells are given relative to the board rendered, so that
+	// those in the halo are ignored
+	board := game.board()
+	within := func(index int) (result []image.Point) {
+		for _, p := range game.living(index) {
+			if p.In(board) {
+				result = append(result, p.Sub(board.Min))
+			}
+		}
+		return
+	}
+
+	input := MetricInput{Index: index, Board: board.Size(), Living: within(index)}
+	if index > 0 {
+		input.Previous = within(index - 1)
+	}
+	return metric(input)
+}
+
+// Set the condition which stops this game at the first generation which
+// satisfies it. Stop conditions are only checked by the dense engine
+func (game *Conway) SetUntil(condition Condition) error {
+
+	if condition.metric == nil {
+		return errors.New("Conditions must be created with ParseCondition")
+	}
+	if game.engine != DenseEngine {
+		return errors.New("Stop conditions can only be checked by the dense engine")
+	}
+	game.until = &condition
+	return nil
+}
+
+// return whether the generation with the given index satisfies the stop
+// condition of this game, if any
+func (game *Conway) satisfied(index int) bool {
+	return game.until != nil && game.until.Holds(game.measure(game.until.metric, index))
+}
+
+// Return a frame selector which renders only the generations which satisfy
+// the given condition. Generations which have not been computed yet are
+// always selected, so that memory estimates are conservative
+func (game *Conway) MetricFrames(condition Condition) FrameSelector {
+	return func(gen int) bool {
+		return gen >= game.nbgenerations || !game.computed(gen) || condition.Holds(game.measure(condition.metric, gen))
+	}
+}
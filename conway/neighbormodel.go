@@ -0,0 +1,25 @@
+// The neighbour colour model gives every living cell a colour of the gradient
+// palette indexed by its number of living neighbours, so that crowded regions
+// are easily told apart from isolated cells. It needs no other information
+// than the neighbours counted for applying the rule
+
+package conway
+
+// NeighborColorModel
+// ----------------------------------------------------------------------------
+
+// type
+
+// The neighbour colour model spreads the number of living neighbours of cells,
+// from 0 to 8, evenly across the gradient palette, so that cells with no
+// living neighbours are given the first colour of living cells and those with
+// 8 the last one, i.e., color index 255
+type NeighborColorModel struct{}
+
+// methods
+
+// Return the color index of the given living cell according to its number of
+// living neighbours
+func (model NeighborColorModel) CellColor(cell Cell) uint8 {
+	return uint8(1 + min(max(cell.Neighbors, 0), 8)*254/8)
+}
@@ -0,0 +1,174 @@
+// Noise flips the state of random cells every so many generations, so that
+// soups do not die out and long animations can be made from small
+// populations. Cells are chosen with their own random number generator, so
+// that noise does not change the random numbers drawn by other features
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Noise
+// ----------------------------------------------------------------------------
+
+// type
+
+// Noise flips either a number of Cells or a Fraction of all cells of the board
+// every Every generations
+type Noise struct {
+	Cells    int
+	Fraction float64
+	Every    int
+}
+
+// Functions
+
+// Return the noise given in spec, either as a number of cells or as a
+// percentage of the board followed by '%', which is applied every the given
+// number of generations
+func ParseNoise(spec string, every int) (Noise, error) {
+
+	noise := Noise{Every: every}
+	if percentage, found := strings.CutSuffix(strings.TrimSpace(spec), "%"); found {
+		value, err := strconv.ParseFloat(percentage, 64)
+		if err != nil || value <= 0 || value > 100 {
+			return noise, fmt.Errorf("Wrong percentage of cells '%v'", spec)
+		}
+		noise.Fraction = value / 100
+		return noise, nil
+	}
+	cells, err := strconv.Atoi(strings.TrimSpace(spec))
+	if err != nil || cells <= 0 {
+		return noise, fmt.Errorf("Wrong number of cells '%v'", spec)
+	}
+	noise.Cells = cells
+	return noise, nil
+}
+
+// return a sample of k distinct integers in the range [0, n) drawn from the
+// given random number generator with Floyd's algorithm, in the order they
+// were drawn
+func sample(rng *rand.Rand, n, k int) []int {
+
+	chosen := make(map[int]bool, k)
+	result := make([]int, 0, k)
+	for j := n - k; j < n; j++ {
+		t := rng.Intn(j + 1)
+		if chosen[t] {
+			t = j
+		}
+		chosen[t] = true
+		result = append(result, t)
+	}
+	return result
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// flip the state of the cell at location (x, y), which is given the colour c
+// if it is born
+func (g *generation) flip(x, y int, c uint8) {
+
+	if g.ColorIndexAt(x, y) != 0 {
+		g.SetColorIndex(x, y, 0)
+		if g.ages != nil {
+			g.setAge(x, y, 0)
+		}
+		return
+	}
+	// as in the rules, cells given the color of dead cells are dead
+	if c == 0 {
+		return
+	}
+	g.SetColorIndex(x, y, c)
+	if g.tracksAges() {
+		g.setAge(x, y, 1)
+	}
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the noise applied to this game, which draws random numbers from the
+// given generator. Noise applies only to the cells of the board rendered, and
+// only the Conway's Game and other Life-like rules simulated with the dense or
+// sparse engines can be perturbed
+func (game *Conway) SetNoise(noise Noise, rng *rand.Rand) error {
+
+	if !game.generations[0].isLife() {
+		return errors.New("Only Life-like rules can be perturbed with noise")
+	}
+	if game.engine == HashLifeEngine {
+		return errors.New("HashLife can not simulate noise")
+	}
+	if noise.Every < 1 {
+		return errors.New("Noise must be applied every one or more generations")
+	}
+	if (noise.Cells <= 0) == (noise.Fraction <= 0) || noise.Fraction > 1 {
+		return errors.New("Noise must flip either a number of cells or a fraction of the board")
+	}
+	if rng == nil {
+		return errors.New("Noise requires a random number generator")
+	}
+	game.noise, game.noiseRng = &noise, rng
+	return nil
+}
+
+// return the cells of the board rendered, relative to its upper-left corner,
+// whose state is flipped in the generation with the given index, if any
+func (game *Conway) noisyCells(index int) []image.Point {
+
+	if game.noise == nil || index%game.noise.Every != 0 {
+		return nil
+	}
+	width, height := game.width-2*game.halo, game.height-2*game.halo
+	k := game.noise.Cells
+	if game.noise.Fraction > 0 {
+		k = int(math.Round(game.noise.Fraction * float64(width*height)))
+	}
+	k = min(k, width*height)
+
+	cells := make([]image.Point, 0, k)
+	for _, i := range sample(game.noiseRng, width*height, k) {
+		cells = append(cells, image.Point{X: i % width, Y: i / width})
+	}
+	return cells
+}
+
+// flip the state of random cells of the given generation of a dense board,
+// which has the given index, if noise applies to it. Cells are coloured as
+// those born from the previous generation
+func (game *Conway) perturb(g, previous *generation, index int) {
+
+	for _, p := range game.noisyCells(index) {
+		p = p.Add(g.origin)
+		g.flip(p.X, p.Y, previous.cellColor(p.X, p.Y))
+	}
+}
+
+// flip the state of random cells of the given living cells of a sparse board,
+// which has the given index, if noise applies to it. Cells are coloured with
+// the given template
+func (game *Conway) perturbCells(cells map[image.Point]uint8, index int, template *generation) {
+
+	for _, p := range game.noisyCells(index) {
+		p = p.Add(game.generations[0].origin)
+		if _, alive := cells[p]; alive {
+			delete(cells, p)
+		} else if c := template.cellColor(p.X, p.Y); c != 0 {
+			cells[p] = c
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// The analyzer identifies small objects of the Conway's Game, such as still
+// lifes, oscillators and spaceships, with a dictionary which stores their name,
+// apgcode, cells and period. The dictionary comes with the most common
+// objects, and it can be extended with custom ones, so that the census of any
+// generation can be taken.
+//
+// Objects are identified among groups of living cells which are close enough
+// to interact, regardless of their location, orientation and phase
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+)
+
+// Object
+// ----------------------------------------------------------------------------
+
+// type
+
+// An object of the Conway's Game is given by its name, its apgcode, the
+// location of its living cells in any of its phases and its period, i.e., the
+// number of generations it takes to return to the same shape, either in the
+// same location or displaced
+type Object struct {
+	Name    string
+	Apgcode string
+	Cells   []image.Point
+	Period  int
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return the cells given as rows separated by '/', where living cells are
+// shown as 'o' and dead cells as '.'
+func patternCells(pattern string) (cells []image.Point) {
+
+	for y, row := range strings.Split(pattern, "/") {
+		for x, c := range row {
+			if c == 'o' {
+				cells = append(cells, image.Point{X: x, Y: y})
+			}
+		}
+	}
+	return
+}
+
+// return the canonical form of the given cells, which is the same for all
+// translations, rotations and reflections of them
+func canonicalCells(cells []image.Point) string {
+
+	var result string
+	transformations := []func(p image.Point) image.Point{
+		func(p image.Point) image.Point { return image.Point{X: p.X, Y: p.Y} },
+		func(p image.Point) image.Point { return image.Point{X: -p.X, Y: p.Y} },
+		func(p image.Point) image.Point { return image.Point{X: p.X, Y: -p.Y} },
+		func(p image.Point) image.Point { return image.Point{X: -p.X, Y: -p.Y} },
+		func(p image.Point) image.Point { return image.Point{X: p.Y, Y: p.X} },
+		func(p image.Point) image.Point { return image.Point{X: -p.Y, Y: p.X} },
+		func(p image.Point) image.Point { return image.Point{X: p.Y, Y: -p.X} },
+		func(p image.Point) image.Point { return image.Point{X: -p.Y, Y: -p.X} },
+	}
+	for _, transformation := range transformations {
+
+		// transform all cells and move them to the origin
+		transformed := make([]image.Point, len(cells))
+		corner := transformation(cells[0])
+		for i, p := range cells {
+			transformed[i] = transformation(p)
+			corner.X, corner.Y = min(corner.X, transformed[i].X), min(corner.Y, transformed[i].Y)
+		}
+		for i := range transformed {
+			transformed[i] = transformed[i].Sub(corner)
+		}
+		sortPoints(transformed)
+
+		// and keep the smallest representation
+		var representation strings.Builder
+		for _, p := range transformed {
+			fmt.Fprintf(&representation, "%v,%v;", p.X, p.Y)
+		}
+		if result == "" || representation.String() < result {
+			result = representation.String()
+		}
+	}
+	return result
+}
+
+// return the cells of the next generation of the given ones according to the
+// rules of the Conway's Game over an infinite board
+func nextCells(cells []image.Point) []image.Point {
+
+	alive := make(map[image.Point]bool)
+	counts := make(map[image.Point]int)
+	for _, p := range cells {
+		alive[p] = true
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if dx != 0 || dy != 0 {
+					counts[p.Add(image.Point{X: dx, Y: dy})]++
+				}
+			}
+		}
+	}
+
+	var result []image.Point
+	for p, count := range counts {
+		if count == 3 || (count == 2 && alive[p]) {
+			result = append(result, p)
+		}
+	}
+	sortPoints(result)
+	return result
+}
+
+// return the groups of the given cells which are close enough to interact,
+// i.e., cells in the same group are at most two cells away from another cell
+// of the group
+func groupCells(cells []image.Point) [][]image.Point {
+
+	pending := make(map[image.Point]bool)
+	for _, p := range cells {
+		pending[p] = true
+	}
+
+	var groups [][]image.Point
+	for _, p := range cells {
+		if !pending[p] {
+			continue
+		}
+		delete(pending, p)
+		group := []image.Point{p}
+		for i := 0; i < len(group); i++ {
+			for dx := -2; dx <= 2; dx++ {
+				for dy := -2; dy <= 2; dy++ {
+					if q := group[i].Add(image.Point{X: dx, Y: dy}); pending[q] {
+						delete(pending, q)
+						group = append(group, q)
+					}
+				}
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// Dictionary
+// ----------------------------------------------------------------------------
+
+// type
+
+// A dictionary of objects stores the canonical form of every phase of every
+// object, so that they can be identified
+type Dictionary struct {
+	objects []Object
+	phases  map[string]int
+}
+
+// Functions
+
+// Return a new dictionary with the most common objects of the Conway's Game
+func NewDictionary() *Dictionary {
+
+	dictionary := &Dictionary{phases: make(map[string]int)}
+	for _, object := range []struct {
+		name, apgcode, pattern string
+		period                 int
+	}{
+		{"block", "xs4_33", "oo/oo", 1},
+		{"tub", "xs4_252", ".o./o.o/.o.", 1},
+		{"boat", "xs5_253", "oo./o.o/.o.", 1},
+		{"beehive", "xs6_696", ".oo./o..o/.oo.", 1},
+		{"ship", "xs6_356", "oo./o.o/.oo", 1},
+		{"loaf", "xs7_2596", ".oo./o..o/.o.o/..o.", 1},
+		{"pond", "xs8_6996", ".oo./o..o/o..o/.oo.", 1},
+		{"blinker", "xp2_7", "ooo", 2},
+		{"toad", "xp2_7e", ".ooo/ooo.", 2},
+		{"beacon", "xp2_318c", "oo../oo../..oo/..oo", 2},
+		{"glider", "xq4_153", ".o./..o/ooo", 4},
+		{"lightweight spaceship", "xq4_6frc", ".o..o/o..../o...o/oooo.", 4},
+	} {
+		if err := dictionary.Add(Object{
+			Name:    object.name,
+			Apgcode: object.apgcode,
+			Cells:   patternCells(object.pattern),
+			Period:  object.period}); err != nil {
+			panic(err)
+		}
+	}
+	return dictionary
+}
+
+// methods
+
+// Add the given object to this dictionary. An error is returned if it has no
+// name or cells, if there is already another object with the same name, or if
+// its cells do not return to the same shape after the given period
+func (d *Dictionary) Add(object Object) error {
+
+	if object.Name == "" || len(object.Cells) == 0 {
+		return errors.New("Objects must have a name and at least one cell")
+	}
+	if object.Period < 1 {
+		return fmt.Errorf("The period of the object '%v' must be strictly positive", object.Name)
+	}
+	for _, other := range d.objects {
+		if other.Name == object.Name {
+			return fmt.Errorf("The object '%v' already exists", object.Name)
+		}
+	}
+
+	// compute the canonical form of all its phases
+	var phases []string
+	cells := append([]image.Point(nil), object.Cells...)
+	for i := 0; i < object.Period; i++ {
+		phases = append(phases, canonicalCells(cells))
+		if cells = nextCells(cells); len(cells) == 0 {
+			return fmt.Errorf("The object '%v' dies out", object.Name)
+		}
+	}
+	if canonicalCells(cells) != phases[0] {
+		return fmt.Errorf("The object '%v' does not have period %v", object.Name, object.Period)
+	}
+
+	// and register them unless they belong to another object
+	object.Cells = append([]image.Point(nil), object.Cells...)
+	d.objects = append(d.objects, object)
+	for _, phase := range phases {
+		if _, ok := d.phases[phase]; !ok {
+			d.phases[phase] = len(d.objects) - 1
+		}
+	}
+	return nil
+}
+
+// Return all objects of this dictionary in the same order they were added
+func (d *Dictionary) Objects() []Object {
+	return append([]Object(nil), d.objects...)
+}
+
+// Return the object whose cells, in any location, orientation and phase, are
+// the given ones, and whether it was found
+func (d *Dictionary) Identify(cells []image.Point) (Object, bool) {
+
+	if len(cells) == 0 {
+		return Object{}, false
+	}
+	if i, ok := d.phases[canonicalCells(cells)]; ok {
+		return d.objects[i], true
+	}
+	return Object{}, false
+}
+
+// Return the census of the given cells, i.e., the number of occurrences of
+// every object of this dictionary found among them, along with the number of
+// groups of cells which could not be identified
+func (d *Dictionary) Census(cells []image.Point) (counts map[string]int, unknown int) {
+
+	counts = make(map[string]int)
+	sorted := append([]image.Point(nil), cells...)
+	sortPoints(sorted)
+	for _, group := range groupCells(sorted) {
+		if object, ok := d.Identify(group); ok {
+			counts[object.Name]++
+		} else {
+			unknown++
+		}
+	}
+	return
+}
+
+// Return the names of the objects found in the given census sorted in
+// decreasing order of occurrences, and alphabetically in case of ties
+func CensusNames(counts map[string]int) []string {
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return counts[names[i]] > counts[names[j]] ||
+			(counts[names[i]] == counts[names[j]] && names[i] < names[j])
+	})
+	return names
+}
@@ -0,0 +1,104 @@
+// Consecutive frames of most games differ only in a small region, e.g., a
+// glider crossing a large board. GIF animations can be optimized so that every
+// frame but the first one covers only the area which changed with respect to
+// the previous frame, which is left in place, and this drastically shrinks the
+// files written
+
+package conway
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return whether both palettes have the same colours
+func samePalette(p, q color.Palette) bool {
+
+	if len(p) != len(q) {
+		return false
+	}
+	for i := range p {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// return the given frame with the given palette if it has the same colours, so
+// that the GIF encoder uses the global palette instead of writing its own
+func withPalette(img *image.Paletted, palette color.Palette) *image.Paletted {
+
+	if !samePalette(img.Palette, palette) {
+		return img
+	}
+	result := *img
+	result.Palette = palette
+	return &result
+}
+
+// return the given frame cropped to the area which differs from the previous
+// frame, which must have the same bounds. Pixels are compared by their colour,
+// so that frames can have different palettes. If both frames are the same, a
+// single pixel is returned, because frames can not be empty
+func deltaFrame(previous, img *image.Paletted) *image.Paletted {
+
+	same := samePalette(previous.Palette, img.Palette)
+
+	bounds := img.Bounds()
+	changed := image.Rectangle{Min: bounds.Max, Max: bounds.Min}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			before, after := previous.Pix[previous.PixOffset(x, y)], img.Pix[img.PixOffset(x, y)]
+			if before == after && same {
+				continue
+			}
+			if !same && previous.Palette[before] == img.Palette[after] {
+				continue
+			}
+			changed.Min.X, changed.Max.X = min(changed.Min.X, x), max(changed.Max.X, x+1)
+			changed.Min.Y, changed.Max.Y = min(changed.Min.Y, y), max(changed.Max.Y, y+1)
+		}
+	}
+	if changed.Empty() {
+		changed = image.Rectangle{Min: bounds.Min, Max: bounds.Min.Add(image.Pt(1, 1))}
+	}
+	return img.SubImage(changed).(*image.Paletted)
+}
+
+// Return a copy of the given animation where every frame but the first one
+// covers only the area which changed with respect to the previous frame, and
+// all frames are left in place. The palette of the first frame becomes the
+// global palette, which is not repeated in frames with the same colours.
+// Frames share their pixels with the given animation. Animations whose frames
+// do not have the same bounds, or which are already disposed in some way, are
+// returned unmodified
+func OptimizeGIF(anim *gif.GIF) gif.GIF {
+
+	result := *anim
+	if len(anim.Image) == 0 {
+		return result
+	}
+	for i, img := range anim.Image {
+		if img.Rect != anim.Image[0].Rect || (i < len(anim.Disposal) && anim.Disposal[i] != 0) {
+			return result
+		}
+	}
+
+	palette := anim.Image[0].Palette
+	bounds := anim.Image[0].Bounds()
+	result.Config = image.Config{ColorModel: palette, Width: bounds.Max.X, Height: bounds.Max.Y}
+	result.Image = make([]*image.Paletted, len(anim.Image))
+	result.Disposal = make([]byte, len(anim.Image))
+	for i, img := range anim.Image {
+		result.Image[i], result.Disposal[i] = img, gif.DisposalNone
+		if i > 0 {
+			result.Image[i] = withPalette(deltaFrame(anim.Image[i-1], img), palette)
+		}
+	}
+	return result
+}
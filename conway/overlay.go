@@ -0,0 +1,163 @@
+// Annotations are drawn over the frames of the generations they refer to, so
+// that known events (e.g., a gun firing a glider) can be highlighted. Every
+// annotation consists of a marker around a cell along with a text next to it,
+// and they are usually read from a CSV file produced by other analyses
+
+package conway
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Distance in pixels between the cell annotated and its marker
+const annotationMargin = 1
+
+// Annotation
+// ----------------------------------------------------------------------------
+
+// type
+
+// An annotation is shown in all generations in the range [From, To], starting
+// from 0 with the first generation of the game. The cell at location At of the
+// board rendered is surrounded by a marker and the text, if any, is drawn to
+// its right with the given colour
+type Annotation struct {
+	From, To int
+	At       image.Point
+	Text     string
+	Color    color.Color
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the annotations given in CSV format. Every record consists of the
+// generation (or a range of generations given as FROM-TO), the coordinates x
+// and y of the cell annotated, the text and, optionally, its colour in the
+// format #RRGGBB, e.g.:
+//
+//	120,45,30,gun fires here,#ff0000
+//
+// Annotations are white by default. Lines starting with '#' are ignored, and
+// so is the first record if it is a header starting with "generation"
+func ReadAnnotations(r io.Reader) ([]Annotation, error) {
+
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "generation") {
+		records = records[1:]
+	}
+
+	var annotations []Annotation
+	for i, record := range records {
+		if len(record) < 4 || len(record) > 5 {
+			return nil, fmt.Errorf("Wrong number of fields in the annotation %v", 1+i)
+		}
+		annotation := Annotation{Text: record[3], Color: color.White}
+
+		// the generation is given either as a single number or as a range
+		from, to, found := strings.Cut(record[0], "-")
+		if !found {
+			to = from
+		}
+		if annotation.From, err = strconv.Atoi(strings.TrimSpace(from)); err != nil {
+			return nil, fmt.Errorf("Wrong generation in the annotation %v", 1+i)
+		}
+		if annotation.To, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || annotation.To < annotation.From {
+			return nil, fmt.Errorf("Wrong generation in the annotation %v", 1+i)
+		}
+		if annotation.At.X, err = strconv.Atoi(strings.TrimSpace(record[1])); err != nil {
+			return nil, fmt.Errorf("Wrong coordinate x in the annotation %v", 1+i)
+		}
+		if annotation.At.Y, err = strconv.Atoi(strings.TrimSpace(record[2])); err != nil {
+			return nil, fmt.Errorf("Wrong coordinate y in the annotation %v", 1+i)
+		}
+		if len(record) == 5 {
+			if annotation.Color, err = parseColor(strings.TrimSpace(record[4])); err != nil {
+				return nil, fmt.Errorf("Wrong colour in the annotation %v", 1+i)
+			}
+		}
+		annotations = append(annotations, annotation)
+	}
+	return annotations, nil
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the annotations drawn over the frames of this game. Since frames are
+// paletted images, annotations are drawn with the colour of the palette
+// closest to theirs
+func (game *Conway) SetAnnotations(annotations []Annotation) error {
+
+	for _, annotation := range annotations {
+		if annotation.Color == nil {
+			return errors.New("Annotations must be given a colour")
+		}
+	}
+	game.annotations = annotations
+	return nil
+}
+
+// return a copy of the given image of the generation with the given index
+// with all its annotations drawn over it. If there are no annotations for
+// this generation, the same image is returned
+func (game *Conway) annotate(index int, img *image.Paletted) *image.Paletted {
+
+	var result *image.Paletted
+	for _, annotation := range game.annotations {
+		if index < annotation.From || index > annotation.To {
+			continue
+		}
+
+		if result == nil {
+			result = copyFrame(img)
+		}
+
+		// draw the marker around the cell
+		c := uint8(img.Palette.Index(annotation.Color))
+		cell := game.cellRect(annotation.At)
+		marker := image.Rect(
+			cell.Min.X-annotationMargin-1, cell.Min.Y-annotationMargin-1,
+			cell.Max.X+annotationMargin, cell.Max.Y+annotationMargin)
+		for x := marker.Min.X; x <= marker.Max.X; x++ {
+			result.SetColorIndex(x, marker.Min.Y, c)
+			result.SetColorIndex(x, marker.Max.Y, c)
+		}
+		for y := marker.Min.Y; y <= marker.Max.Y; y++ {
+			result.SetColorIndex(marker.Min.X, y, c)
+			result.SetColorIndex(marker.Max.X, y, c)
+		}
+
+		// and the text to its right, vertically centered
+		if annotation.Text != "" {
+			DrawText(result, image.Point{
+				X: marker.Max.X + 2,
+				Y: (marker.Min.Y+marker.Max.Y)/2 - TextHeight(1)/2},
+				annotation.Text, img.Palette[c], 1)
+		}
+	}
+
+	if result == nil {
+		return img
+	}
+	return result
+}
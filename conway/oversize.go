@@ -0,0 +1,81 @@
+// The dimensions of GIF images are stored in 16 bits, so that animations wider
+// or higher than 65535 pixels can not be encoded at all, and many viewers fail
+// with much smaller ones. Animations which exceed a maximum dimension can be
+// split into a grid of tiles, each one a separate animation with the same
+// frames and delays
+
+package conway
+
+import (
+	"image"
+	"image/gif"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Largest width and height of GIF images
+const MaxGIFDimension = 65535
+
+// GIFTile
+// ----------------------------------------------------------------------------
+
+// type
+
+// A tile of an animation is the animation shown in the region located at the
+// given Row and Column of the grid of tiles
+type GIFTile struct {
+	Row, Column int
+	Anim        gif.GIF
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the dimensions of the given animation, i.e., those of its first frame
+func GIFDimensions(anim *gif.GIF) (width, height int) {
+
+	if len(anim.Image) == 0 {
+		return 0, 0
+	}
+	bounds := anim.Image[0].Bounds()
+	return bounds.Dx(), bounds.Dy()
+}
+
+// Split the given animation into a grid of tiles whose width and height do not
+// exceed the given maximum. Tiles are made as even as possible, and they are
+// returned row by row. Animations which do not exceed the maximum dimension
+// are returned as a single tile
+func SplitGIF(anim *gif.GIF, maximum int) []GIFTile {
+
+	width, height := GIFDimensions(anim)
+	if maximum < 1 || (width <= maximum && height <= maximum) {
+		return []GIFTile{{Anim: *anim}}
+	}
+
+	// compute the number of rows and columns and the size of every tile
+	rows, columns := (height+maximum-1)/maximum, (width+maximum-1)/maximum
+	tilewidth, tileheight := (width+columns-1)/columns, (height+rows-1)/rows
+
+	var tiles []GIFTile
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			tile := GIFTile{Row: row, Column: column, Anim: gif.GIF{
+				Delay:     anim.Delay,
+				LoopCount: anim.LoopCount,
+				Disposal:  anim.Disposal}}
+			for _, frame := range anim.Image {
+				region := image.Rect(column*tilewidth, row*tileheight, (column+1)*tilewidth, (row+1)*tileheight).
+					Add(frame.Rect.Min).Intersect(frame.Rect)
+				img := image.NewPaletted(image.Rect(0, 0, region.Dx(), region.Dy()), frame.Palette)
+				for y := 0; y < region.Dy(); y++ {
+					start := frame.PixOffset(region.Min.X, region.Min.Y+y)
+					copy(img.Pix[y*img.Stride:(y+1)*img.Stride], frame.Pix[start:start+region.Dx()])
+				}
+				tile.Anim.Image = append(tile.Anim.Image, img)
+			}
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles
+}
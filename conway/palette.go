@@ -0,0 +1,119 @@
+// Palettes can be read from files, so that favourite colour schemes are reused
+// instead of being given in the specification of colour models. Files are
+// either GIMP palettes (.gpl), e.g.:
+//
+//	GIMP Palette
+//	Name: Sunset
+//	Columns: 4
+//	# comments start with '#'
+//	 20  12  28	Night
+//	255 128   0	Orange
+//
+// or plain lists of colours given in hexadecimal notation, one per line, with
+// or without a leading '#', where empty lines and lines starting with ';' or
+// '//' are ignored. In both cases, the first colour is used for dead cells
+
+package conway
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the colours of the palette given in the reader either as a GIMP
+// palette or as a plain list of colours (see the description of the formats
+// above), along with an error if it is not well formed, it is empty or it has
+// more than 256 colours
+func ReadPalette(r io.Reader) (color.Palette, error) {
+
+	gimp := regexp.MustCompile(`^\s*(\d+)\s+(\d+)\s+(\d+)(?:\s.*)?$`)
+	plain := regexp.MustCompile(`^#?([a-fA-F0-9]{2})([a-fA-F0-9]{2})([a-fA-F0-9]{2})$`)
+
+	var palette color.Palette
+	scanner := bufio.NewScanner(r)
+	isGimp := false
+	for nbline := 1; scanner.Scan(); nbline++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		// the header of GIMP palettes is only acknowledged in the first line
+		if nbline == 1 && line == "GIMP Palette" {
+			isGimp = true
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		// GIMP palettes give colours as decimal components followed by an
+		// optional name, and they have attributes and comments
+		if isGimp {
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "Name:") || strings.HasPrefix(line, "Columns:") {
+				continue
+			}
+			match := gimp.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("Syntax error in the line %v of the palette: '%v'", nbline, line)
+			}
+			var components [3]uint8
+			for i := range components {
+				value, err := strconv.Atoi(match[1+i])
+				if err != nil || value > 255 {
+					return nil, fmt.Errorf("Wrong component in the line %v of the palette: '%v'", nbline, line)
+				}
+				components[i] = uint8(value)
+			}
+			palette = append(palette, color.RGBA{components[0], components[1], components[2], 255})
+			continue
+		}
+
+		// otherwise, colours are given in hexadecimal notation
+		match := plain.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("Syntax error in the line %v of the palette: '%v'", nbline, line)
+		}
+		var components [3]uint8
+		for i := range components {
+			value, _ := strconv.ParseUint(match[1+i], 16, 8)
+			components[i] = uint8(value)
+		}
+		palette = append(palette, color.RGBA{components[0], components[1], components[2], 255})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(palette) == 0 {
+		return nil, errors.New("The palette has no colours")
+	}
+	if len(palette) > maxPaletteSize {
+		return nil, fmt.Errorf("The palette has %v colours but at most %v are allowed", len(palette), maxPaletteSize)
+	}
+	return palette, nil
+}
+
+// Return a palette with n colours spread evenly across the given ones, which
+// are blended with their neighbours, so that the first and last colours are
+// always the given ones. For example, the gradient steps of colour models are
+// mapped onto any list of colours
+func StretchPalette(colors color.Palette, n int) color.Palette {
+
+	palette := make(color.Palette, n)
+	for i := range palette {
+		if len(colors) == 1 || n == 1 {
+			palette[i] = colors[0]
+			continue
+		}
+		position := float64(i) * float64(len(colors)-1) / float64(n-1)
+		j := min(int(position), len(colors)-2)
+		palette[i] = blendColors(colors[j], colors[j+1], position-float64(j))
+	}
+	return palette
+}
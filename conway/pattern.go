@@ -0,0 +1,163 @@
+// Patterns are sets of living cells which can be read from and written to files
+// in the formats used by other programs simulating Life-like rules, so that
+// known patterns can be simulated and interesting generations can be shared
+
+package conway
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+// Pattern
+// ----------------------------------------------------------------------------
+
+// type
+
+// A pattern consists of the locations of its living Cells relative to its
+// upper-left corner, its dimensions and, if known, its Name, Author, Comments
+// and Rule
+type Pattern struct {
+	Name, Author  string
+	Comments      []string
+	Rule          string
+	Width, Height int
+	Cells         []image.Point
+}
+
+// Functions
+
+// Return a pattern with the given cells cropped to their bounding box, so that
+// its upper-left corner is the location of the upper-left living cell
+func NewPattern(cells []image.Point) Pattern {
+
+	if len(cells) == 0 {
+		return Pattern{}
+	}
+	bounds := image.Rectangle{Min: cells[0], Max: cells[0].Add(image.Point{X: 1, Y: 1})}
+	for _, cell := range cells[1:] {
+		bounds = bounds.Union(image.Rectangle{Min: cell, Max: cell.Add(image.Point{X: 1, Y: 1})})
+	}
+	pattern := Pattern{Width: bounds.Dx(), Height: bounds.Dy()}
+	for _, cell := range cells {
+		pattern.Cells = append(pattern.Cells, cell.Sub(bounds.Min))
+	}
+	return pattern
+}
+
+// Return the pattern read from the given reader, along with an error if it is
+// not well formed. The format is recognized from the first line: "#Life 1.06"
+// for Life 1.06, "#Life 1.05" for Life 1.05, "[M2]" for macrocell, either a
+// comment starting with '!' or a row of 'O' and '.' for plaintext and RLE
+// otherwise
+func LoadPattern(r io.Reader) (Pattern, error) {
+
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return Pattern{}, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#Life 1.06") {
+			return LoadLife106(bytes.NewReader(contents))
+		}
+		if strings.HasPrefix(line, "#Life 1.05") {
+			return LoadLife105(bytes.NewReader(contents))
+		}
+		if strings.HasPrefix(line, "[M2]") {
+			return LoadMacrocell(bytes.NewReader(contents))
+		}
+		if strings.HasPrefix(line, "!") || strings.Trim(line, "O.") == "" {
+			return LoadPlaintext(bytes.NewReader(contents))
+		}
+		break
+	}
+	return LoadRLE(bytes.NewReader(contents))
+}
+
+// methods
+
+// Return the contents of a grid with the given dimensions with the same
+// layout expected by Set, where the upper-left corner of this pattern is
+// located at the given location, along with an error if it does not fit in
+// the grid
+func (pattern Pattern) Contents(width, height int, location image.Point) ([]bool, error) {
+
+	contents := make([]bool, (1+width)*(1+height))
+	for _, cell := range pattern.Cells {
+		p := cell.Add(location)
+		if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+			return nil, fmt.Errorf("The pattern does not fit in a grid of dimensions %vx%v", width, height)
+		}
+		contents[p.Y*(1+width)+p.X] = true
+	}
+	return contents, nil
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return the living cells of this generation as a pattern cropped to their
+// bounding box, along with its rule if it is a Life-like rule
+func (g *generation) Pattern() Pattern {
+
+	var cells []image.Point
+	width, height := g.dimensions()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if g.ColorIndexAt(x, y) != 0 {
+				cells = append(cells, image.Point{X: x, Y: y})
+			}
+		}
+	}
+	pattern := NewPattern(cells)
+	if g.rule != nil && g.transition == nil {
+		pattern.Rule = g.rule.String()
+	}
+	return pattern
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return the living cells of the generation with the given index as a pattern
+// cropped to their bounding box, along with an error if it has not been
+// computed. Unlike Pattern of generations, this works with all engines
+func (game *Conway) Pattern(index int) (Pattern, error) {
+
+	if index < 0 || index >= game.nbgenerations || !game.computed(index) {
+		return Pattern{}, fmt.Errorf("The generation %v has not been computed", index)
+	}
+	if game.engine == DenseEngine {
+		return game.generations[index].Pattern(), nil
+	}
+	pattern := NewPattern(game.living(index))
+	if first := game.generations[0]; first.rule != nil {
+		pattern.Rule = first.rule.String()
+	}
+	return pattern, nil
+}
+
+// Return the index of the last generation computed by this game, or -1 if
+// none has been computed yet
+func (game *Conway) Last() int {
+
+	for index := game.nbgenerations - 1; index >= 0; index-- {
+		if game.computed(index) {
+			return index
+		}
+	}
+	return -1
+}
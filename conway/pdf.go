@@ -0,0 +1,74 @@
+// Images can be written as single-page PDF documents, which are better suited
+// than raster formats for printing. The page has the same size in points as
+// the image in pixels
+
+package conway
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Write the given image as a single-page PDF document to the given writer
+func WritePDF(w io.Writer, img image.Image) error {
+
+	// compress the RGB components of all pixels
+	bounds := img.Bounds()
+	var pixels bytes.Buffer
+	compressor := zlib.NewWriter(&pixels)
+	row := make([]byte, 3*bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			row[3*(x-bounds.Min.X)] = uint8(r >> 8)
+			row[3*(x-bounds.Min.X)+1] = uint8(g >> 8)
+			row[3*(x-bounds.Min.X)+2] = uint8(b >> 8)
+		}
+		compressor.Write(row)
+	}
+	if err := compressor.Close(); err != nil {
+		return err
+	}
+
+	// the content of the page just paints the image over the whole page
+	content := fmt.Sprintf("q %v 0 0 %v 0 0 cm /Im0 Do Q", bounds.Dx(), bounds.Dy())
+
+	// write all objects recording their offsets for the cross-reference table
+	var document bytes.Buffer
+	var offsets []int
+	object := func(body string, stream []byte) {
+		offsets = append(offsets, document.Len())
+		fmt.Fprintf(&document, "%v 0 obj\n%v\n", len(offsets), body)
+		if stream != nil {
+			document.WriteString("stream\n")
+			document.Write(stream)
+			document.WriteString("\nendstream\n")
+		}
+		document.WriteString("endobj\n")
+	}
+	document.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	object("<< /Type /Pages /Kids [3 0 R] /Count 1 >>", nil)
+	object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %v %v] /Resources << /XObject << /Im0 4 0 R >> >> /Contents 5 0 R >>",
+		bounds.Dx(), bounds.Dy()), nil)
+	object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %v /Height %v /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %v >>",
+		bounds.Dx(), bounds.Dy(), pixels.Len()), pixels.Bytes())
+	object(fmt.Sprintf("<< /Length %v >>", len(content)), []byte(content))
+
+	// and finally the cross-reference table and the trailer
+	xref := document.Len()
+	fmt.Fprintf(&document, "xref\n0 %v\n0000000000 65535 f \n", 1+len(offsets))
+	for _, offset := range offsets {
+		fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&document, "trailer\n<< /Size %v /Root 1 0 R >>\nstartxref\n%v\n%%%%EOF\n", 1+len(offsets), xref)
+
+	_, err := w.Write(document.Bytes())
+	return err
+}
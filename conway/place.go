@@ -0,0 +1,168 @@
+// Patterns can be stamped onto generations at any location, rotated clockwise
+// by a multiple of 90 degrees and flipped horizontally and/or vertically, so
+// that any initial population can be composed from known patterns, e.g., two
+// glider guns facing each other
+
+package conway
+
+import (
+	"fmt"
+	"image"
+)
+
+// Rotation
+// ----------------------------------------------------------------------------
+
+// type
+
+// Patterns can be rotated clockwise by 0, 90, 180 or 270 degrees
+type Rotation int
+
+const (
+	Rotate0 Rotation = iota
+	Rotate90
+	Rotate180
+	Rotate270
+)
+
+// Functions
+
+// Return the rotation by the given number of degrees, which must be a multiple
+// of 90, along with an error otherwise
+func NewRotation(degrees int) (Rotation, error) {
+
+	if degrees%90 != 0 {
+		return Rotate0, fmt.Errorf("The rotation %v is not a multiple of 90 degrees", degrees)
+	}
+	return Rotation(((degrees/90)%4 + 4) % 4), nil
+}
+
+// methods
+
+// Return the number of degrees of this rotation
+func (rotation Rotation) String() string {
+	return fmt.Sprintf("%v", 90*(int(rotation)%4))
+}
+
+// Flip
+// ----------------------------------------------------------------------------
+
+// type
+
+// Patterns can be flipped horizontally (left to right), vertically (top to
+// bottom) or both, which is given as FlipHorizontal|FlipVertical
+type Flip int
+
+const (
+	NoFlip         Flip = 0
+	FlipHorizontal Flip = 1
+	FlipVertical   Flip = 2
+)
+
+// Functions
+
+// Return the flip with the given name, either "none", "horizontal",
+// "vertical" or "both", along with an error if it is not known
+func NewFlip(name string) (Flip, error) {
+
+	switch name {
+	case "none", "":
+		return NoFlip, nil
+	case "horizontal":
+		return FlipHorizontal, nil
+	case "vertical":
+		return FlipVertical, nil
+	case "both":
+		return FlipHorizontal | FlipVertical, nil
+	}
+	return NoFlip, fmt.Errorf("Unknown flip '%v'", name)
+}
+
+// methods
+
+// Return the name of this flip
+func (flip Flip) String() string {
+
+	switch flip {
+	case NoFlip:
+		return "none"
+	case FlipHorizontal:
+		return "horizontal"
+	case FlipVertical:
+		return "vertical"
+	}
+	return "both"
+}
+
+// Pattern
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return a copy of this pattern first flipped and then rotated clockwise, so
+// that its upper-left corner is still located at the origin. Its name,
+// author, comments and rule are preserved
+func (pattern Pattern) Transform(rotation Rotation, flip Flip) Pattern {
+
+	result := pattern
+	result.Comments = append([]string(nil), pattern.Comments...)
+	result.Cells = make([]image.Point, 0, len(pattern.Cells))
+	if rotation%2 == 1 {
+		result.Width, result.Height = pattern.Height, pattern.Width
+	}
+	for _, cell := range pattern.Cells {
+		if flip&FlipHorizontal != 0 {
+			cell.X = pattern.Width - 1 - cell.X
+		}
+		if flip&FlipVertical != 0 {
+			cell.Y = pattern.Height - 1 - cell.Y
+		}
+		switch rotation % 4 {
+		case Rotate90:
+			cell = image.Point{X: pattern.Height - 1 - cell.Y, Y: cell.X}
+		case Rotate180:
+			cell = image.Point{X: pattern.Width - 1 - cell.X, Y: pattern.Height - 1 - cell.Y}
+		case Rotate270:
+			cell = image.Point{X: cell.Y, Y: pattern.Width - 1 - cell.X}
+		}
+		result.Cells = append(result.Cells, cell)
+	}
+	return result
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Stamp the given pattern onto this generation with its upper-left corner at
+// the given location, once flipped and rotated as requested. Cells already
+// alive are kept. Locations are resolved with the boundary condition of this
+// generation, so that patterns wrap around toroidal grids, and an error is
+// returned if any cell falls out of the grid, in which case the generation is
+// left untouched
+func (g *generation) Place(pattern Pattern, at image.Point, rotation Rotation, flip Flip) error {
+
+	var cells []image.Point
+	for _, cell := range pattern.Transform(rotation, flip).Cells {
+		p, ok := g.locate(at.X+cell.X, at.Y+cell.Y)
+		if !ok {
+			width, height := g.dimensions()
+			return fmt.Errorf("The pattern does not fit in a grid of dimensions %vx%v at (%v, %v)", width, height, at.X, at.Y)
+		}
+		cells = append(cells, p)
+	}
+
+	for _, p := range cells {
+		g.SetColorIndex(p.X, p.Y, g.cellColor(p.X, p.Y))
+	}
+
+	// custom colour models are given the number of neighbours of every cell,
+	// so that they are recomputed once all cells have been set
+	if g.colorModel != nil {
+		for _, p := range cells {
+			g.SetColorIndex(p.X, p.Y, g.customColor(p.X, p.Y, 1))
+		}
+	}
+	return nil
+}
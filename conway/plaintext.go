@@ -0,0 +1,98 @@
+// The plaintext format (usually with the extension .cells) is used by LifeWiki
+// for small patterns. Lines starting with '!' are comments, where "!Name:"
+// and "!Author:" give the name and author of the pattern, and every other line
+// is a row of the pattern, where living cells are shown as 'O' and dead cells
+// as '.'. Rules are not acknowledged, so that patterns are assumed to follow
+// the Conway's Game
+
+package conway
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+// Pattern
+// ----------------------------------------------------------------------------
+
+// Functions
+
+// Return the pattern read from the given reader in plaintext format, cropped
+// to its bounding box, along with an error if any row is malformed. Living
+// cells shown as '*' are acknowledged as well
+func LoadPlaintext(r io.Reader) (Pattern, error) {
+
+	var pattern Pattern
+	var cells []image.Point
+	scanner := bufio.NewScanner(r)
+	row := 0
+	for nbline := 1; scanner.Scan(); nbline++ {
+
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(line, "!") {
+			text := strings.TrimSpace(line[1:])
+			if name, ok := strings.CutPrefix(text, "Name:"); ok {
+				pattern.Name = strings.TrimSpace(name)
+			} else if author, ok := strings.CutPrefix(text, "Author:"); ok {
+				pattern.Author = strings.TrimSpace(author)
+			} else {
+				pattern.Comments = append(pattern.Comments, text)
+			}
+			continue
+		}
+
+		for column, char := range line {
+			switch char {
+			case 'O', '*':
+				cells = append(cells, image.Point{X: column, Y: row})
+			case '.':
+			default:
+				return Pattern{}, fmt.Errorf("Unknown character '%c' in line %v, column %v", char, nbline, 1+column)
+			}
+		}
+		row++
+	}
+	if err := scanner.Err(); err != nil {
+		return Pattern{}, err
+	}
+
+	result := NewPattern(cells)
+	result.Name, result.Author, result.Comments = pattern.Name, pattern.Author, pattern.Comments
+	return result, nil
+}
+
+// methods
+
+// Write this pattern to the given writer in plaintext format, along with its
+// name, author and comments, if they are known. Note that the rule of the
+// pattern is not written since this format does not acknowledge it
+func (pattern Pattern) ExportPlaintext(w io.Writer) error {
+
+	var result strings.Builder
+	if pattern.Name != "" {
+		fmt.Fprintf(&result, "!Name: %v\n", pattern.Name)
+	}
+	if pattern.Author != "" {
+		fmt.Fprintf(&result, "!Author: %v\n", pattern.Author)
+	}
+	for _, comment := range pattern.Comments {
+		fmt.Fprintf(&result, "!%v\n", comment)
+	}
+
+	rows := make([][]byte, pattern.Height)
+	for y := range rows {
+		rows[y] = []byte(strings.Repeat(".", pattern.Width))
+	}
+	for _, cell := range pattern.Cells {
+		rows[cell.Y][cell.X] = 'O'
+	}
+	for _, row := range rows {
+		result.WriteString(string(row) + "\n")
+	}
+
+	_, err := io.WriteString(w, result.String())
+	return err
+}
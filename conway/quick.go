@@ -0,0 +1,91 @@
+// Quick provides a high-level façade for embedding the Conway's Game in other
+// programs: a single call creates a random initial population, runs the game
+// and encodes it as a GIF animation, using sensible defaults for all options
+// not given
+
+package conway
+
+import (
+	"image/color"
+	"io"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Default values of the options of Quick
+const (
+	quickSize        = 100
+	quickGenerations = 100
+	quickRule        = "B3/S23"
+	quickModel       = "gradient"
+
+	// fraction of cells alive in the initial population
+	quickDensity = 0.25
+
+	// delay between frames in 100th of a second
+	quickDelay = 5
+)
+
+// QuickOptions
+// ----------------------------------------------------------------------------
+
+// type
+
+// The options of Quick. All of them are optional: dimensions and generations
+// default to 100, the rule defaults to the Conway's Game (B3/S23), the model
+// defaults to "gradient" ("radial" and "bichrome" are also available) and, if
+// no seed is given, a new one is chosen
+type QuickOptions struct {
+	Width, Height int
+	Generations   int
+	Rule          string
+	Seed          int64
+	Model         string
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return a palette where dead cells are given the first color, and living
+// cells are given a gradient of 255 colors from the second to the third
+func GradientPalette(dead, from, to color.Color) color.Palette {
+
+	r1, g1, b1, _ := from.RGBA()
+	r2, g2, b2, _ := to.RGBA()
+	interpolate := func(c1, c2 uint32, i float64) uint8 {
+		return uint8(float64(c1>>8) + i*(float64(c2>>8)-float64(c1>>8))/255.0)
+	}
+
+	palette := color.Palette{dead}
+	for i := 1.0; i <= 255.0; i++ {
+		palette = append(palette, color.RGBA{
+			interpolate(r1, r2, i),
+			interpolate(g1, g2, i),
+			interpolate(b1, b2, i),
+			255})
+	}
+	return palette
+}
+
+// Return a palette with only two colors for the bichrome model, where dead
+// cells are given the first one and living cells the second one
+func BichromePalette(dead, alive color.Color) color.Palette {
+	return color.Palette{dead, alive}
+}
+
+// Run the Conway's Game (or any other Life-like rule) over a random initial
+// population and write it to the given writer as a GIF animation. A quarter
+// of the cells are initially alive
+func Quick(w io.Writer, options QuickOptions) error {
+
+	return Config{
+		Width:       options.Width,
+		Height:      options.Height,
+		Generations: options.Generations,
+		Rule:        options.Rule,
+		Seed:        options.Seed,
+		Model:       options.Model,
+		Density:     quickDensity,
+	}.Run(w)
+}
@@ -0,0 +1,55 @@
+// The rainbow palette walks the hue wheel in HSV space, so that all hues are
+// shown with the same saturation and brightness. A perceptually even rainbow
+// can not be approximated well by interpolating two colours in RGB space as
+// gradient palettes do, because intermediate colours get darker and duller
+
+package conway
+
+import (
+	"image/color"
+	"math"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return the colour with the given hue in degrees, and saturation and value in
+// the range [0, 1]
+func hsvColor(hue, saturation, value float64) color.Color {
+
+	hue = math.Mod(math.Mod(hue, 360)+360, 360) / 60
+	chroma := value * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue, 2)-1))
+	var r, g, b float64
+	switch int(hue) {
+	case 0:
+		r, g, b = chroma, x, 0
+	case 1:
+		r, g, b = x, chroma, 0
+	case 2:
+		r, g, b = 0, chroma, x
+	case 3:
+		r, g, b = 0, x, chroma
+	case 4:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+	m := value - chroma
+	component := func(c float64) uint8 {
+		return uint8(math.Round(255 * (c + m)))
+	}
+	return color.RGBA{component(r), component(g), component(b), 255}
+}
+
+// Return a palette where dead cells are given the first color, and living
+// cells are given 255 fully saturated and bright colors which walk once
+// around the hue wheel, starting with red
+func RainbowPalette(dead color.Color) color.Palette {
+
+	palette := color.Palette{dead}
+	for i := 0; i < 255; i++ {
+		palette = append(palette, hsvColor(360*float64(i)/255, 1, 1))
+	}
+	return palette
+}
@@ -0,0 +1,106 @@
+// The delay between frames can be ramped across the animation, so that it
+// starts slow and ends fast, or the other way around, or it follows any custom
+// keyframes. Delays are eased between keyframes so that the speed of the
+// animation changes smoothly
+
+package conway
+
+import (
+	"fmt"
+	"image/gif"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Ratio between the slowest and the fastest delays of the predefined ramps
+const rampFactor = 5
+
+// Ramp
+// ----------------------------------------------------------------------------
+
+// type
+
+// A keyframe sets the delay (in 100th of a second) of the frame at the given
+// position of the animation, in the range [0, 1]
+type Keyframe struct {
+	Position float64
+	Delay    int
+}
+
+// A ramp consists of keyframes sorted in increasing order of their position
+type Ramp []Keyframe
+
+// methods
+
+// Return the delay at the given position of the animation, in the range [0,
+// 1]. Positions before the first keyframe and after the last one take their
+// delays, and delays between keyframes are eased
+func (ramp Ramp) Delay(position float64) int {
+
+	if position <= ramp[0].Position {
+		return ramp[0].Delay
+	}
+	for i := 1; i < len(ramp); i++ {
+		if position <= ramp[i].Position {
+			t := (position - ramp[i-1].Position) / (ramp[i].Position - ramp[i-1].Position)
+			t = t * t * (3 - 2*t)
+			return int(math.Round(float64(ramp[i-1].Delay) + t*float64(ramp[i].Delay-ramp[i-1].Delay)))
+		}
+	}
+	return ramp[len(ramp)-1].Delay
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the ramp given in spec, where delay is the delay between frames
+// requested by the user. It is either "slow-fast", which starts rampFactor
+// times slower than delay and ends with delay; "fast-slow", which does the
+// opposite; or a comma-separated list of keyframes POSITION:DELAY, where
+// positions are percentages of the animation given in increasing order, e.g.,
+// "0:20,50:5,100:2"
+func ParseRamp(spec string, delay int) (Ramp, error) {
+
+	switch spec {
+	case "slow-fast":
+		return Ramp{{0, rampFactor * delay}, {1, delay}}, nil
+	case "fast-slow":
+		return Ramp{{0, delay}, {1, rampFactor * delay}}, nil
+	}
+
+	var ramp Ramp
+	for _, keyframe := range strings.Split(spec, ",") {
+		position, value, found := strings.Cut(strings.TrimSpace(keyframe), ":")
+		if !found {
+			return nil, fmt.Errorf("Wrong keyframe '%v'", keyframe)
+		}
+		percentage, err := strconv.ParseFloat(position, 64)
+		if err != nil || percentage < 0 || percentage > 100 ||
+			(len(ramp) > 0 && percentage/100 <= ramp[len(ramp)-1].Position) {
+			return nil, fmt.Errorf("Wrong position in the keyframe '%v'", keyframe)
+		}
+		d, err := strconv.Atoi(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("Wrong delay in the keyframe '%v'", keyframe)
+		}
+		ramp = append(ramp, Keyframe{Position: percentage / 100, Delay: d})
+	}
+	return ramp, nil
+}
+
+// Set the delays of all frames of the given animation but the first one,
+// which has its own delay, according to the given ramp
+func ApplyRamp(anim *gif.GIF, ramp Ramp) {
+
+	for i := 1; i < len(anim.Delay); i++ {
+		position := 1.0
+		if len(anim.Delay) > 2 {
+			position = float64(i-1) / float64(len(anim.Delay)-2)
+		}
+		anim.Delay[i] = ramp.Delay(position)
+	}
+}
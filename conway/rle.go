@@ -0,0 +1,247 @@
+// Patterns are usually exchanged in the Run Length Encoded format (RLE), which
+// is understood by most programs simulating Life-like rules, e.g., Golly.
+// Files consist of optional comment lines starting with '#', a header with the
+// dimensions of the pattern and, optionally, its rule, e.g., "x = 3, y = 3,
+// rule = B3/S23", and the rows of the pattern, where runs of dead and living
+// cells are given as a count followed by either 'b' or 'o', and rows end with
+// '$'. The pattern ends with '!'
+
+package conway
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Pattern
+// ----------------------------------------------------------------------------
+
+// Functions
+
+// Return the pattern read from the given reader in RLE format, along with an
+// error if it is not well formed. Comments are given in lines starting with
+// "#C" or "#c", and the name and author of the pattern in lines starting with
+// "#N" and "#O" respectively. Rules given in lines starting with "#r" are
+// acknowledged as well, though the header takes precedence. Any character
+// other than 'b' and '.' stands for living cells, so that patterns of
+// multi-state rules are read as Life-like patterns
+func LoadRLE(r io.Reader) (pattern Pattern, err error) {
+
+	scanner := bufio.NewScanner(r)
+	header, done := false, false
+	x, y, count := 0, 0, 0
+	for nbline := 1; scanner.Scan() && !done; nbline++ {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// comments and the information of the pattern are only acknowledged
+		// before the header
+		if strings.HasPrefix(line, "#") {
+			if header {
+				return pattern, fmt.Errorf("Unexpected comment in line %v", nbline)
+			}
+			text := ""
+			if len(line) > 2 {
+				text = strings.TrimSpace(line[2:])
+			}
+			switch line[:min(2, len(line))] {
+			case "#N":
+				pattern.Name = text
+			case "#O":
+				pattern.Author = text
+			case "#C", "#c":
+				pattern.Comments = append(pattern.Comments, text)
+			case "#r":
+				pattern.Rule = text
+			}
+			continue
+		}
+
+		// the header gives the dimensions of the pattern and, optionally, its
+		// rule
+		if !header {
+			if err := pattern.parseHeader(line); err != nil {
+				return pattern, fmt.Errorf("%v in line %v", err, nbline)
+			}
+			header = true
+			continue
+		}
+
+		// the rows of the pattern are given as runs of cells
+		for column, char := range line {
+			switch {
+			case unicode.IsDigit(char):
+				count = 10*count + int(char-'0')
+				continue
+			case unicode.IsSpace(char):
+				continue
+			case char == '!':
+				done = true
+			case char == '$':
+				x, y = 0, y+max(count, 1)
+			case char == 'b' || char == '.':
+				x += max(count, 1)
+			case unicode.IsLetter(char):
+				for i := 0; i < max(count, 1); i++ {
+					pattern.Cells = append(pattern.Cells, image.Point{X: x, Y: y})
+					x++
+				}
+			default:
+				return pattern, fmt.Errorf("Unknown character '%c' in line %v, column %v", char, nbline, 1+column)
+			}
+			if done {
+				break
+			}
+			if x > pattern.Width || y >= pattern.Height && x > 0 {
+				return pattern, fmt.Errorf("The pattern exceeds its dimensions %vx%v in line %v", pattern.Width, pattern.Height, nbline)
+			}
+			count = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return pattern, err
+	}
+	if !header {
+		return pattern, errors.New("Missing header")
+	}
+	return pattern, nil
+}
+
+// methods
+
+// parse the given header of a RLE file and store the dimensions and rule found
+// in it in this pattern
+func (pattern *Pattern) parseHeader(line string) error {
+
+	found := false
+	for _, field := range strings.Split(line, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return fmt.Errorf("Syntax error in the header '%v'", line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "x", "y":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("Wrong dimension '%v'", value)
+			}
+			if key == "x" {
+				pattern.Width, found = n, true
+			} else {
+				pattern.Height = n
+			}
+		case "rule":
+			pattern.Rule = value
+		}
+	}
+	if !found {
+		return fmt.Errorf("Missing dimensions in the header '%v'", line)
+	}
+	return nil
+}
+
+// Write this pattern to the given writer in RLE format, along with its name,
+// author, comments and rule, if they are known. Lines are not longer than 70
+// characters
+func (pattern Pattern) ExportRLE(w io.Writer) error {
+
+	var result strings.Builder
+	if pattern.Name != "" {
+		fmt.Fprintf(&result, "#N %v\n", pattern.Name)
+	}
+	if pattern.Author != "" {
+		fmt.Fprintf(&result, "#O %v\n", pattern.Author)
+	}
+	for _, comment := range pattern.Comments {
+		fmt.Fprintf(&result, "#C %v\n", comment)
+	}
+	fmt.Fprintf(&result, "x = %v, y = %v", pattern.Width, pattern.Height)
+	if pattern.Rule != "" {
+		fmt.Fprintf(&result, ", rule = %v", pattern.Rule)
+	}
+	result.WriteString("\n")
+
+	// cells are sorted by rows, so that runs are found by traversing them
+	cells := append([]image.Point(nil), pattern.Cells...)
+	sort.Slice(cells, func(i, j int) bool {
+		return cells[i].Y < cells[j].Y || cells[i].Y == cells[j].Y && cells[i].X < cells[j].X
+	})
+
+	// every run is written as a token, and lines are broken between tokens
+	line := ""
+	emit := func(count int, tag byte) {
+		token := string(tag)
+		if count > 1 {
+			token = strconv.Itoa(count) + token
+		}
+		if len(line)+len(token) > 70 {
+			result.WriteString(line + "\n")
+			line = ""
+		}
+		line += token
+	}
+	x, y := 0, 0
+	for i := 0; i < len(cells); {
+		cell := cells[i]
+		if cell.Y > y {
+			emit(cell.Y-y, '$')
+			x, y = 0, cell.Y
+		}
+		if cell.X > x {
+			emit(cell.X-x, 'b')
+		}
+
+		// count the living cells of this run
+		run := 1
+		for i+run < len(cells) && cells[i+run] == cell.Add(image.Point{X: run}) {
+			run++
+		}
+		emit(run, 'o')
+		x, i = cell.X+run, i+run
+	}
+	emit(1, '!')
+	result.WriteString(line + "\n")
+
+	_, err := io.WriteString(w, result.String())
+	return err
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Write the living cells of this generation to the given writer in RLE format
+// cropped to their bounding box, along with its rule if it is a Life-like rule
+func (g *generation) ExportRLE(w io.Writer) error {
+	return g.Pattern().ExportRLE(w)
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Write the living cells of the generation with the given index to the given
+// writer in RLE format cropped to their bounding box, along with an error if
+// it has not been computed. Unlike ExportRLE of generations, this works with
+// all engines
+func (game *Conway) ExportRLE(index int, w io.Writer) error {
+
+	pattern, err := game.Pattern(index)
+	if err != nil {
+		return err
+	}
+	return pattern.ExportRLE(w)
+}
@@ -0,0 +1,240 @@
+// Life-like rules generalize the rules of the Conway's Game by specifying the
+// number of living neighbours that make dead cells take birth and living cells
+// survive. They are written in the B/S notation, e.g., the Conway's Game is
+// B3/S23 and HighLife is B36/S23.
+//
+// Additionally, rules can be stochastic: every birth and survival happens with
+// a given probability, and they can be isotropic non-totalistic rules written
+// in Hensel notation, e.g., B2-a/S12, or weighted rules (see weighted.go).
+//
+// Rules with B0 make dead cells with no living neighbours take birth, so that
+// the infinite background of dead cells around the grid becomes alive in the
+// next generation and, unless the rule also has S8, dead again in the
+// following one. Cells off the grid take the state of this strobing
+// background, so that patterns are not distorted at the edges of the grid
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"strings"
+)
+
+// LifeRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// A Life-like rule is given by the number of living neighbours that make dead
+// cells take birth and living cells survive, along with the probabilities that
+// a birth or a survival actually happens. Non-totalistic rules additionally
+// store the neighbourhoods that make dead cells take birth (first) and living
+// cells survive (second), and the numbers of neighbours given in Birth and
+// Survival are those which appear in any of them. Weighted rules store their
+// weights and sums separately, and only Birth[0] is set, if the empty
+// neighbourhood makes cells take birth
+type LifeRule struct {
+	Birth, Survival                       [9]bool
+	BirthProbability, SurvivalProbability float64
+	transitions                           *[2][neighbourhoods]bool
+	weighted                              *weightedRule
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the rule of the Conway's Game, B3/S23
+func ConwayRule() LifeRule {
+	rule, _ := ParseLifeRule("B3/S23")
+	return rule
+}
+
+// Return the Life-like rule given in B/S notation, e.g., B3/S23, in Hensel
+// notation, e.g., B2-a/S12, or as a weighted rule, e.g.,
+// B4,5/S3,4,5/W1,2,1,2,0,2,1,2,1, along with an error if the rule is not well
+// formed. Births and survivals happen always, i.e., with probability 1
+func ParseLifeRule(spec string) (rule LifeRule, err error) {
+
+	parts := strings.Split(strings.TrimSpace(spec), "/")
+	if len(parts) == 3 && strings.HasPrefix(strings.ToUpper(parts[0]), "B") &&
+		strings.HasPrefix(strings.ToUpper(parts[1]), "S") &&
+		strings.HasPrefix(strings.ToUpper(parts[2]), "W") {
+		return parseWeightedRule(parts, spec)
+	}
+	if len(parts) != 2 || !strings.HasPrefix(strings.ToUpper(parts[0]), "B") ||
+		!strings.HasPrefix(strings.ToUpper(parts[1]), "S") {
+		return rule, fmt.Errorf("Syntax error in the rule '%v'", spec)
+	}
+	rule.BirthProbability, rule.SurvivalProbability = 1, 1
+
+	// rules with arrangements of neighbours are non-totalistic
+	birth, survival := strings.ToLower(parts[0][1:]), strings.ToLower(parts[1][1:])
+	if strings.ContainsAny(birth+survival, "-abcdefghijklmnopqrstuvwxyz") {
+		rule.transitions = new([2][neighbourhoods]bool)
+		if rule.transitions[0], rule.Birth, err = parseHensel(birth, spec); err != nil {
+			return rule, err
+		}
+		if rule.transitions[1], rule.Survival, err = parseHensel(survival, spec); err != nil {
+			return rule, err
+		}
+		return rule, nil
+	}
+
+	for _, digit := range birth {
+		if digit < '0' || digit > '8' {
+			return rule, fmt.Errorf("Wrong number of neighbours '%c' in the rule '%v'", digit, spec)
+		}
+		rule.Birth[digit-'0'] = true
+	}
+	for _, digit := range survival {
+		if digit < '0' || digit > '8' {
+			return rule, fmt.Errorf("Wrong number of neighbours '%c' in the rule '%v'", digit, spec)
+		}
+		rule.Survival[digit-'0'] = true
+	}
+
+	return rule, nil
+}
+
+// methods
+
+// Return the specification of this rule in B/S notation, in Hensel notation
+// if it is non-totalistic, or as a weighted rule. Probabilities are not shown
+func (rule LifeRule) String() string {
+
+	if rule.weighted != nil {
+		return rule.weighted.String()
+	}
+
+	if rule.transitions != nil {
+		return "B" + henselString(&rule.transitions[0]) + "/S" + henselString(&rule.transitions[1])
+	}
+
+	var birth, survival strings.Builder
+	for n := 0; n <= 8; n++ {
+		if rule.Birth[n] {
+			fmt.Fprint(&birth, n)
+		}
+		if rule.Survival[n] {
+			fmt.Fprint(&survival, n)
+		}
+	}
+	return "B" + birth.String() + "/S" + survival.String()
+}
+
+// Return whether this rule is non-totalistic, i.e., whether births or
+// survivals depend on the arrangement of living neighbours. Weighted rules are
+// non-totalistic
+func (rule LifeRule) NonTotalistic() bool {
+	return rule.transitions != nil || rule.weighted != nil
+}
+
+// return whether a cell with the given neighbourhood takes birth (if it is
+// dead) or survives (if it is alive) according to this rule, before
+// considering probabilities
+func (rule *LifeRule) applies(alive bool, neighbourhood int) bool {
+
+	if rule.weighted != nil {
+		return rule.weighted.applies(alive, neighbourhood)
+	}
+	if rule.transitions != nil {
+		if alive {
+			return rule.transitions[1][neighbourhood]
+		}
+		return rule.transitions[0][neighbourhood]
+	}
+	// cells of triangular lattices might have more neighbours than those
+	// acknowledged by Life-like rules
+	neighbours := bits.OnesCount(uint(neighbourhood))
+	if neighbours >= len(rule.Birth) {
+		return false
+	}
+	if alive {
+		return rule.Survival[neighbours]
+	}
+	return rule.Birth[neighbours]
+}
+
+// Return whether this rule is stochastic, i.e., whether births or survivals
+// happen with a probability strictly less than 1
+func (rule LifeRule) Stochastic() bool {
+	return rule.BirthProbability < 1 || rule.SurvivalProbability < 1
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the Life-like rule followed by this generation. Stochastic rules draw
+// random numbers from the given generator, which is shared with all the
+// following generations so that runs are reproducible. By default,
+// generations follow the rule of the Conway's Game
+func (g *generation) SetRule(rule LifeRule, rng *rand.Rand) error {
+
+	if rule.BirthProbability < 0 || rule.BirthProbability > 1 ||
+		rule.SurvivalProbability < 0 || rule.SurvivalProbability > 1 {
+		return errors.New("Probabilities must be in the range [0, 1]")
+	}
+	if rule.Stochastic() && rng == nil {
+		return errors.New("Stochastic rules require a random number generator")
+	}
+	if rule.NonTotalistic() && g.topology != SquareTopology {
+		return errors.New("Non-totalistic rules can only be simulated over a square grid")
+	}
+	g.rule, g.rng = &rule, rng
+	return nil
+}
+
+// return the state of the cells off the grid in the next generation, i.e.,
+// whether a cell of the background, whose neighbours are all in its same
+// state, is alive in the next generation. Probabilities are not considered,
+// so that the background is uniform
+func (g *generation) nextBackground() bool {
+
+	if g.rule == nil {
+		return false
+	}
+	var uniform int
+	if g.background {
+		uniform = (neighbourhoods - 1) &^ neighbourBit(0, 0)
+		if g.topology != SquareTopology {
+			uniform = 1<<len(triangularOffsets[g.topology]) - 1
+		}
+	}
+	return g.rule.applies(g.background, uniform)
+}
+
+// return whether the cell at location (x, y) is alive in the next generation
+// according to the rule of this generation
+func (g *generation) lives(x, y int) bool {
+	return g.decide(g.ColorIndexAt(x, y) != 0, g.neighbourhood(x, y))
+}
+
+// return whether a cell which is currently alive (or not) with the given
+// neighbourhood of living cells is alive in the next generation according to
+// the rule of this generation
+func (g *generation) decide(alive bool, neighbourhood int) bool {
+
+	// by default, use the rule of the Conway's Game
+	if g.rule == nil {
+		neighbours := bits.OnesCount(uint(neighbourhood))
+		return (alive && (neighbours == 2 || neighbours == 3)) ||
+			(!alive && neighbours == 3)
+	}
+
+	// -- survival: living cells survive with the given probability if they
+	// have the right neighbourhood
+	if alive {
+		return g.rule.applies(true, neighbourhood) &&
+			(g.rule.SurvivalProbability >= 1 || g.rng.Float64() < g.rule.SurvivalProbability)
+	}
+
+	// -- birth: dead cells take birth with the given probability if they have
+	// the right neighbourhood
+	return g.rule.applies(false, neighbourhood) &&
+		(g.rule.BirthProbability >= 1 || g.rng.Float64() < g.rule.BirthProbability)
+}
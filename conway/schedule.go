@@ -0,0 +1,165 @@
+// The rule of Life-like automata can change during a run according to a
+// schedule, e.g., B3/S23 for generations 1 to 200 and B36/S23 afterwards.
+// Every entry of the schedule gives the rule used for computing the
+// generations in a range, and generations not covered by the schedule follow
+// the rule of the first generation
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ScheduledRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// A scheduled rule is used for computing all generations in the range [From,
+// To], where generations are numbered from 0 with the first generation of the
+// game. If To is negative, the rule is used for all generations from From on
+type ScheduledRule struct {
+	From, To int
+	Rule     LifeRule
+}
+
+// methods
+
+// Return whether this scheduled rule is used for computing the generation
+// with the given index
+func (s ScheduledRule) covers(index int) bool {
+	return index >= s.From && (s.To < 0 || index <= s.To)
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the schedule given in spec as a comma-separated list of entries
+// RULE:FROM-TO, where TO can be omitted to use the rule for all generations
+// from FROM on, e.g., "B36/S23:201-" or "B3/S23:1-200,B36/S23:201-". An error
+// is returned if the schedule is not well formed
+func ParseSchedule(spec string) ([]ScheduledRule, error) {
+
+	// weighted rules contain commas as well, so that every entry starts only
+	// where a rule starts
+	var entries []string
+	for _, token := range strings.Split(spec, ",") {
+		if len(entries) > 0 && !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(token)), "B") {
+			entries[len(entries)-1] += "," + token
+			continue
+		}
+		entries = append(entries, token)
+	}
+
+	var schedule []ScheduledRule
+	for _, entry := range entries {
+		rule, generations, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found {
+			return nil, fmt.Errorf("Syntax error in the entry '%v'", entry)
+		}
+		var scheduled ScheduledRule
+		var err error
+		if scheduled.Rule, err = ParseLifeRule(rule); err != nil {
+			return nil, err
+		}
+		from, to, found := strings.Cut(generations, "-")
+		if !found {
+			return nil, fmt.Errorf("Missing range of generations in the entry '%v'", entry)
+		}
+		if scheduled.From, err = strconv.Atoi(strings.TrimSpace(from)); err != nil {
+			return nil, fmt.Errorf("Wrong first generation in the entry '%v'", entry)
+		}
+		scheduled.To = -1
+		if strings.TrimSpace(to) != "" {
+			if scheduled.To, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				return nil, fmt.Errorf("Wrong last generation in the entry '%v'", entry)
+			}
+		}
+		schedule = append(schedule, scheduled)
+	}
+	return schedule, nil
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the schedule of rules of this game. Only the Conway's Game and other
+// Life-like rules can be scheduled, and ranges of generations must be well
+// formed and can not overlap. Schedules apply only to the generations
+// recorded, so that the burn-in phase, if any, follows the rule of the first
+// generation
+func (game *Conway) SetSchedule(schedule []ScheduledRule) error {
+
+	first := game.generations[0]
+	if !first.isLife() {
+		return errors.New("Only Life-like rules can be scheduled")
+	}
+	if game.engine == HashLifeEngine {
+		return errors.New("HashLife can not simulate scheduled rules")
+	}
+	for i, s := range schedule {
+		if s.From < 1 || (s.To >= 0 && s.To < s.From) {
+			return fmt.Errorf("Wrong range of generations [%v, %v]", s.From, s.To)
+		}
+		if s.Rule.Stochastic() && first.rng == nil {
+			return errors.New("Stochastic rules require a random number generator")
+		}
+		if s.Rule.Birth[0] && (game.engine != DenseEngine || game.unbounded) {
+			return errors.New("Rules with B0 can only be simulated with the dense engine over a bounded board")
+		}
+		if s.Rule.NonTotalistic() && first.topology != SquareTopology {
+			return errors.New("Non-totalistic rules can only be simulated over a square grid")
+		}
+		for _, t := range schedule[:i] {
+			if s.covers(t.From) || t.covers(s.From) {
+				return fmt.Errorf("The rules '%v' and '%v' are scheduled in overlapping ranges", t.Rule, s.Rule)
+			}
+		}
+	}
+	game.schedule, game.rule = schedule, first.rule
+	return nil
+}
+
+// return the next generation of the given one, applying the rule scheduled
+// for it, if any
+func (game *Conway) next(g *generation) *generation {
+
+	if game.schedule == nil {
+		return g.Next()
+	}
+	step := *g
+	step.rule = game.scheduledRule(g.nbgeneration)
+	return step.Next()
+}
+
+// return the rule used for computing the generation with the given index,
+// which is nil for the rule of the Conway's Game
+func (game *Conway) scheduledRule(index int) *LifeRule {
+
+	for i := range game.schedule {
+		if game.schedule[i].covers(index) {
+			return &game.schedule[i].Rule
+		}
+	}
+	return game.rule
+}
+
+// return whether any rule of this game, either the rule of the first
+// generation or a scheduled one, has B0
+func (game *Conway) strobes() bool {
+
+	if rule := game.generations[0].rule; rule != nil && rule.Birth[0] {
+		return true
+	}
+	for _, s := range game.schedule {
+		if s.Rule.Birth[0] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+// Scrubbing allows serving any generation of a game on demand without storing
+// all of them. Only keyframes are stored, i.e., one generation every so many,
+// and any other generation is computed by replaying the game from the closest
+// keyframe before it
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+)
+
+// Scrubber
+// ----------------------------------------------------------------------------
+
+// type
+
+// A scrubber stores one keyframe every interval generations. Keyframes are
+// computed lazily, as generations are requested. Additionally, the last
+// generation served is cached so that generations requested sequentially (as
+// it happens when playing an animation) are computed only once. Images are
+// cropped to remove the halo of the game, if any, and annotated as frames are.
+// Scrubbers are safe for concurrent use
+type Scrubber struct {
+	interval      int
+	nbgenerations int
+	keyframes     []*generation
+	last          *generation
+	next          func(g *generation) *generation
+	overlay       func(index int, img *image.Paletted) *image.Paletted
+	board         image.Rectangle
+	mutex         sync.Mutex
+}
+
+// methods
+
+// Return a scrubber of this game which stores one keyframe every interval
+// generations. The burn-in phase, if any, is simulated right away. Games with
+// stochastic rules can not be scrubbed, as replaying them would produce
+// different generations, and neither can unbounded games
+func (game *Conway) Scrubber(interval int) (*Scrubber, error) {
+
+	if interval < 1 {
+		return nil, errors.New("The interval between keyframes must be positive")
+	}
+	if game.unbounded {
+		return nil, errors.New("Unbounded games can not be scrubbed")
+	}
+	if rule := game.generations[0].rule; rule != nil && rule.Stochastic() {
+		return nil, errors.New("Games with stochastic rules can not be scrubbed")
+	}
+	if game.noise != nil {
+		return nil, errors.New("Games perturbed with noise can not be scrubbed")
+	}
+	for _, s := range game.schedule {
+		if s.Rule.Stochastic() {
+			return nil, errors.New("Games with stochastic rules can not be scrubbed")
+		}
+	}
+
+	game.burnIn()
+	return &Scrubber{
+		interval:      interval,
+		nbgenerations: game.nbgenerations,
+		keyframes:     []*generation{game.generations[0]},
+		last:          game.generations[0],
+		next:          game.next,
+		overlay: func(index int, img *image.Paletted) *image.Paletted {
+			return game.annotate(index, game.resize(game.crop(img)))
+		},
+		board: game.board()}, nil
+}
+
+// Return the number of generations that can be served by this scrubber
+func (s *Scrubber) Generations() int {
+	return s.nbgenerations
+}
+
+// Return the number of keyframes currently stored by this scrubber
+func (s *Scrubber) Keyframes() int {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.keyframes)
+}
+
+// Return the paletted image of the generation with the given index, starting
+// from 0. In case the index is out of range an error is returned
+func (s *Scrubber) Generation(index int) (*image.Paletted, error) {
+
+	current, err := s.generation(index)
+	if err != nil {
+		return nil, err
+	}
+	return s.overlay(index, (*image.Paletted)(&current.img)), nil
+}
+
+// Return the living cells of the generation with the given index, starting
+// from 0, sorted by rows and then by columns. Cells are located relative to
+// the upper-left corner of the board rendered, so that cells in the halo, if
+// any, are ignored. In case the index is out of range an error is returned
+func (s *Scrubber) Alive(index int) ([]image.Point, error) {
+
+	current, err := s.generation(index)
+	if err != nil {
+		return nil, err
+	}
+	var result []image.Point
+	for y := s.board.Min.Y; y < s.board.Max.Y; y++ {
+		for x := s.board.Min.X; x < s.board.Max.X; x++ {
+			if current.ColorIndexAt(x, y) != 0 {
+				result = append(result, image.Point{X: x, Y: y}.Sub(s.board.Min))
+			}
+		}
+	}
+	return result, nil
+}
+
+// Return the dimensions of the board rendered by this scrubber, i.e., without
+// the halo, if any
+func (s *Scrubber) Dimensions() (width, height int) {
+	return s.board.Dx(), s.board.Dy()
+}
+
+// return the generation with the given index, starting from 0. In case the
+// index is out of range an error is returned
+func (s *Scrubber) generation(index int) (*generation, error) {
+
+	if index < 0 || index >= s.nbgenerations {
+		return nil, fmt.Errorf("Generation %v out of range [0, %v)", index, s.nbgenerations)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// first, compute all keyframes up to the one preceding the requested
+	// generation
+	ikeyframe := index / s.interval
+	for len(s.keyframes) <= ikeyframe {
+		current := s.keyframes[len(s.keyframes)-1]
+		for i := 0; i < s.interval; i++ {
+			current = s.next(current)
+		}
+		s.keyframes = append(s.keyframes, current)
+	}
+
+	// and now replay the game either from that keyframe or from the last
+	// generation served, whichever is closer
+	current := s.keyframes[ikeyframe]
+	if last := s.last.nbgeneration - 1; last <= index && last > ikeyframe*s.interval {
+		current = s.last
+	}
+	for current.nbgeneration-1 < index {
+		current = s.next(current)
+	}
+	s.last = current
+
+	return current, nil
+}
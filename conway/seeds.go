@@ -0,0 +1,203 @@
+// Every stochastic feature draws its random numbers from its own stream, which
+// is derived from a master seed and the name of the feature. Thus, enabling or
+// disabling one feature does not change the random numbers drawn by the others,
+// so that runs which differ only in one feature can be compared fairly.
+//
+// The state of every stream is given by its seed and the number of values drawn
+// from it, so that it can be saved in checkpoints and restored later to resume
+// runs bit-exactly
+
+package conway
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Names of the random streams of every subsystem
+const (
+	// the initial population. For backwards compatibility, its stream is
+	// seeded with the master seed itself
+	SoupStream = "soup"
+
+	// births and survivals of stochastic rules
+	RuleStream = "rule"
+
+	// the species given to cells in multi-colour variants
+	SpeciesStream = "species"
+
+	// the cells flipped by noise
+	NoiseStream = "noise"
+
+	// growths and lightnings in the forest-fire model
+	ForestFireStream = "forestfire"
+)
+
+// RandState
+// ----------------------------------------------------------------------------
+
+// type
+
+// The state of a random stream is given by the Seed it was created with and
+// the number of values drawn from it since then
+type RandState struct {
+	Seed  int64  `json:"seed"`
+	Draws uint64 `json:"draws"`
+}
+
+// Source
+// ----------------------------------------------------------------------------
+
+// type
+
+// A source of random numbers which keeps track of its state, so that it can be
+// saved and restored
+type Source struct {
+	state  RandState
+	source rand.Source64
+}
+
+// Functions
+
+// Return a new source of random numbers with the given state
+func NewSource(state RandState) *Source {
+
+	source := &Source{}
+	source.Restore(state)
+	return source
+}
+
+// methods
+
+// Return a non-negative pseudo-random 63-bit integer
+func (s *Source) Int63() int64 {
+
+	s.state.Draws++
+	return s.source.Int63()
+}
+
+// Return a pseudo-random 64-bit value
+func (s *Source) Uint64() uint64 {
+
+	s.state.Draws++
+	return s.source.Uint64()
+}
+
+// Seed this source with the given value
+func (s *Source) Seed(seed int64) {
+	s.Restore(RandState{Seed: seed})
+}
+
+// Return the current state of this source
+func (s *Source) State() RandState {
+	return s.state
+}
+
+// Restore the given state of this source, which is seeded again and skips as
+// many values as were drawn
+func (s *Source) Restore(state RandState) {
+
+	s.source = rand.NewSource(state.Seed).(rand.Source64)
+	for i := uint64(0); i < state.Draws; i++ {
+		s.source.Uint64()
+	}
+	s.state = state
+}
+
+// Seeds
+// ----------------------------------------------------------------------------
+
+// type
+
+// Seeds derive the seeds of the random streams of all subsystems from a
+// master seed, and they keep track of the sources of the streams created
+type Seeds struct {
+	master  int64
+	sources map[string]*Source
+}
+
+// Functions
+
+// Return the seeds derived from the given master seed
+func NewSeeds(master int64) Seeds {
+	return Seeds{master: master, sources: make(map[string]*Source)}
+}
+
+// methods
+
+// Return the master seed
+func (s Seeds) Master() int64 {
+	return s.master
+}
+
+// Return the seed of the random stream of the given subsystem. Different
+// subsystems get unrelated seeds, and the same subsystem always gets the same
+// seed from the same master seed
+func (s Seeds) Seed(subsystem string) int64 {
+
+	if subsystem == SoupStream {
+		return s.master
+	}
+
+	// mix the master seed with a hash of the name of the subsystem using
+	// the finalizer of SplitMix64
+	hash := fnv.New64a()
+	hash.Write([]byte(subsystem))
+	z := uint64(s.master) ^ hash.Sum64()
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// Return a new random number generator for the given subsystem
+func (s Seeds) Rand(subsystem string) *rand.Rand {
+	return s.Stream(subsystem, s.Seed(subsystem))
+}
+
+// Return a new random number generator for the given subsystem seeded with
+// the given value. If the state of the subsystem has been restored, the
+// generator resumes from it instead, and the seed is ignored
+func (s Seeds) Stream(subsystem string, seed int64) *rand.Rand {
+
+	source, ok := s.sources[subsystem]
+	if !ok {
+		source = NewSource(RandState{Seed: seed})
+		if s.sources != nil {
+			s.sources[subsystem] = source
+		}
+	}
+	return rand.New(source)
+}
+
+// Return the current state of the random streams of all subsystems created so
+// far
+func (s Seeds) States() map[string]RandState {
+
+	result := make(map[string]RandState, len(s.sources))
+	for subsystem, source := range s.sources {
+		result[subsystem] = source.State()
+	}
+	return result
+}
+
+// Restore the state of the random streams of the given subsystems, e.g., from
+// a checkpoint. Generators already created resume from the restored state, and
+// so do those created afterwards
+func (s Seeds) Restore(states map[string]RandState) error {
+
+	if s.sources == nil {
+		return fmt.Errorf("The random streams of the master seed %v are not tracked", s.master)
+	}
+	for subsystem, state := range states {
+		if source, ok := s.sources[subsystem]; ok {
+			source.Restore(state)
+		} else {
+			s.sources[subsystem] = NewSource(state)
+		}
+	}
+	return nil
+}
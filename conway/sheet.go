@@ -0,0 +1,106 @@
+// Contact sheets lay out several generations of a game as a grid over a single
+// large image, every one with a label showing its index. They are intended for
+// posters and teaching handouts. Sprite sheets lay out generations in the same
+// way but packed next to each other, so that they can be cut automatically
+
+package conway
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Margin around the sheet and between consecutive generations, in pixels
+const sheetMargin = 16
+
+// Scale used for drawing labels in contact sheets
+const sheetLabelScale = 2
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return a contact sheet with one every n generations of this game laid out
+// in a grid with the given number of columns. If columns is not strictly
+// positive, the grid is made as square as possible. If labels is true, every
+// generation is labelled with its index, starting from 0
+func (game *Conway) ContactSheet(every, columns int, labels bool) *image.RGBA {
+	return game.sheet(every, columns, sheetMargin, labels)
+}
+
+// Return a sprite sheet with one every n generations of this game laid out in
+// a grid with the given number of columns as ContactSheet does, but with
+// frames packed next to each other, without margins nor labels, so that every
+// frame can be located by its position, e.g., by game engines and
+// documentation tools. Cells of the grid left empty are transparent
+func (game *Conway) SpriteSheet(every, columns int) *image.RGBA {
+	return game.sheet(every, columns, 0, false)
+}
+
+// return a sheet with one every n generations of this game laid out in a grid
+// with the given number of columns and the given margin around the sheet and
+// between generations, which are labelled with their index if labels is true.
+// Sheets with margins are drawn over a white background
+func (game *Conway) sheet(every, columns, margin int, labels bool) *image.RGBA {
+
+	// select the generations to show
+	if every < 1 {
+		every = 1
+	}
+	var indices []int
+	for index := 0; index < len(game.generations); index += every {
+		if game.computed(index) {
+			indices = append(indices, index)
+		}
+	}
+	if len(indices) == 0 {
+		return image.NewRGBA(image.Rectangle{})
+	}
+
+	// compute the dimensions of the grid and every cell of the grid
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(len(indices)))))
+	}
+	rows := (len(indices) + columns - 1) / columns
+	bounds := game.frame(indices[0], 1).Bounds()
+	labelHeight := 0
+	if labels {
+		labelHeight = sheetMargin/2 + TextHeight(sheetLabelScale)
+	}
+	cellWidth := bounds.Dx() + margin
+	cellHeight := bounds.Dy() + labelHeight + margin
+
+	// create the sheet
+	sheet := image.NewRGBA(image.Rect(0, 0,
+		margin+columns*cellWidth,
+		margin+rows*cellHeight))
+	if margin > 0 {
+		draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+
+	// and draw every generation along with its label
+	for i, index := range indices {
+		origin := image.Point{
+			X: margin + (i%columns)*cellWidth,
+			Y: margin + (i/columns)*cellHeight}
+		img := game.frame(index, 1)
+		draw.Draw(sheet, image.Rectangle{Min: origin, Max: origin.Add(bounds.Size())},
+			img, bounds.Min, draw.Src)
+		if labels {
+			label := fmt.Sprintf("Gen %v", index)
+			DrawText(sheet, image.Point{
+				X: origin.X + (bounds.Dx()-TextWidth(label, sheetLabelScale))/2,
+				Y: origin.Y + bounds.Dy() + sheetMargin/2},
+				label, color.Black, sheetLabelScale)
+		}
+	}
+
+	return sheet
+}
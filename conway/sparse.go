@@ -0,0 +1,181 @@
+// Sparse boards store only the location of living cells, so that the memory
+// and time used by huge and mostly empty boards scale with their population
+// rather than with their dimensions. Generations are materialized as paletted
+// images only when they are rendered
+
+package conway
+
+import (
+	"image"
+	"time"
+)
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// return the generation used as a template for computing the colours of
+// living cells of a sparse board, with the given index
+func (game *Conway) template(nbgeneration int) *generation {
+
+	template := *game.generations[0]
+	template.nbgeneration = nbgeneration
+	return &template
+}
+
+// return the living cells of the generation next to the one given in cells,
+// along with their colours, which are computed with the given template.
+// Unless the game is unbounded, the boundary condition of the template is
+// applied at the edges of the board
+func (game *Conway) nextSparse(cells map[image.Point]uint8, template *generation) map[image.Point]uint8 {
+
+	// the location of a cell is given by the boundary condition, unless the
+	// game is unbounded
+	locate := func(p image.Point) (image.Point, bool) {
+		if game.unbounded {
+			return p, true
+		}
+		return template.locate(p.X, p.Y)
+	}
+
+	// compute the neighbourhoods of all living cells and those around them
+	neighbours := make(map[image.Point]int, 9*len(cells))
+	for p := range cells {
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if q, ok := locate(p.Add(image.Point{X: dx, Y: dy})); ok {
+					neighbours[q] = 0
+				}
+			}
+		}
+	}
+	for p := range neighbours {
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				if dx == 0 && dy == 0 {
+					continue
+				}
+				if q, ok := locate(p.Add(image.Point{X: dx, Y: dy})); ok {
+					if _, alive := cells[q]; alive {
+						neighbours[p] |= neighbourBit(dx, dy)
+					}
+				}
+			}
+		}
+	}
+
+	// stochastic rules draw random numbers for every cell, so that cells are
+	// processed in order for the sake of reproducibility
+	candidates := make([]image.Point, 0, len(neighbours))
+	for p := range neighbours {
+		candidates = append(candidates, p)
+	}
+	if template.rule != nil && template.rule.Stochastic() {
+		sortPoints(candidates)
+	}
+
+	// and apply the rule to all of them
+	next := make(map[image.Point]uint8, len(cells))
+	for _, p := range candidates {
+		_, alive := cells[p]
+		if !template.decide(alive, neighbours[p]) {
+			continue
+		}
+
+		// as in dense boards, cells given the color of dead cells are dead
+		if c := template.cellColor(p.X, p.Y); c != 0 {
+			next[p] = c
+		}
+	}
+	return next
+}
+
+// Run the entire game over a sparse board, simulating first the burn-in
+// phase, if any
+func (game *Conway) runSparse() {
+
+	// get the living cells of the first generation
+	first := game.generations[0]
+	cells := make(map[image.Point]uint8)
+	for x := 0; x < game.width; x++ {
+		for y := 0; y < game.height; y++ {
+			if c := first.ColorIndexAt(x, y); c != 0 {
+				cells[image.Point{X: x, Y: y}] = c
+			}
+		}
+	}
+
+	// simulate the burn-in phase, if any, and recompute the colours of the
+	// cells of the last generation simulated
+	if game.burnin > 0 {
+		start := time.Now()
+		game.burninStats.InitialPopulation = len(cells)
+		// as in dense boards, colours are not computed during the burn-in
+		template := game.template(first.nbgeneration)
+		template.model = ""
+		for igeneration := 0; igeneration < game.burnin; igeneration++ {
+			cells = game.nextSparse(cells, template)
+		}
+		for p := range cells {
+			if cells[p] = first.cellColor(p.X, p.Y); cells[p] == 0 {
+				delete(cells, p)
+			}
+		}
+		game.burninStats.Generations = game.burnin
+		game.burninStats.FinalPopulation = len(cells)
+		game.burninStats.Elapsed = time.Since(start)
+	}
+
+	// and now simulate all generations
+	game.cells = make([]map[image.Point]uint8, game.nbgenerations)
+	game.cells[0] = cells
+	for igeneration := 1; igeneration < game.nbgenerations; igeneration++ {
+		template := game.template(first.nbgeneration + igeneration - 1)
+		if game.schedule != nil {
+			template.rule = game.scheduledRule(igeneration)
+		}
+		game.cells[igeneration] = game.nextSparse(game.cells[igeneration-1], template)
+		game.perturbCells(game.cells[igeneration], igeneration, template)
+	}
+}
+
+// return the generation with the given index of a game whose living cells are
+// stored separately (either in a sparse board or sampled from HashLife) as a
+// dense generation. Unbounded games are materialized over a region which contains
+// both the original board and all living cells
+func (game *Conway) materialize(index int) *generation {
+
+	first := game.generations[0]
+	cells := game.cells[index]
+	if !game.unbounded {
+		g := first.empty(first.nbgeneration + index)
+		for p, c := range cells {
+			g.SetColorIndex(p.X, p.Y, c)
+		}
+		return g
+	}
+
+	region := image.Rect(0, 0, game.width, game.height)
+	for p := range cells {
+		region = region.Union(image.Rect(p.X, p.Y, p.X+1, p.Y+1))
+	}
+	g := first.emptyWithin(image.Rect(0, 0, region.Dx(), region.Dy()), first.nbgeneration+index)
+	g.origin = region.Min.Mul(-1)
+	g.center = first.center.Sub(region.Min)
+	for p, c := range cells {
+		g.SetColorIndex(p.X-region.Min.X, p.Y-region.Min.Y, c)
+	}
+	return g
+}
+
+// return the paletted image of the generation with the given index of a game
+// whose living cells are stored separately
+func (game *Conway) renderCells(index int) *image.Paletted {
+
+	g := game.materialize(index)
+	if game.unbounded {
+		return game.render(g)
+	}
+	return (*image.Paletted)(&g.img)
+}
@@ -0,0 +1,169 @@
+// Multi-colour variants of the Conway's Game follow its rules, but living cells
+// belong to one among several species. Cells that survive keep their species,
+// and newborn cells take the species of the majority of their parents. The
+// Immigration Game is played with two species. QuadLife is played with four
+// species, and newborn cells whose three parents belong to different species
+// take the remaining one.
+//
+// The species of every cell is stored separately from its colour, and every
+// species is rendered with a separate sub-palette
+
+package conway
+
+import (
+	"errors"
+	"image/color"
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return a palette for the given number of species where dead cells are given
+// the first color and every species is given a separate sub-palette which
+// fades from its color to half its brightness
+func SpeciesPalette(dead color.Color, species []color.Color) color.Palette {
+
+	palette := color.Palette{dead}
+	size := 255 / len(species)
+	for _, c := range species {
+		r, g, b, _ := c.RGBA()
+		for i := 0; i < size; i++ {
+			fade := 1.0 - 0.5*float64(i)/float64(size)
+			palette = append(palette, color.RGBA{
+				uint8(fade * float64(r>>8)),
+				uint8(fade * float64(g>>8)),
+				uint8(fade * float64(b>>8)),
+				255})
+		}
+	}
+	return palette
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the contents of a generation with nbspecies species. Every cell is given
+// its species, starting from 1, or 0 if it is dead. The slice follows the same
+// layout expected by Set. In case the given slice and the length of the
+// contents do not match, or any species is out of range, an error is returned
+func (g *generation) SetSpecies(species []uint8, nbspecies int) error {
+
+	if nbspecies < 1 || nbspecies > 255 {
+		return errors.New("The number of species must be in the range [1, 255]")
+	}
+	if len(species) != (1+g.img.Rect.Max.Y/g.ratio.Y-g.img.Rect.Min.Y/g.ratio.Y)*
+		(1+g.img.Rect.Max.X/g.ratio.X-g.img.Rect.Min.X/g.ratio.X) {
+		return errors.New("Mismatched dimensions")
+	}
+	for _, s := range species {
+		if int(s) > nbspecies {
+			return errors.New("Species out of range")
+		}
+	}
+
+	g.species = append([]uint8(nil), species...)
+	g.nbspecies = nbspecies
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			g.SetColorIndex(x, y, g.speciesColor(x, y))
+		}
+	}
+	return nil
+}
+
+// Return the species of all cells of a generation, or 0 for dead cells. The
+// slice follows the same layout expected by SetSpecies
+func (g *generation) Species() []uint8 {
+	return append([]uint8(nil), g.species...)
+}
+
+// return the species of the cell at location (x, y), or 0 if it is dead
+func (g *generation) speciesAt(x, y int) uint8 {
+	return g.species[y*(1+g.img.Rect.Max.X/g.ratio.X)+x]
+}
+
+// return the color index of the cell at location (x, y) according to its
+// species. The color given by the colour model is scaled to the sub-palette of
+// the species
+func (g *generation) speciesColor(x, y int) uint8 {
+
+	s := g.speciesAt(x, y)
+	if s == 0 {
+		return 0
+	}
+	size := 255 / g.nbspecies
+	return uint8(1 + (int(s)-1)*size + (int(g.cellColor(x, y))-1)*size/255)
+}
+
+// return the species of a cell born at location (x, y), which is the species
+// of the majority of its living neighbours. Ties are broken in favour of the
+// species with the lowest index. In QuadLife, if all parents belong to
+// different species, the newborn takes the only species not found among them
+func (g *generation) newborn(x, y int) uint8 {
+
+	counts := make([]int, 1+g.nbspecies)
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			if p, ok := g.locate(x+dx, y+dy); ok {
+				counts[g.speciesAt(p.X, p.Y)]++
+			}
+		}
+	}
+
+	var result uint8
+	for s := 1; s <= g.nbspecies; s++ {
+		if result == 0 || counts[s] > counts[result] {
+			result = uint8(s)
+		}
+	}
+
+	// in QuadLife, if every parent belongs to a different species and only
+	// one species is missing, take it
+	if g.automaton == "quadlife" && counts[result] == 1 {
+		var missing []uint8
+		for s := 1; s <= g.nbspecies; s++ {
+			if counts[s] == 0 {
+				missing = append(missing, uint8(s))
+			}
+		}
+		if len(missing) == 1 {
+			return missing[0]
+		}
+	}
+	return result
+}
+
+// Return the next generation of a multi-colour variant of the Conway's Game.
+// Births and survivals follow the Life-like rule of this generation
+func (g *generation) nextSpecies() *generation {
+
+	// create a new generation with the same features than this one
+	next := g.empty(1 + g.nbgeneration)
+	next.species = make([]uint8, len(g.species))
+
+	width := 1 + g.img.Rect.Max.X/g.ratio.X
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			if !g.lives(x, y) {
+				continue
+			}
+
+			// living cells keep their species and newborn cells take the
+			// species of the majority of their parents
+			if s := g.speciesAt(x, y); s != 0 {
+				next.species[y*width+x] = s
+			} else {
+				next.species[y*width+x] = g.newborn(x, y)
+			}
+			next.SetColorIndex(x, y, next.speciesColor(x, y))
+		}
+	}
+
+	// and return the next generation
+	return next
+}
@@ -0,0 +1,267 @@
+// The state of a game can be saved after running it and resumed later, so that
+// long runs can be split across several invocations. States are written in
+// JSON with a version number, and they capture the cells of the last
+// generation (and their ages, if tracked), the rule, the palette, the index of
+// the generation and the state of all random streams, so that resumed runs are
+// bit-exact continuations of the original ones
+
+package conway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Version of the format of saved states. States written with other versions
+// are rejected
+const StateVersion = 1
+
+// State
+// ----------------------------------------------------------------------------
+
+// type
+
+// A state consists of the cells of a generation given as States with the same
+// layout expected by SetStates and, if tracked, their Ages, along with the
+// dimensions of the board (Width, Height), the Automaton and the Life-like
+// Rule, Boundary and Lifespan it follows, the Palette (in the format #RRGGBB)
+// and colour Model used for rendering it, its index Generation counted from
+// the first generation of the first run, the number of Generations of every
+// run and the master Seed and the state of the random Streams of all
+// subsystems
+type State struct {
+	Version     int                  `json:"version"`
+	Generation  int                  `json:"generation"`
+	Generations int                  `json:"generations"`
+	Width       int                  `json:"width"`
+	Height      int                  `json:"height"`
+	Automaton   string               `json:"automaton"`
+	Rule        string               `json:"rule,omitempty"`
+	Boundary    Boundary             `json:"boundary"`
+	Lifespan    int                  `json:"lifespan,omitempty"`
+	Model       string               `json:"model"`
+	Palette     []string             `json:"palette"`
+	States      []uint8              `json:"states"`
+	Ages        []int                `json:"ages,omitempty"`
+	Seed        int64                `json:"seed"`
+	Streams     map[string]RandState `json:"streams,omitempty"`
+}
+
+// Functions
+
+// Return the state read from the given reader, along with an error if it is
+// not well formed or it was written with another version
+func ReadState(r io.Reader) (State, error) {
+
+	var state State
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return State{}, fmt.Errorf("Syntax error in the state: %v", err)
+	}
+	if state.Version != StateVersion {
+		return State{}, fmt.Errorf("Unsupported version %v of the state, expected %v", state.Version, StateVersion)
+	}
+	if state.Width <= 0 || state.Height <= 0 ||
+		len(state.States) != (1+state.Width)*(1+state.Height) {
+		return State{}, errors.New("The cells of the state do not match its dimensions")
+	}
+	if state.Ages != nil && len(state.Ages) != len(state.States) {
+		return State{}, errors.New("The ages of the state do not match its dimensions")
+	}
+	return state, nil
+}
+
+// Return a new game which resumes the state read from the given reader, with
+// as many generations as the game that was saved. The first generation of the
+// new game is the last one of the saved game. Only Life-like rules can be
+// loaded this way, and other automata have to be rebuilt before restoring
+// their state with Restore
+func Load(r io.Reader) (*Conway, error) {
+
+	state, err := ReadState(r)
+	if err != nil {
+		return nil, err
+	}
+	if state.Automaton != "life" {
+		return nil, fmt.Errorf("Only Life-like rules can be loaded, but the state follows '%v'", state.Automaton)
+	}
+	rule, err := ParseLifeRule(state.Rule)
+	if err != nil {
+		return nil, err
+	}
+	var palette color.Palette
+	for _, spec := range state.Palette {
+		var c color.RGBA
+		if _, err := fmt.Sscanf(spec, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+			return nil, fmt.Errorf("Wrong colour '%v' in the palette of the state", spec)
+		}
+		c.A = 0xff
+		palette = append(palette, c)
+	}
+
+	// the random streams resume from their saved state
+	seeds := NewSeeds(state.Seed)
+	if err := seeds.Restore(state.Streams); err != nil {
+		return nil, err
+	}
+
+	initial := NewGeneration(image.Rect(0, 0, state.Width, state.Height),
+		palette,
+		AspectRatio{X: 1, Y: 1},
+		state.Model,
+		1, max(state.Generations, 1))
+	initial.SetCenter(image.Point{X: state.Width / 2, Y: state.Height / 2})
+	initial.SetBoundary(state.Boundary)
+	if err := initial.SetRule(rule, seeds.Rand(RuleStream)); err != nil {
+		return nil, err
+	}
+	if err := initial.SetLifespan(state.Lifespan); err != nil {
+		return nil, err
+	}
+
+	game := NewConway(state.Width, state.Height, max(state.Generations, 1), initial)
+	game.SetSeeds(seeds)
+	if err := game.Restore(state); err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// return an error if the cells of this generation are not fully described by
+// their states, so that it can not be saved
+func (g *generation) saveable() error {
+
+	if g.species != nil || g.volume != nil || g.continuum != nil ||
+		g.ants != nil || g.margolus != nil || g.automaton == "elementary" {
+		return fmt.Errorf("The state of '%v' can not be saved", g.automaton)
+	}
+	return nil
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the seeds whose random streams are used by this game, so that their
+// state is saved along with it
+func (game *Conway) SetSeeds(seeds Seeds) {
+	game.seeds = &seeds
+}
+
+// Return the state of the last generation computed by this game, along with an
+// error if none has been computed or it can not be saved. Only games
+// simulated by the dense engine can be saved
+func (game *Conway) State() (State, error) {
+
+	if game.engine != DenseEngine {
+		return State{}, errors.New("Only games simulated by the dense engine can be saved")
+	}
+	index := game.Last()
+	if index < 0 {
+		return State{}, errors.New("No generation has been computed yet")
+	}
+	g := game.generations[index]
+	if err := g.saveable(); err != nil {
+		return State{}, err
+	}
+
+	width, height := g.dimensions()
+	state := State{
+		Version:     StateVersion,
+		Generation:  game.offset + index,
+		Generations: game.nbgenerations,
+		Width:       width,
+		Height:      height,
+		Automaton:   g.automaton,
+		Boundary:    g.boundary,
+		Lifespan:    g.lifespan,
+		Model:       g.model,
+		States:      g.States()}
+	if state.Automaton == "" {
+		state.Automaton = "life"
+	}
+	if g.rule != nil {
+		state.Rule = g.rule.String()
+	}
+	if g.ages != nil {
+		state.Ages = append([]int(nil), g.ages...)
+	}
+	for _, c := range g.img.Palette {
+		rgba := color.RGBAModel.Convert(c).(color.RGBA)
+		state.Palette = append(state.Palette, fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B))
+	}
+	if game.seeds != nil {
+		state.Seed, state.Streams = game.seeds.Master(), game.seeds.States()
+	}
+	return state, nil
+}
+
+// Write the state of the last generation computed by this game to the given
+// writer in JSON, along with an error if it can not be saved
+func (game *Conway) Save(w io.Writer) error {
+
+	state, err := game.State()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(state)
+}
+
+// Restore the cells of the given state in the first generation of this game,
+// which must have been created with the same dimensions and automaton, so
+// that this game resumes the saved one. The random streams are not restored,
+// since they have to be restored in the seeds before creating the generators
+// drawn from them (see Seeds.Restore)
+func (game *Conway) Restore(state State) error {
+
+	g := game.generations[0]
+	if err := g.saveable(); err != nil {
+		return err
+	}
+	automaton := g.automaton
+	if automaton == "" {
+		automaton = "life"
+	}
+	if automaton != state.Automaton {
+		return fmt.Errorf("The state follows '%v' instead of '%v'", state.Automaton, automaton)
+	}
+	if width, height := g.dimensions(); width != state.Width || height != state.Height {
+		return fmt.Errorf("The dimensions %vx%v of the state do not match those of the board %vx%v", state.Width, state.Height, width, height)
+	}
+	for _, index := range state.States {
+		if int(index) >= len(g.img.Palette) {
+			return fmt.Errorf("The state %v exceeds the palette", index)
+		}
+	}
+
+	if err := g.SetStates(state.States); err != nil {
+		return err
+	}
+	g.ages = nil
+	if state.Ages != nil && g.tracksAges() {
+		g.ages = append([]int(nil), state.Ages...)
+	}
+	game.offset = state.Generation
+	return nil
+}
+
+// Return the index of the first generation of this game counted from the first
+// generation of the first run, i.e., the index of the generation it resumes,
+// or 0 if it does not resume any
+func (game *Conway) Offset() int {
+	return game.offset
+}
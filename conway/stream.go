@@ -0,0 +1,184 @@
+// GIF animations can also be written while games are simulated, so that every
+// frame is encoded as soon as its generation is computed. Games simulated by
+// the dense engine keep then only the generations needed for computing the
+// next ones and averaging frames, so that very long runs take a bounded amount
+// of memory. The result is exactly the same file written with GetGIF
+
+package conway
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// length of the header and the logical screen descriptor of GIF files
+const gifHeaderLength = 13
+
+// trailer which terminates all GIF files
+const gifTrailer = 0x3b
+
+// number of previous generations needed by the director for detecting that a
+// game is stable
+const stableWindow = 2
+
+// GIFOptions
+// ----------------------------------------------------------------------------
+
+// type
+
+// Options of the GIF animations written while games are simulated: the delay
+// in 100th of a second of the first frame and of all the others, the number of
+// generations colours are averaged over, the number of times the animation is
+// repeated as in gif.GIF, i.e., 0 means forever and -1 means that it is shown
+// only once, and whether frames are optimized as OptimizeGIF does
+type GIFOptions struct {
+	Delay0    int
+	Delay     int
+	Average   int
+	LoopCount int
+	Optimize  bool
+}
+
+// gifStream
+// ----------------------------------------------------------------------------
+
+// type
+
+// a gifStream writes the frames of a GIF animation one at a time. Every frame
+// is encoded separately with the standard library, and only its image
+// descriptor and data are written but for the first one, which also writes
+// the header of the file. The size of the animation is that of the first
+// frame. Optimized animations use its palette as the global palette, and the
+// last frame is kept for computing the area changed in the next one
+type gifStream struct {
+	w         io.Writer
+	loopCount int
+	optimize  bool
+	config    image.Config
+	nbframes  int
+	previous  *image.Paletted
+}
+
+// methods
+
+// write the given frame with the given delay
+func (stream *gifStream) write(img *image.Paletted, delay int) error {
+
+	if stream.nbframes == 0 {
+		stream.config = image.Config{Width: img.Bounds().Max.X, Height: img.Bounds().Max.Y}
+		if stream.optimize {
+			stream.config.ColorModel = img.Palette
+		}
+	}
+	frame := &gif.GIF{
+		Image:  []*image.Paletted{img},
+		Delay:  []int{delay},
+		Config: stream.config}
+	if stream.optimize {
+		if stream.previous != nil && stream.previous.Rect == img.Rect {
+			frame.Image[0] = withPalette(deltaFrame(stream.previous, img), stream.config.ColorModel.(color.Palette))
+		}
+		frame.Disposal = []byte{gif.DisposalNone}
+		stream.previous = img
+	}
+	var buffer bytes.Buffer
+	if err := gif.EncodeAll(&buffer, frame); err != nil {
+		return err
+	}
+	data := buffer.Bytes()
+	data = data[:len(data)-1]
+
+	// the header is written only once along with the global palette, if
+	// any, and it is followed by the extension which repeats the animation,
+	// if requested
+	length := gifHeaderLength
+	if packed := data[10]; packed&0x80 != 0 {
+		length += 3 << ((packed & 0x07) + 1)
+	}
+	if stream.nbframes == 0 {
+		if _, err := stream.w.Write(data[:length]); err != nil {
+			return err
+		}
+		if stream.loopCount >= 0 {
+			extension := []byte{0x21, 0xff, 0x0b, 'N', 'E', 'T', 'S', 'C', 'A', 'P', 'E', '2', '.', '0',
+				0x03, 0x01, byte(stream.loopCount), byte(stream.loopCount >> 8), 0x00}
+			if _, err := stream.w.Write(extension); err != nil {
+				return err
+			}
+		}
+	}
+	stream.nbframes++
+	_, err := stream.w.Write(data[length:])
+	return err
+}
+
+// terminate the animation, which must have at least one frame
+func (stream *gifStream) close() error {
+
+	if stream.nbframes == 0 {
+		return errors.New("There are no frames to write")
+	}
+	_, err := stream.w.Write([]byte{gifTrailer})
+	return err
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Run this game and write its GIF animation with the given options to the
+// given writer while it is simulated. Only those generations chosen by the
+// frame selector of this game (if any) are rendered. Generations simulated by
+// the dense engine which are no longer needed are released as soon as their
+// frame has been written, though the first and the last ones are always kept
+func (game *Conway) EncodeGIF(w io.Writer, opts GIFOptions) error {
+
+	stream := &gifStream{w: w, loopCount: opts.LoopCount, optimize: opts.Optimize}
+	emit := func(index int) error {
+		if game.selector != nil && !game.selector(index) {
+			return nil
+		}
+		delay := opts.Delay
+		if stream.nbframes == 0 {
+			delay = opts.Delay0
+		}
+		return stream.write(game.frame(index, opts.Average), delay)
+	}
+
+	// other engines are simulated first, and their frames are then written
+	// one at a time
+	if game.engine != DenseEngine {
+		game.Run()
+		for index := range game.generations {
+			if err := emit(index); err != nil {
+				return err
+			}
+		}
+		return stream.close()
+	}
+
+	// generations are needed for averaging frames, for drawing trails, for
+	// detecting stable games, and for selecting frames by metrics
+	window := max(opts.Average, game.trail, stableWindow, 1) + 1
+	err := game.runDense(func(index int) error {
+		if err := emit(index); err != nil {
+			return err
+		}
+		if index-window > 0 {
+			game.generations[index-window] = nil
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return stream.close()
+}
@@ -0,0 +1,128 @@
+// Generations can be exported as SVG images, so that they can be printed at any
+// resolution, e.g., posters of the final state of a run. Living cells are
+// drawn either as squares or as circles with their colour over the colour of
+// dead cells, and cells are given the size of the aspect ratio of the game
+
+package conway
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// SVGShape
+// ----------------------------------------------------------------------------
+
+// type
+
+// Living cells can be drawn either as squares, which are merged along rows so
+// that the image is as small as possible, or as circles
+type SVGShape int
+
+const (
+	SVGSquares SVGShape = iota
+	SVGCircles
+)
+
+// Functions
+
+// Return the shape with the given name, either "square" or "circle", along
+// with an error if it is not known
+func NewSVGShape(name string) (SVGShape, error) {
+
+	switch name {
+	case "square":
+		return SVGSquares, nil
+	case "circle":
+		return SVGCircles, nil
+	}
+	return SVGSquares, fmt.Errorf("Unknown shape of cells '%v'", name)
+}
+
+// return the given colour in the format #rrggbb
+func svgColor(c color.Color) string {
+
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Write this generation to the given writer as an SVG image where living cells
+// are drawn as squares
+func (g *generation) ExportSVG(w io.Writer) error {
+	return g.ExportSVGShape(w, SVGSquares)
+}
+
+// Write this generation to the given writer as an SVG image where living cells
+// are drawn with the given shape. Cells of the same colour are grouped, so
+// that every colour is written only once
+func (g *generation) ExportSVGShape(w io.Writer, shape SVGShape) error {
+
+	width, height := g.dimensions()
+	cw, ch := g.ratio.X, g.ratio.Y
+	palette := g.img.Palette
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%v\" height=\"%v\" viewBox=\"0 0 %v %v\" shape-rendering=\"crispEdges\">\n",
+		width*cw, height*ch, width*cw, height*ch)
+	fmt.Fprintf(&result, "<rect width=\"%v\" height=\"%v\" fill=\"%v\"/>\n", width*cw, height*ch, svgColor(palette[0]))
+
+	// cells are grouped by their colour
+	var elements [256]strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			index := g.ColorIndexAt(x, y)
+			if index == 0 {
+				continue
+			}
+			if shape == SVGCircles {
+				fmt.Fprintf(&elements[index], "<ellipse cx=\"%v\" cy=\"%v\" rx=\"%v\" ry=\"%v\"/>\n",
+					float64(x*cw)+float64(cw)/2, float64(y*ch)+float64(ch)/2, float64(cw)/2, float64(ch)/2)
+				continue
+			}
+
+			// consecutive cells of the same colour are drawn as a single
+			// rectangle
+			run := 1
+			for x+run < width && g.ColorIndexAt(x+run, y) == index {
+				run++
+			}
+			fmt.Fprintf(&elements[index], "<rect x=\"%v\" y=\"%v\" width=\"%v\" height=\"%v\"/>\n", x*cw, y*ch, run*cw, ch)
+			x += run - 1
+		}
+	}
+	for index := 1; index < len(palette) && index < len(elements); index++ {
+		if elements[index].Len() > 0 {
+			fmt.Fprintf(&result, "<g fill=\"%v\">\n%v</g>\n", svgColor(palette[index]), elements[index].String())
+		}
+	}
+	result.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, result.String())
+	return err
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Write the generation with the given index to the given writer as an SVG
+// image where living cells are drawn with the given shape, along with an error
+// if it has not been computed. This works with all engines
+func (game *Conway) ExportSVG(index int, w io.Writer, shape SVGShape) error {
+
+	if index < 0 || index >= game.nbgenerations || !game.computed(index) {
+		return fmt.Errorf("The generation %v has not been computed", index)
+	}
+	if game.engine == DenseEngine {
+		return game.generations[index].ExportSVGShape(w, shape)
+	}
+	return game.materialize(index).ExportSVGShape(w, shape)
+}
@@ -0,0 +1,150 @@
+// Generations seeded symmetric keep their symmetry forever under the rules of
+// the Conway's Game, as long as the board is symmetric too. The analyzer
+// reports the mirror and rotational symmetries of generations, and the
+// generation where every symmetry of the first one is broken, either by
+// boundary effects or by stochastic rules
+
+package conway
+
+import (
+	"image"
+	"strings"
+)
+
+// Symmetry
+// ----------------------------------------------------------------------------
+
+// type
+
+// Symmetries are given as a set of flags, where every flag stands for a
+// symmetry with respect to the center of the board
+type Symmetry int
+
+const (
+	// the board is the mirror image of itself across its vertical axis
+	MirrorX Symmetry = 1 << iota
+
+	// the board is the mirror image of itself across its horizontal axis
+	MirrorY
+
+	// the board is the mirror image of itself across its main diagonal.
+	// Only square boards can have this symmetry
+	MirrorDiagonal
+
+	// the board is the same after a half turn
+	Rotation180
+
+	// the board is the same after a quarter turn. Only square boards can
+	// have this symmetry
+	Rotation90
+)
+
+// Names of all symmetries in the same order of their flags
+var symmetryNames = []string{"mirror-x", "mirror-y", "mirror-diagonal", "rotation-180", "rotation-90"}
+
+// methods
+
+// Return the names of the symmetries of this set separated by commas, or
+// "none" if it is empty
+func (s Symmetry) String() string {
+
+	var names []string
+	for i, name := range symmetryNames {
+		if s&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// Return the individual symmetries of this set
+func (s Symmetry) Split() (result []Symmetry) {
+
+	for i := range symmetryNames {
+		if s&(1<<i) != 0 {
+			result = append(result, 1<<i)
+		}
+	}
+	return
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the symmetries of the given living cells with respect to the center
+// of a board with the given dimensions. Empty boards have all symmetries
+func Symmetries(alive map[image.Point]bool, width, height int) Symmetry {
+
+	transformations := map[Symmetry]func(p image.Point) image.Point{
+		MirrorX:     func(p image.Point) image.Point { return image.Point{X: width - 1 - p.X, Y: p.Y} },
+		MirrorY:     func(p image.Point) image.Point { return image.Point{X: p.X, Y: height - 1 - p.Y} },
+		Rotation180: func(p image.Point) image.Point { return image.Point{X: width - 1 - p.X, Y: height - 1 - p.Y} },
+	}
+	if width == height {
+		transformations[MirrorDiagonal] = func(p image.Point) image.Point { return image.Point{X: p.Y, Y: p.X} }
+		transformations[Rotation90] = func(p image.Point) image.Point { return image.Point{X: width - 1 - p.Y, Y: p.X} }
+	}
+
+	var result Symmetry
+	for symmetry, transformation := range transformations {
+		symmetric := true
+		for p := range alive {
+			if !alive[transformation(p)] {
+				symmetric = false
+				break
+			}
+		}
+		if symmetric {
+			result |= symmetry
+		}
+	}
+	return result
+}
+
+// Recording
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return the symmetries of the generation with the given index, or an error if
+// it was not recorded
+func (r *Recording) Symmetries(index int) (Symmetry, error) {
+
+	alive, err := r.Alive(index)
+	if err != nil {
+		return 0, err
+	}
+	return Symmetries(alive, r.Width, r.Height), nil
+}
+
+// Return the symmetries of the first generation recorded along with the index
+// of the first generation where every one of them is broken. Symmetries which
+// are never broken are not present in the map
+func (r *Recording) SymmetryBreaks() (initial Symmetry, breaks map[Symmetry]int) {
+
+	breaks = make(map[Symmetry]int)
+	alive := make(map[image.Point]bool)
+	for i, d := range r.deltas {
+		for _, p := range d.died {
+			delete(alive, p)
+		}
+		for _, p := range d.born {
+			alive[p] = true
+		}
+
+		current := Symmetries(alive, r.Width, r.Height)
+		if i == 0 {
+			initial = current
+			continue
+		}
+		for _, symmetry := range initial.Split() {
+			if _, ok := breaks[symmetry]; !ok && current&symmetry == 0 {
+				breaks[symmetry] = d.index
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,121 @@
+// Generations of very large boards can be cut into tiles at multiple
+// resolutions, following the layout of slippy maps, so that web viewers can
+// pan and zoom them: the whole frame fits in a single tile at zoom level 0,
+// and every following level doubles the resolution up to the last one, where
+// frames are shown at their full resolution. Every level is computed from the
+// next one by downscaling blocks of 2x2 pixels, which preserves the palette
+
+package conway
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+// Tile
+// ----------------------------------------------------------------------------
+
+// type
+
+// A tile is the square image located at column X and row Y of the given Zoom
+// level. Tiles at the right and bottom edges are padded with the colour of
+// dead cells
+type Tile struct {
+	Zoom, X, Y int
+	Image      *image.Paletted
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return a copy of the given image downscaled by half, where every block of
+// 2x2 pixels is mapped to a single pixel with the given policy
+func halve(img *image.Paletted, policy DownscalePolicy) *image.Paletted {
+
+	bounds := img.Bounds()
+	result := image.NewPaletted(image.Rect(0, 0, (bounds.Dx()+1)/2, (bounds.Dy()+1)/2), img.Palette)
+	var counts [256]int
+	for y := 0; y < result.Rect.Dy(); y++ {
+		for x := 0; x < result.Rect.Dx(); x++ {
+
+			// count the colours of the living pixels of this block, which
+			// might be smaller at the edges of the image
+			block := image.Rect(2*x, 2*y, 2*x+2, 2*y+2).Add(bounds.Min).Intersect(bounds)
+			alive, colour := 0, uint8(0)
+			for py := block.Min.Y; py < block.Max.Y; py++ {
+				for px := block.Min.X; px < block.Max.X; px++ {
+					if c := img.ColorIndexAt(px, py); c != 0 {
+						alive++
+						if counts[c]++; counts[c] > counts[colour] {
+							colour = c
+						}
+					}
+				}
+			}
+			if alive > 0 {
+				counts = [256]int{}
+			}
+			if policy.shows(alive, block.Dx()*block.Dy()) {
+				result.Pix[y*result.Stride+x] = colour
+			}
+		}
+	}
+	return result
+}
+
+// Cut the given image into square tiles of the given size at all zoom levels,
+// and return the last one, where the image is shown at its full resolution.
+// Lower levels are downscaled with the given policy. Every tile is given to
+// emit, starting with the last level, and tiles which fall completely off
+// the image are not emitted. In case emit fails, its error is returned
+func Tiles(img *image.Paletted, size int, policy DownscalePolicy, emit func(Tile) error) (int, error) {
+
+	if size < 1 {
+		return 0, errors.New("The size of tiles must be strictly positive")
+	}
+
+	// compute the last zoom level, where the image fits in as many tiles as
+	// needed at its full resolution
+	zoom := 0
+	for size<<zoom < max(img.Rect.Dx(), img.Rect.Dy()) {
+		zoom++
+	}
+
+	level := img
+	for z := zoom; z >= 0; z-- {
+		bounds := level.Bounds()
+		for y := 0; y*size < bounds.Dy(); y++ {
+			for x := 0; x*size < bounds.Dx(); x++ {
+				tile := image.NewPaletted(image.Rect(0, 0, size, size), level.Palette)
+				for py := 0; py < size && y*size+py < bounds.Dy(); py++ {
+					start := level.PixOffset(bounds.Min.X+x*size, bounds.Min.Y+y*size+py)
+					copy(tile.Pix[py*tile.Stride:py*tile.Stride+min(size, bounds.Dx()-x*size)], level.Pix[start:])
+				}
+				if err := emit(Tile{Zoom: z, X: x, Y: y, Image: tile}); err != nil {
+					return zoom, err
+				}
+			}
+		}
+		if z > 0 {
+			level = halve(level, policy)
+		}
+	}
+	return zoom, nil
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Cut the frame of the generation with the given index into square tiles of
+// the given size at all zoom levels (see Tiles), and return the last one. An
+// error is returned if the generation has not been computed
+func (game *Conway) Tiles(index, size int, policy DownscalePolicy, emit func(Tile) error) (int, error) {
+
+	if index < 0 || index >= game.nbgenerations || !game.computed(index) {
+		return 0, fmt.Errorf("The generation %v has not been computed", index)
+	}
+	return Tiles(game.frame(index, 1), size, policy, emit)
+}
@@ -0,0 +1,67 @@
+// Cells which have just died can leave a trail behind them: they are drawn
+// with the colour they had when they were alive, dimmed towards the colour of
+// dead cells over a number of generations, so that gliders and other
+// spaceships look like comets. As with effects, dimmed colours are added to
+// the palette while there is room for them. Trails are drawn over the pixels
+// of previous frames, so that they follow cells only when frames show the
+// same region in all generations
+
+package conway
+
+import (
+	"errors"
+	"image"
+)
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the number of generations the trail of cells which have died lasts, so
+// that 0 means that no trail is drawn
+func (game *Conway) SetTrail(generations int) error {
+
+	if generations < 0 {
+		return errors.New("The length of trails can not be negative")
+	}
+	game.trail = generations
+	return nil
+}
+
+// return a copy of the given frame of the generation with the given index
+// where the pixels of cells which died in the last generations of the trail
+// are given their last colour dimmed towards the colour of dead cells. If no
+// trail has been requested, the same image is returned
+func (game *Conway) drawTrail(index, average int, img *image.Paletted) *image.Paletted {
+
+	if game.trail == 0 || index == 0 {
+		return img
+	}
+
+	// the last frames are rendered again, starting with the most recent one,
+	// so that every pixel takes the colour of the last living cell drawn on it
+	s := newShader(img.Palette)
+	pix := make([]uint8, len(img.Pix))
+	copy(pix, img.Pix)
+	pending := make([]bool, len(img.Pix))
+	for i, c := range img.Pix {
+		pending[i] = c == 0
+	}
+	for k := 1; k <= game.trail && index-k >= 0; k++ {
+		if !game.computed(index - k) {
+			break
+		}
+		previous := game.uncropped(index-k, average)
+		if previous.Rect != img.Rect {
+			break
+		}
+		level := quantize(float64(k) / float64(game.trail+1))
+		for i, c := range previous.Pix {
+			if pending[i] && c != 0 {
+				pix[i], pending[i] = s.blend(c, 0, level), false
+			}
+		}
+	}
+	return &image.Paletted{Pix: pix, Stride: img.Stride, Rect: img.Rect, Palette: s.palette}
+}
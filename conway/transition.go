@@ -0,0 +1,51 @@
+// Library users can plug their own transition function into Life-like
+// automata, so that arbitrary rules can be simulated without modifying this
+// package. The transition function is given the state of every cell (i.e.,
+// its colour index) along with the number of living neighbours, and it
+// returns its state in the next generation
+
+package conway
+
+import "errors"
+
+// Rule
+// ----------------------------------------------------------------------------
+
+// type
+
+// A transition rule returns the state of a cell in the next generation given
+// whether it is alive, its number of living neighbours and its current state.
+// States are colour indexes of the palette, and cells with state 0 are dead
+type Rule func(alive bool, neighbors int, state uint8) uint8
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the transition rule of this generation, which takes precedence over its
+// Life-like rule and its colour model, since the states returned are directly
+// used as colour indexes. Only the Conway's Game and other Life-like rules can
+// be given a transition rule
+func (g *generation) SetTransitionRule(rule Rule) error {
+
+	if !g.isLife() || g.species != nil {
+		return errors.New("Only Life-like automata can be given a transition rule")
+	}
+	g.transition = rule
+	return nil
+}
+
+// return the next generation computed with the transition rule of this
+// generation
+func (g *generation) nextTransition() *generation {
+
+	next := g.empty(1 + g.nbgeneration)
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			state := g.ColorIndexAt(x, y)
+			next.SetColorIndex(x, y, g.transition(state != 0, g.nbalive(x, y), state))
+		}
+	}
+	return next
+}
@@ -0,0 +1,138 @@
+// Besides the square grid, Life-like rules can be simulated over a triangular
+// lattice where cells are alternately triangles pointing up and down. Cells
+// are either neighbours of the 12 triangles sharing an edge or a vertex with
+// them, or only of the 3 triangles sharing an edge. The orientation of every
+// cell is given by its location: triangles pointing up are those whose
+// coordinates, relative to the origin of the board, add up to an even number
+
+package conway
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// Topology
+// ----------------------------------------------------------------------------
+
+// type
+
+// The topology of the grid decides the shape and the neighbours of cells
+type Topology int
+
+const (
+	// square cells with the 8 cells around them as neighbours
+	SquareTopology Topology = iota
+
+	// triangular cells with the 12 triangles sharing an edge or a vertex with
+	// them as neighbours
+	TriangularTopology
+
+	// triangular cells with the 3 triangles sharing an edge with them as
+	// neighbours
+	TriangularEdgeTopology
+)
+
+// Offsets of the neighbours of triangles pointing up. Those of triangles
+// pointing down are their vertical reflection
+var triangularOffsets = map[Topology][]image.Point{
+	TriangularTopology: {
+		{X: -1, Y: -1}, {X: 0, Y: -1}, {X: 1, Y: -1},
+		{X: -2, Y: 0}, {X: -1, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0},
+		{X: -2, Y: 1}, {X: -1, Y: 1}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 2, Y: 1}},
+	TriangularEdgeTopology: {
+		{X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}},
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Set the topology of this generation, which is shared with all the following
+// generations. Only the Conway's Game and other Life-like rules can be
+// simulated over triangular lattices, and their rules must be totalistic
+func (g *generation) SetTopology(topology Topology) error {
+
+	if topology != SquareTopology && !g.isLife() {
+		return errors.New("Only Life-like rules can be simulated over a triangular lattice")
+	}
+	if topology != SquareTopology && g.rule != nil && g.rule.NonTotalistic() {
+		return errors.New("Non-totalistic rules can only be simulated over a square grid")
+	}
+	g.topology = topology
+	return nil
+}
+
+// return whether the cell at location (x, y) of a triangular lattice points up
+func (g *generation) pointsUp(x, y int) bool {
+	return (x-g.origin.X+y-g.origin.Y)&1 == 0
+}
+
+// return the neighbourhood of living cells around the given position of a
+// triangular lattice as a mask where every neighbour is given a separate bit
+// in the same order of triangularOffsets
+func (g *generation) triangularNeighbourhood(x, y int) (result int) {
+
+	direction := 1
+	if !g.pointsUp(x, y) {
+		direction = -1
+	}
+	for i, offset := range triangularOffsets[g.topology] {
+		if p, ok := g.locate(x+offset.X, y+direction*offset.Y); (ok && g.ColorIndexAt(p.X, p.Y) != 0) || (!ok && g.background) {
+			result |= 1 << i
+		}
+	}
+	return
+}
+
+// return a copy of the given image of this generation where every cell is
+// drawn as a triangle. Every triangle spans the block of its cell and half of
+// the blocks of its neighbours to the left and right, so that pixels in the
+// corners of every block are given the colour of the neighbour they belong to
+func (g *generation) drawTriangles(img *image.Paletted) *image.Paletted {
+
+	result := image.NewPaletted(img.Rect, img.Palette)
+	for px := img.Rect.Min.X; px < img.Rect.Max.X; px++ {
+		for py := img.Rect.Min.Y; py < img.Rect.Max.Y; py++ {
+			x, y := px/g.ratio.X, py/g.ratio.Y
+
+			// compute the location of this pixel within its block, and the
+			// half width of the triangle of its cell at its height
+			u := (float64(px%g.ratio.X)+0.5)/float64(g.ratio.X) - 0.5
+			v := (float64(py%g.ratio.Y) + 0.5) / float64(g.ratio.Y)
+			if !g.pointsUp(x, y) {
+				v = 1 - v
+			}
+
+			// pixels off the triangle belong to the neighbour next to them
+			if math.Abs(u) > v {
+				if u < 0 {
+					x--
+				} else {
+					x++
+				}
+			}
+			if p := image.Pt(x*g.ratio.X, y*g.ratio.Y); p.In(img.Rect) {
+				result.SetColorIndex(px, py, img.ColorIndexAt(p.X, p.Y))
+			}
+		}
+	}
+	return result
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// return the given image of the generation with the given index with its
+// cells drawn with the shape given by the topology of this game
+func (game *Conway) shape(index int, img *image.Paletted) *image.Paletted {
+
+	if game.generations[0].topology == SquareTopology {
+		return img
+	}
+	return game.generations[index].drawTriangles(img)
+}
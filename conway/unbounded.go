@@ -0,0 +1,164 @@
+// Unbounded games are simulated over an infinite board: the region simulated
+// grows automatically whenever living cells approach its boundary, so that
+// patterns like gliders never smash into the edges. Frames keep the
+// dimensions of the original board and they show either the whole region
+// simulated scaled to fit, the original board as a fixed viewport or the
+// region followed by a camera
+
+package conway
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Minimum distance (in cells) between living cells and the boundary of the
+// region simulated in unbounded games. If any living cell gets closer, the
+// region is expanded
+const unboundedMargin = 2
+
+// View
+// ----------------------------------------------------------------------------
+
+// type
+
+// The view of an unbounded game decides how the region simulated is rendered
+// in frames with the dimensions of the original board
+type View int
+
+const (
+	// the whole region simulated is scaled down to fit in the frame
+	ScaleToFit View = iota
+
+	// only the original board is shown
+	Viewport
+
+	// the region around the living cells is shown, as filmed by the camera
+	// of the game
+	Tracking
+)
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// return this generation if all living cells are far enough from its
+// boundary, or a larger copy of it otherwise. The region is expanded only in
+// those directions where living cells are too close to the boundary, and it
+// grows at least a quarter of its size so that expansions are not frequent
+func (g *generation) expand() *generation {
+
+	// compute the bounding box of all living cells. Note that the last row and
+	// column of cells lie outside the image and thus, they are always dead
+	width, height := g.img.Rect.Max.X/g.ratio.X, g.img.Rect.Max.Y/g.ratio.Y
+	box := image.Rectangle{Min: image.Point{X: width, Y: height}}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if g.ColorIndexAt(x, y) != 0 {
+				box = box.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if box.Empty() {
+		return g
+	}
+
+	// compute the number of cells to add in every direction
+	var left, top, right, bottom int
+	if box.Min.X < unboundedMargin {
+		left = max(unboundedMargin, width/4)
+	}
+	if box.Min.Y < unboundedMargin {
+		top = max(unboundedMargin, height/4)
+	}
+	if box.Max.X > width-unboundedMargin {
+		right = max(unboundedMargin, width/4)
+	}
+	if box.Max.Y > height-unboundedMargin {
+		bottom = max(unboundedMargin, height/4)
+	}
+	if left == 0 && top == 0 && right == 0 && bottom == 0 {
+		return g
+	}
+
+	// and create the new generation
+	return g.pad(left, top, right, bottom)
+}
+
+// Conway
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Make this game unbounded, so that it is simulated over an infinite board
+// rendered with the given view. Only Life-like automata (including the
+// multi-colour variants) can be unbounded
+func (game *Conway) SetUnbounded(view View) error {
+
+	if g := game.generations[0]; !g.isLife() && g.species == nil {
+		return errors.New("Only Life-like automata can be simulated over an infinite board")
+	}
+	if game.generations[0].boundary != DeadBoundary {
+		return errors.New("Infinite boards have no boundary")
+	}
+	if game.halo > 0 {
+		return errors.New("Infinite boards can not be padded with a halo")
+	}
+	if game.generations[0].topology != SquareTopology {
+		return errors.New("Infinite boards can only be square grids")
+	}
+	if game.strobes() {
+		return errors.New("Rules with B0 can not be simulated over an infinite board")
+	}
+	game.unbounded, game.view = true, view
+	return nil
+}
+
+// return the paletted image of the given generation of an unbounded game with
+// the dimensions of the original board, according to the view of this game
+func (game *Conway) render(g *generation) *image.Paletted {
+
+	img := image.NewPaletted(image.Rect(0, 0, game.width*g.ratio.X, game.height*g.ratio.Y), g.img.Palette)
+
+	// the color of every cell of the frame is given by the color of a single
+	// cell (in the viewport) or the highest color index of all cells scaled
+	// down to it, so that living cells are always shown. Cells filmed by a
+	// camera are magnified when the scale is less than one
+	width, height := g.img.Rect.Max.X/g.ratio.X, g.img.Rect.Max.Y/g.ratio.Y
+	scale := max(float64(width)/float64(game.width), float64(height)/float64(game.height), 1)
+	xoffset := (float64(game.width)*scale - float64(width)) / 2
+	yoffset := (float64(game.height)*scale - float64(height)) / 2
+	if game.view == Tracking {
+		var x, y float64
+		x, y, scale = game.camera.frame(g, game.width, game.height)
+		xoffset, yoffset = float64(game.width)*scale/2-x, float64(game.height)*scale/2-y
+	}
+	for x := 0; x < game.width; x++ {
+		for y := 0; y < game.height; y++ {
+			var c uint8
+			if game.view == Viewport {
+				c = g.ColorIndexAt(g.origin.X+x, g.origin.Y+y)
+			} else {
+				xmin, xmax := int(math.Floor(float64(x)*scale-xoffset)), int(math.Floor(float64(x+1)*scale-xoffset))
+				ymin, ymax := int(math.Floor(float64(y)*scale-yoffset)), int(math.Floor(float64(y+1)*scale-yoffset))
+				for xs := max(0, xmin); xs < max(xmin+1, xmax) && xs < width; xs++ {
+					for ys := max(0, ymin); ys < max(ymin+1, ymax) && ys < height; ys++ {
+						c = max(c, g.ColorIndexAt(xs, ys))
+					}
+				}
+			}
+			for xpixel := 0; xpixel < g.ratio.X; xpixel++ {
+				for ypixel := 0; ypixel < g.ratio.Y; ypixel++ {
+					img.SetColorIndex(x*g.ratio.X+xpixel, y*g.ratio.Y+ypixel, c)
+				}
+			}
+		}
+	}
+
+	return img
+}
@@ -0,0 +1,536 @@
+// Animations can also be written as animated WebP files, which compress far
+// better than GIF files the flat colours of boards and support full colour.
+// Every frame is composited as GIF viewers do (see ComposeGIF) and the area
+// that changed is encoded with the lossless format of WebP (VP8L), where runs
+// of identical pixels, both along rows and with regard to the row above, are
+// given as backward references and the few colours used are kept in a colour
+// cache, so that large areas of dead cells take almost no space
+
+package conway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"io"
+	"sort"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// Largest width and height of WebP images
+const MaxWebPDimension = 16384
+
+// number of length prefix codes of VP8L, which extend the alphabet of green
+const webpLengthCodes = 24
+
+// number of distance prefix codes of VP8L
+const webpDistanceCodes = 40
+
+// longest backward reference of VP8L
+const webpMaxLength = 4096
+
+// shortest backward reference worth coding
+const webpMinLength = 3
+
+// number of bits of the index of the colour cache, which holds the colours
+// recently seen so that they are given with a single symbol
+const webpCacheBits = 8
+
+// longest code of prefix codes and of the code used for their lengths
+const (
+	webpMaxCodeLength       = 15
+	webpMaxLengthCodeLength = 7
+)
+
+// order in which the lengths of the code used for code lengths are given
+var webpCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// Bit writer
+// ----------------------------------------------------------------------------
+
+// type
+
+// bits are written starting from the least significant one of every byte
+type webpBitWriter struct {
+	buffer []byte
+	bits   uint64
+	nbits  uint
+}
+
+// methods
+
+// write the n least significant bits of the given value
+func (w *webpBitWriter) write(value uint32, n uint) {
+
+	w.bits |= uint64(value&(1<<n-1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buffer = append(w.buffer, byte(w.bits))
+		w.bits >>= 8
+		w.nbits -= 8
+	}
+}
+
+// return all bits written so far, padded with zeros to a whole byte
+func (w *webpBitWriter) bytes() []byte {
+
+	if w.nbits > 0 {
+		return append(w.buffer, byte(w.bits))
+	}
+	return w.buffer
+}
+
+// Prefix codes
+// ----------------------------------------------------------------------------
+
+// type
+
+// a prefix code is given by the length and code of every symbol, which are
+// stored bit-reversed so that they can be written directly. Codes with a single
+// symbol take no bits at all
+type webpCode struct {
+	lengths []int
+	codes   []uint32
+	single  bool
+}
+
+// Functions
+
+// return the lengths of the Huffman code of the given frequencies, none longer
+// than the given limit. Long codes are avoided by raising the lowest
+// frequencies until the code fits
+func webpCodeLengths(frequencies []int, limit int) []int {
+
+	lengths := make([]int, len(frequencies))
+	for minimum := 1; ; minimum *= 2 {
+
+		// leaves are merged in increasing order of frequency
+		type node struct {
+			weight      int
+			left, right int
+		}
+		var nodes []node
+		var queue []int
+		for symbol, frequency := range frequencies {
+			if frequency > 0 {
+				nodes = append(nodes, node{weight: max(frequency, minimum), left: -1, right: symbol})
+				queue = append(queue, len(nodes)-1)
+			}
+		}
+		if len(queue) < 2 {
+			if len(queue) == 1 {
+				lengths[nodes[0].right] = 1
+			}
+			return lengths
+		}
+		for len(queue) > 1 {
+			sort.SliceStable(queue, func(i, j int) bool { return nodes[queue[i]].weight < nodes[queue[j]].weight })
+			nodes = append(nodes, node{weight: nodes[queue[0]].weight + nodes[queue[1]].weight, left: queue[0], right: queue[1]})
+			queue = append(queue[2:], len(nodes)-1)
+		}
+
+		// and the length of every leaf is its depth
+		clear(lengths)
+		longest := 0
+		var visit func(index, depth int)
+		visit = func(index, depth int) {
+			if nodes[index].left < 0 {
+				lengths[nodes[index].right] = depth
+				longest = max(longest, depth)
+				return
+			}
+			visit(nodes[index].left, depth+1)
+			visit(nodes[index].right, depth+1)
+		}
+		visit(queue[0], 0)
+		if longest <= limit {
+			return lengths
+		}
+	}
+}
+
+// return the canonical prefix code with the given lengths
+func newWebPCode(lengths []int) webpCode {
+
+	code := webpCode{lengths: lengths, codes: make([]uint32, len(lengths))}
+	var counts [webpMaxCodeLength + 1]int
+	used := 0
+	for _, length := range lengths {
+		if length > 0 {
+			counts[length]++
+			used++
+		}
+	}
+	code.single = used <= 1
+
+	var next [webpMaxCodeLength + 2]uint32
+	for length := 1; length <= webpMaxCodeLength; length++ {
+		next[length+1] = (next[length] + uint32(counts[length])) << 1
+	}
+	for symbol, length := range lengths {
+		if length > 0 {
+			value := next[length]
+			next[length]++
+
+			// codes are read from the most significant bit
+			var reversed uint32
+			for i := 0; i < length; i++ {
+				reversed |= (value >> i & 1) << (length - 1 - i)
+			}
+			code.codes[symbol] = reversed
+		}
+	}
+	return code
+}
+
+// methods
+
+// write the given symbol with this code
+func (code *webpCode) writeSymbol(w *webpBitWriter, symbol int) {
+
+	if !code.single {
+		w.write(code.codes[symbol], uint(code.lengths[symbol]))
+	}
+}
+
+// write the description of this code, either as a simple code if it has at
+// most one symbol or as a normal code otherwise
+func (code *webpCode) writeCode(w *webpBitWriter) {
+
+	symbol := -1
+	for s, length := range code.lengths {
+		if length > 0 {
+			symbol = s
+			break
+		}
+	}
+	if code.single && symbol < 256 {
+		w.write(1, 1)
+		w.write(0, 1)
+		if symbol <= 1 {
+			w.write(0, 1)
+			w.write(uint32(max(symbol, 0)), 1)
+		} else {
+			w.write(1, 1)
+			w.write(uint32(symbol), 8)
+		}
+		return
+	}
+
+	// the lengths are given as tokens, where runs of zeros are given with the
+	// symbols 17 (3-10 zeros) and 18 (11-138 zeros)
+	type token struct {
+		symbol, extra int
+	}
+	var tokens []token
+	for i := 0; i < len(code.lengths); {
+		if code.lengths[i] != 0 {
+			tokens = append(tokens, token{symbol: code.lengths[i]})
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(code.lengths) && code.lengths[i+run] == 0 {
+			run++
+		}
+		i += run
+		for run > 0 {
+			switch {
+			case run >= 11:
+				n := min(run, 138)
+				tokens = append(tokens, token{18, n - 11})
+				run -= n
+			case run >= 3:
+				tokens = append(tokens, token{17, run - 3})
+				run = 0
+			default:
+				tokens = append(tokens, token{symbol: 0})
+				run--
+			}
+		}
+	}
+
+	frequencies := make([]int, 19)
+	for _, t := range tokens {
+		frequencies[t.symbol]++
+	}
+	lengthCode := newWebPCode(webpCodeLengths(frequencies, webpMaxLengthCodeLength))
+	nblengths := 19
+	for nblengths > 4 && lengthCode.lengths[webpCodeLengthOrder[nblengths-1]] == 0 {
+		nblengths--
+	}
+
+	w.write(0, 1)
+	w.write(uint32(nblengths-4), 4)
+	for _, s := range webpCodeLengthOrder[:nblengths] {
+		w.write(uint32(lengthCode.lengths[s]), 3)
+	}
+	w.write(0, 1)
+	for _, t := range tokens {
+		lengthCode.writeSymbol(w, t.symbol)
+		switch t.symbol {
+		case 17:
+			w.write(uint32(t.extra), 3)
+		case 18:
+			w.write(uint32(t.extra), 7)
+		}
+	}
+}
+
+// VP8L
+// ----------------------------------------------------------------------------
+
+// Functions
+
+// return the prefix code and the extra bits of the given length or distance
+// code, which is strictly positive
+func webpPrefix(value int) (prefix, nbextra, extra int) {
+
+	value--
+	if value < 4 {
+		return value, 0, 0
+	}
+	highest := 0
+	for v := value; v > 1; v >>= 1 {
+		highest++
+	}
+	second := value >> (highest - 1) & 1
+	nbextra = highest - 1
+	return 2*highest + second, nbextra, value & (1<<nbextra - 1)
+}
+
+// return the given image encoded in the lossless format of WebP, without the
+// header of its chunk
+func encodeVP8L(img *image.NRGBA) []byte {
+
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	pixels := make([]uint32, width*height)
+	alpha := false
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := img.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+			r, g, b, a := img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]
+			pixels[y*width+x] = uint32(a)<<24 | uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+			alpha = alpha || a != 0xff
+		}
+	}
+
+	// pixels are given either as literals, as indices of the colour cache or
+	// as backward references to the previous pixel or to the pixel above,
+	// whichever is longer. All pixels are stored in the colour cache
+	type symbol struct {
+		pixel               uint32
+		length, code, cache int
+	}
+	var symbols []symbol
+	var cache [1 << webpCacheBits]uint32
+	store := func(pixel uint32) int {
+		key := int((0x1e35a7bd * pixel) >> (32 - webpCacheBits))
+		if cache[key] == pixel {
+			return key
+		}
+		cache[key] = pixel
+		return -1
+	}
+	matches := func(i, distance int) int {
+		length := 0
+		for i+length < len(pixels) && length < webpMaxLength && pixels[i+length] == pixels[i+length-distance] {
+			length++
+		}
+		return length
+	}
+	for i := 0; i < len(pixels); {
+		best, code := 0, 0
+		for _, candidate := range []struct{ distance, code int }{{1, 2}, {width, 1}} {
+			if i >= candidate.distance {
+				if length := matches(i, candidate.distance); length > best {
+					best, code = length, candidate.code
+				}
+			}
+		}
+		if best >= webpMinLength {
+			symbols = append(symbols, symbol{length: best, code: code})
+			for _, pixel := range pixels[i : i+best] {
+				store(pixel)
+			}
+			i += best
+		} else {
+			symbols = append(symbols, symbol{pixel: pixels[i], cache: store(pixels[i])})
+			i++
+		}
+	}
+
+	// compute the prefix codes of green (along with lengths), red, blue,
+	// alpha and distances
+	frequencies := [5][]int{
+		make([]int, 256+webpLengthCodes+1<<webpCacheBits), make([]int, 256), make([]int, 256), make([]int, 256),
+		make([]int, webpDistanceCodes)}
+	for _, s := range symbols {
+		if s.length > 0 {
+			prefix, _, _ := webpPrefix(s.length)
+			frequencies[0][256+prefix]++
+			prefix, _, _ = webpPrefix(s.code)
+			frequencies[4][prefix]++
+			continue
+		}
+		if s.cache >= 0 {
+			frequencies[0][256+webpLengthCodes+s.cache]++
+			continue
+		}
+		frequencies[0][s.pixel>>8&0xff]++
+		frequencies[1][s.pixel>>16&0xff]++
+		frequencies[2][s.pixel&0xff]++
+		frequencies[3][s.pixel>>24]++
+	}
+	var codes [5]webpCode
+	for i := range codes {
+		codes[i] = newWebPCode(webpCodeLengths(frequencies[i], webpMaxCodeLength))
+	}
+
+	// the header is followed by no transforms, the size of the colour cache,
+	// a single group of prefix codes and the pixels
+	w := &webpBitWriter{}
+	w.write(0x2f, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	if alpha {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+	w.write(0, 3)
+	w.write(0, 1)
+	w.write(1, 1)
+	w.write(webpCacheBits, 4)
+	w.write(0, 1)
+	for i := range codes {
+		codes[i].writeCode(w)
+	}
+	for _, s := range symbols {
+		if s.length > 0 {
+			prefix, nbextra, extra := webpPrefix(s.length)
+			codes[0].writeSymbol(w, 256+prefix)
+			w.write(uint32(extra), uint(nbextra))
+			prefix, nbextra, extra = webpPrefix(s.code)
+			codes[4].writeSymbol(w, prefix)
+			w.write(uint32(extra), uint(nbextra))
+			continue
+		}
+		if s.cache >= 0 {
+			codes[0].writeSymbol(w, 256+webpLengthCodes+s.cache)
+			continue
+		}
+		codes[0].writeSymbol(w, int(s.pixel>>8&0xff))
+		codes[1].writeSymbol(w, int(s.pixel>>16&0xff))
+		codes[2].writeSymbol(w, int(s.pixel&0xff))
+		codes[3].writeSymbol(w, int(s.pixel>>24))
+	}
+	return w.bytes()
+}
+
+// return the smallest rectangle which contains all pixels that differ in the
+// given images, which have the same bounds
+func webpChangedArea(previous, current *image.NRGBA) image.Rectangle {
+
+	var area image.Rectangle
+	for y := current.Rect.Min.Y; y < current.Rect.Max.Y; y++ {
+		for x := current.Rect.Min.X; x < current.Rect.Max.X; x++ {
+			i := current.PixOffset(x, y)
+			if !bytes.Equal(previous.Pix[i:i+4], current.Pix[i:i+4]) {
+				area = area.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return area
+}
+
+// append a chunk of a RIFF file with the given type and data to the given
+// buffer. Chunks are padded to an even number of bytes
+func appendRIFFChunk(buffer []byte, kind string, data []byte) []byte {
+
+	buffer = append(buffer, kind...)
+	buffer = binary.LittleEndian.AppendUint32(buffer, uint32(len(data)))
+	buffer = append(buffer, data...)
+	if len(data)%2 == 1 {
+		buffer = append(buffer, 0)
+	}
+	return buffer
+}
+
+// append the given value to the given buffer in 24 bits
+func appendUint24(buffer []byte, value int) []byte {
+	return append(buffer, byte(value), byte(value>>8), byte(value>>16))
+}
+
+// Write the given animation to the given writer as an animated WebP, with the
+// same delays and number of loops. Frames are composited with ComposeGIF and
+// only the area that changes in every frame is written, losslessly. An error is
+// returned if the animation is larger than MaxWebPDimension
+func EncodeWebP(w io.Writer, anim *gif.GIF) error {
+
+	if len(anim.Image) == 0 {
+		return errors.New("Animations must have at least one frame")
+	}
+	width, height := composedDimensions(anim)
+	if width > MaxWebPDimension || height > MaxWebPDimension {
+		return fmt.Errorf("The animation (%vx%v) exceeds the maximum dimension of WebP files (%v)", width, height, MaxWebPDimension)
+	}
+
+	// the canvas may have transparent areas, and it is animated
+	vp8x := []byte{0x10 | 0x02, 0, 0, 0}
+	vp8x = appendUint24(appendUint24(vp8x, width-1), height-1)
+
+	// GIF files loop forever with 0 and play once with -1, whereas WebP files
+	// loop forever with 0 and play as many times as requested
+	loops := 0
+	if anim.LoopCount < 0 {
+		loops = 1
+	} else if anim.LoopCount > 0 {
+		loops = 1 + anim.LoopCount
+	}
+	animation := binary.LittleEndian.AppendUint32(nil, 0)
+	animation = binary.LittleEndian.AppendUint16(animation, uint16(min(loops, 0xffff)))
+
+	contents := appendRIFFChunk(appendRIFFChunk([]byte("WEBP"), "VP8X", vp8x), "ANIM", animation)
+	previous := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if err := ComposeGIF(anim, func(index int, img *image.NRGBA) error {
+
+		// only the area which changed since the previous frame is written,
+		// and its origin has to be at even coordinates
+		area := image.Rect(0, 0, 1, 1)
+		if index == 0 {
+			area = img.Rect
+		} else if changed := webpChangedArea(previous, img); !changed.Empty() {
+			area = image.Rect(changed.Min.X&^1, changed.Min.Y&^1, changed.Max.X, changed.Max.Y)
+		}
+		copy(previous.Pix, img.Pix)
+
+		// durations are given in milliseconds, and frames are neither
+		// blended nor disposed
+		var delay int
+		if index < len(anim.Delay) {
+			delay = anim.Delay[index]
+		}
+		frame := appendUint24(appendUint24(nil, area.Min.X/2), area.Min.Y/2)
+		frame = appendUint24(appendUint24(frame, area.Dx()-1), area.Dy()-1)
+		frame = appendUint24(frame, 10*delay)
+		frame = append(frame, 0x02)
+		frame = appendRIFFChunk(frame, "VP8L", encodeVP8L(img.SubImage(area).(*image.NRGBA)))
+		contents = appendRIFFChunk(contents, "ANMF", frame)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var riff bytes.Buffer
+	riff.WriteString("RIFF")
+	binary.Write(&riff, binary.LittleEndian, uint32(len(contents)))
+	riff.Write(contents)
+	_, err := riff.WriteTo(w)
+	return err
+}
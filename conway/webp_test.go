@@ -0,0 +1,490 @@
+package conway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"math/rand"
+	"testing"
+)
+
+// The animations written by EncodeWebP are verified by decoding them with the
+// decoder below, written after the specification of the WebP container and of
+// its lossless format. It acknowledges only what the encoder uses, i.e., VP8L
+// images without transforms nor meta prefix codes whose backward references
+// refer either to the previous pixel or to the pixel above, and any other
+// feature is reported as an error
+
+// bits are read starting from the least significant one of every byte
+type webpBitReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *webpBitReader) read(n int) int {
+
+	value := 0
+	for i := 0; i < n; i++ {
+		if r.pos>>3 >= len(r.data) {
+			r.err = errors.New("unexpected end of data")
+			return 0
+		}
+		value |= int(r.data[r.pos>>3]>>(r.pos&7)&1) << i
+		r.pos++
+	}
+	return value
+}
+
+// a prefix code maps the length and the value of every code to its symbol.
+// Codes with a single symbol take no bits at all
+type webpDecoder struct {
+	symbols map[[2]int]int
+	single  int
+}
+
+// return the canonical prefix code with the given lengths, which must be
+// complete unless there is a single symbol
+func newWebPDecoder(lengths []int) (webpDecoder, error) {
+
+	decoder := webpDecoder{symbols: make(map[[2]int]int), single: -1}
+	var counts [webpMaxCodeLength + 1]int
+	used := 0
+	for symbol, length := range lengths {
+		if length > webpMaxCodeLength {
+			return decoder, fmt.Errorf("code of length %v", length)
+		}
+		if length > 0 {
+			counts[length]++
+			used++
+			decoder.single = symbol
+		}
+	}
+	if used == 0 {
+		return decoder, errors.New("empty code")
+	}
+	if used == 1 {
+		return decoder, nil
+	}
+	decoder.single = -1
+
+	// the code is complete if its leaves fill the tree
+	space := 1 << webpMaxCodeLength
+	for length := 1; length <= webpMaxCodeLength; length++ {
+		space -= counts[length] << (webpMaxCodeLength - length)
+	}
+	if space != 0 {
+		return decoder, errors.New("incomplete code")
+	}
+	var next [webpMaxCodeLength + 2]int
+	for length := 1; length <= webpMaxCodeLength; length++ {
+		next[length+1] = (next[length] + counts[length]) << 1
+	}
+	for symbol, length := range lengths {
+		if length > 0 {
+			decoder.symbols[[2]int{length, next[length]}] = symbol
+			next[length]++
+		}
+	}
+	return decoder, nil
+}
+
+func (decoder webpDecoder) decode(r *webpBitReader) int {
+
+	if decoder.single >= 0 {
+		return decoder.single
+	}
+	code := 0
+	for length := 1; length <= webpMaxCodeLength && r.err == nil; length++ {
+		code = code<<1 | r.read(1)
+		if symbol, ok := decoder.symbols[[2]int{length, code}]; ok {
+			return symbol
+		}
+	}
+	if r.err == nil {
+		r.err = errors.New("invalid code")
+	}
+	return 0
+}
+
+// read the description of a prefix code of the given alphabet
+func readWebPCode(r *webpBitReader, alphabet int) (webpDecoder, error) {
+
+	lengths := make([]int, alphabet)
+	if r.read(1) == 1 {
+		nbsymbols := r.read(1) + 1
+		for i := 0; i < nbsymbols; i++ {
+			nbits := 1
+			if i > 0 || r.read(1) == 1 {
+				nbits = 8
+			}
+			symbol := r.read(nbits)
+			if symbol >= alphabet {
+				return webpDecoder{}, fmt.Errorf("symbol %v out of the alphabet", symbol)
+			}
+			lengths[symbol] = 1
+		}
+		return newWebPDecoder(lengths)
+	}
+
+	codeLengths := make([]int, 19)
+	nbcodes := r.read(4) + 4
+	for _, symbol := range webpCodeLengthOrder[:nbcodes] {
+		codeLengths[symbol] = r.read(3)
+	}
+	lengthDecoder, err := newWebPDecoder(codeLengths)
+	if err != nil {
+		return webpDecoder{}, err
+	}
+	nbtokens := alphabet
+	if r.read(1) == 1 {
+		nbtokens = 2 + r.read(2+2*r.read(3))
+	}
+	previous := 8
+	for symbol := 0; symbol < alphabet && nbtokens > 0 && r.err == nil; nbtokens-- {
+		token := lengthDecoder.decode(r)
+		if token < 16 {
+			lengths[symbol] = token
+			if token != 0 {
+				previous = token
+			}
+			symbol++
+			continue
+		}
+		repeat, value := 0, 0
+		switch token {
+		case 16:
+			repeat, value = 3+r.read(2), previous
+		case 17:
+			repeat = 3 + r.read(3)
+		case 18:
+			repeat = 11 + r.read(7)
+		}
+		if symbol+repeat > alphabet {
+			return webpDecoder{}, errors.New("code lengths out of the alphabet")
+		}
+		for ; repeat > 0; repeat-- {
+			lengths[symbol] = value
+			symbol++
+		}
+	}
+	if r.err != nil {
+		return webpDecoder{}, r.err
+	}
+	return newWebPDecoder(lengths)
+}
+
+// return the length or distance given with the given prefix code
+func readWebPPrefix(r *webpBitReader, prefix int) int {
+
+	if prefix < 4 {
+		return prefix + 1
+	}
+	nbextra := (prefix - 2) >> 1
+	return (2+prefix&1)<<nbextra + r.read(nbextra) + 1
+}
+
+// return the image encoded in the given VP8L bitstream
+func decodeVP8L(data []byte) (*image.NRGBA, error) {
+
+	r := &webpBitReader{data: data}
+	if r.read(8) != 0x2f {
+		return nil, errors.New("wrong signature")
+	}
+	width, height := r.read(14)+1, r.read(14)+1
+	r.read(1)
+	if r.read(3) != 0 {
+		return nil, errors.New("wrong version")
+	}
+	if r.read(1) != 0 {
+		return nil, errors.New("transforms are not acknowledged")
+	}
+	cachebits := 0
+	if r.read(1) == 1 {
+		if cachebits = r.read(4); cachebits < 1 || cachebits > 11 {
+			return nil, fmt.Errorf("wrong size of the colour cache %v", cachebits)
+		}
+	}
+	if r.read(1) != 0 {
+		return nil, errors.New("meta prefix codes are not acknowledged")
+	}
+
+	green := 256 + webpLengthCodes
+	if cachebits > 0 {
+		green += 1 << cachebits
+	}
+	var decoders [5]webpDecoder
+	for i, alphabet := range []int{green, 256, 256, 256, webpDistanceCodes} {
+		var err error
+		if decoders[i], err = readWebPCode(r, alphabet); err != nil {
+			return nil, fmt.Errorf("prefix code %v: %v", i, err)
+		}
+	}
+
+	pixels := make([]uint32, width*height)
+	cache := make([]uint32, 1<<cachebits)
+	insert := func(pixel uint32) {
+		if cachebits > 0 {
+			cache[(0x1e35a7bd*pixel)>>(32-cachebits)] = pixel
+		}
+	}
+	for i := 0; i < len(pixels) && r.err == nil; {
+		green := decoders[0].decode(r)
+		switch {
+		case green < 256:
+			red, blue, alpha := decoders[1].decode(r), decoders[2].decode(r), decoders[3].decode(r)
+			pixels[i] = uint32(alpha)<<24 | uint32(red)<<16 | uint32(green)<<8 | uint32(blue)
+			insert(pixels[i])
+			i++
+		case green < 256+webpLengthCodes:
+			length := readWebPPrefix(r, green-256)
+			var distance int
+			switch code := readWebPPrefix(r, decoders[4].decode(r)); code {
+			case 1:
+				distance = width
+			case 2:
+				distance = 1
+			default:
+				return nil, fmt.Errorf("distance code %v is not acknowledged", code)
+			}
+			if distance > i || i+length > len(pixels) {
+				return nil, fmt.Errorf("backward reference (%v, %v) out of the image at pixel %v", distance, length, i)
+			}
+			for ; length > 0; length-- {
+				pixels[i] = pixels[i-distance]
+				insert(pixels[i])
+				i++
+			}
+		default:
+			pixels[i] = cache[green-256-webpLengthCodes]
+			insert(pixels[i])
+			i++
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if (len(data)*8 - r.pos) >= 8 {
+		return nil, fmt.Errorf("%v bytes left after the image", len(data)-(r.pos+7)/8)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i, pixel := range pixels {
+		copy(img.Pix[4*i:], []uint8{uint8(pixel >> 16), uint8(pixel >> 8), uint8(pixel), uint8(pixel >> 24)})
+	}
+	return img, nil
+}
+
+// a frame of an animated WebP file
+type webpFrame struct {
+	img      *image.NRGBA
+	offset   image.Point
+	duration int
+	flags    byte
+}
+
+// return the value given in the first three bytes of b
+func uint24(b []byte) int {
+	return int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+}
+
+// return the chunks of a RIFF file given in data as pairs of type and data
+func readRIFFChunks(data []byte) ([][2][]byte, error) {
+
+	var chunks [][2][]byte
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, errors.New("truncated chunk header")
+		}
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		if 8+size > len(data) {
+			return nil, fmt.Errorf("chunk %q of %v bytes exceeds its container", data[:4], size)
+		}
+		chunks = append(chunks, [2][]byte{data[:4], data[8 : 8+size]})
+		data = data[8+size+size%2:]
+	}
+	return chunks, nil
+}
+
+// return the dimensions of the canvas, the loop count and the frames of the
+// given animated WebP file
+func decodeWebP(data []byte) (width, height, loops int, frames []webpFrame, err error) {
+
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, 0, nil, errors.New("not a WebP file")
+	}
+	if size := int(binary.LittleEndian.Uint32(data[4:8])); size != len(data)-8 {
+		return 0, 0, 0, nil, fmt.Errorf("RIFF size %v, want %v", size, len(data)-8)
+	}
+	chunks, err := readRIFFChunks(data[12:])
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	if len(chunks) < 2 || string(chunks[0][0]) != "VP8X" || string(chunks[1][0]) != "ANIM" {
+		return 0, 0, 0, nil, errors.New("animations must start with the chunks VP8X and ANIM")
+	}
+	vp8x, anim := chunks[0][1], chunks[1][1]
+	if len(vp8x) != 10 || vp8x[0]&0x02 == 0 {
+		return 0, 0, 0, nil, errors.New("wrong chunk VP8X")
+	}
+	width, height = 1+uint24(vp8x[4:]), 1+uint24(vp8x[7:])
+	if len(anim) != 6 {
+		return 0, 0, 0, nil, errors.New("wrong chunk ANIM")
+	}
+	loops = int(binary.LittleEndian.Uint16(anim[4:]))
+
+	for _, chunk := range chunks[2:] {
+		if string(chunk[0]) != "ANMF" || len(chunk[1]) < 16 {
+			return 0, 0, 0, nil, fmt.Errorf("unexpected chunk %q", chunk[0])
+		}
+		header := chunk[1]
+		frame := webpFrame{
+			offset:   image.Point{X: 2 * uint24(header[0:]), Y: 2 * uint24(header[3:])},
+			duration: uint24(header[12:]),
+			flags:    header[15]}
+		bitstream, err := readRIFFChunks(header[16:])
+		if err != nil {
+			return 0, 0, 0, nil, err
+		}
+		if len(bitstream) != 1 || string(bitstream[0][0]) != "VP8L" {
+			return 0, 0, 0, nil, errors.New("frames must consist of a single chunk VP8L")
+		}
+		if frame.img, err = decodeVP8L(bitstream[0][1]); err != nil {
+			return 0, 0, 0, nil, fmt.Errorf("frame %v: %v", len(frames), err)
+		}
+		if dx, dy := 1+uint24(header[6:]), 1+uint24(header[9:]); frame.img.Rect.Dx() != dx || frame.img.Rect.Dy() != dy {
+			return 0, 0, 0, nil, fmt.Errorf("frame %v is %vx%v, want %vx%v", len(frames), frame.img.Rect.Dx(), frame.img.Rect.Dy(), dx, dy)
+		}
+		if !frame.img.Rect.Add(frame.offset).In(image.Rect(0, 0, width, height)) {
+			return 0, 0, 0, nil, fmt.Errorf("frame %v out of the canvas", len(frames))
+		}
+		frames = append(frames, frame)
+	}
+	return width, height, loops, frames, nil
+}
+
+// return an animation of the given frames drawn with a random palette of 256
+// colours, one of them transparent
+func randomGIF(rng *rand.Rand, width, height int, rects ...image.Rectangle) *gif.GIF {
+
+	palette := make(color.Palette, 256)
+	for i := range palette {
+		palette[i] = color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255}
+	}
+	palette[255] = color.RGBA{}
+
+	anim := &gif.GIF{Config: image.Config{Width: width, Height: height}}
+	for i, rect := range rects {
+		img := image.NewPaletted(rect, palette)
+		for j := range img.Pix {
+			// a few colours are far more frequent than the others, so that
+			// codes of very different lengths are used
+			img.Pix[j] = uint8(min(255, rng.ExpFloat64()*8*float64(i+1)))
+		}
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, 1+i)
+		anim.Disposal = append(anim.Disposal, gif.DisposalNone)
+	}
+	return anim
+}
+
+// return the animation written by Quick with the given options
+func quickGIF(t *testing.T, options QuickOptions) *gif.GIF {
+
+	var buffer bytes.Buffer
+	if err := Quick(&buffer, options); err != nil {
+		t.Fatalf("Quick: %v", err)
+	}
+	anim, err := gif.DecodeAll(&buffer)
+	if err != nil {
+		t.Fatalf("DecodeAll: %v", err)
+	}
+	return anim
+}
+
+func TestEncodeWebP(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+	single := &gif.GIF{
+		Image:    []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 4200, 3), color.Palette{color.RGBA{0x12, 0x34, 0x56, 0xff}})},
+		Delay:    []int{7},
+		Disposal: []byte{gif.DisposalNone}}
+	gradient := quickGIF(t, QuickOptions{Width: 40, Height: 30, Generations: 20, Seed: 1})
+	gradient.LoopCount = 2
+	bichrome := quickGIF(t, QuickOptions{Width: 64, Height: 16, Generations: 10, Seed: 2, Model: "bichrome"})
+	bichrome.LoopCount = -1
+
+	tests := []struct {
+		name  string
+		anim  *gif.GIF
+		loops int
+	}{
+		{"single colour", single, 0},
+		{"random", randomGIF(rng, 37, 23, image.Rect(0, 0, 37, 23), image.Rect(3, 5, 20, 17), image.Rect(3, 5, 20, 17), image.Rect(36, 22, 37, 23)), 0},
+		{"gradient", gradient, 3},
+		{"bichrome", bichrome, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			var buffer bytes.Buffer
+			if err := EncodeWebP(&buffer, test.anim); err != nil {
+				t.Fatalf("EncodeWebP: %v", err)
+			}
+			width, height, loops, frames, err := decodeWebP(buffer.Bytes())
+			if err != nil {
+				t.Fatalf("decodeWebP: %v", err)
+			}
+			if wantw, wanth := composedDimensions(test.anim); width != wantw || height != wanth {
+				t.Errorf("canvas %vx%v, want %vx%v", width, height, wantw, wanth)
+			}
+			if loops != test.loops {
+				t.Errorf("loop count %v, want %v", loops, test.loops)
+			}
+			if len(frames) != len(test.anim.Image) {
+				t.Fatalf("%v frames, want %v", len(frames), len(test.anim.Image))
+			}
+
+			// frames are drawn over the canvas without blending, and they
+			// must match the frames composited from the GIF animation
+			canvas := image.NewNRGBA(image.Rect(0, 0, width, height))
+			if err := ComposeGIF(test.anim, func(index int, img *image.NRGBA) error {
+				frame := frames[index]
+				if frame.flags != 0x02 {
+					return fmt.Errorf("frame %v has flags %#x, want 0x02", index, frame.flags)
+				}
+				if want := 10 * test.anim.Delay[index]; frame.duration != want {
+					return fmt.Errorf("frame %v lasts %v ms, want %v", index, frame.duration, want)
+				}
+				for y := 0; y < frame.img.Rect.Dy(); y++ {
+					i := canvas.PixOffset(frame.offset.X, frame.offset.Y+y)
+					copy(canvas.Pix[i:i+4*frame.img.Rect.Dx()], frame.img.Pix[y*frame.img.Stride:])
+				}
+				if !bytes.Equal(canvas.Pix, img.Pix) {
+					return fmt.Errorf("frame %v differs from the GIF animation", index)
+				}
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// animations larger than the maximum dimension of WebP files or without frames
+// can not be written
+func TestEncodeWebPErrors(t *testing.T) {
+
+	for _, anim := range []*gif.GIF{
+		{},
+		{Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, MaxWebPDimension+1, 1), color.Palette{color.Black})}},
+	} {
+		if err := EncodeWebP(&bytes.Buffer{}, anim); err == nil {
+			t.Errorf("EncodeWebP of an animation with %v frames did not fail", len(anim.Image))
+		}
+	}
+}
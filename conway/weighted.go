@@ -0,0 +1,124 @@
+// Weighted Life generalizes Life-like rules so that every position of the
+// neighbourhood contributes a weight, and births and survivals are decided on
+// the sum of the weights of living neighbours rather than on their number.
+// Weighted rules are written as B<sums>/S<sums>/W<weights>, where sums are
+// separated by commas and the nine weights are given row by row over the 3x3
+// neighbourhood, e.g., B4,5/S3,4,5/W1,2,1,2,0,2,1,2,1. The weight of the
+// center is added when the cell itself is alive
+
+package conway
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// weightedRule
+// ----------------------------------------------------------------------------
+
+// type
+
+// A weighted rule stores the weight of every position of the 3x3
+// neighbourhood in the same order of their bits (see neighbourBit), along with
+// the weighted sums that make dead cells take birth and living cells survive
+type weightedRule struct {
+	weights         [9]int
+	birth, survival map[int]bool
+}
+
+// methods
+
+// return whether a cell with the given neighbourhood takes birth (if it is
+// dead) or survives (if it is alive) according to this weighted rule
+func (rule *weightedRule) applies(alive bool, neighbourhood int) bool {
+
+	sum := 0
+	for i, weight := range rule.weights {
+		if neighbourhood&(1<<i) != 0 {
+			sum += weight
+		}
+	}
+	if alive {
+		return rule.survival[sum+rule.weights[4]]
+	}
+	return rule.birth[sum]
+}
+
+// return the specification of this weighted rule
+func (rule *weightedRule) String() string {
+
+	sums := func(set map[int]bool) string {
+		var values []int
+		for sum := range set {
+			values = append(values, sum)
+		}
+		sort.Ints(values)
+		result := make([]string, len(values))
+		for i, sum := range values {
+			result[i] = strconv.Itoa(sum)
+		}
+		return strings.Join(result, ",")
+	}
+	weights := make([]string, len(rule.weights))
+	for i, weight := range rule.weights {
+		weights[i] = strconv.Itoa(weight)
+	}
+	return "B" + sums(rule.birth) + "/S" + sums(rule.survival) + "/W" + strings.Join(weights, ",")
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// return the weighted rule given in the parts of spec, i.e., the weighted
+// sums for births, survivals and the weights, each with its prefix
+func parseWeightedRule(parts []string, spec string) (rule LifeRule, err error) {
+
+	// sums are given as comma-separated lists of integers, which might be empty
+	parse := func(list string) ([]int, error) {
+		var result []int
+		for _, value := range strings.Split(list, ",") {
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("Wrong weighted sum '%v' in the rule '%v'", value, spec)
+			}
+			result = append(result, n)
+		}
+		return result, nil
+	}
+
+	weighted := &weightedRule{birth: make(map[int]bool), survival: make(map[int]bool)}
+	birth, err := parse(parts[0][1:])
+	if err != nil {
+		return rule, err
+	}
+	survival, err := parse(parts[1][1:])
+	if err != nil {
+		return rule, err
+	}
+	weights, err := parse(parts[2][1:])
+	if err != nil {
+		return rule, err
+	}
+	if len(weights) != len(weighted.weights) {
+		return rule, fmt.Errorf("Weighted rules require exactly %v weights, but %v were given in the rule '%v'", len(weighted.weights), len(weights), spec)
+	}
+	copy(weighted.weights[:], weights)
+	for _, sum := range birth {
+		weighted.birth[sum] = true
+	}
+	for _, sum := range survival {
+		weighted.survival[sum] = true
+	}
+
+	// cells with no living neighbours are born if the empty sum makes them
+	// take birth, as in B0 rules
+	rule.Birth[0] = weighted.birth[0]
+	rule.BirthProbability, rule.SurvivalProbability = 1, 1
+	rule.weighted = weighted
+	return rule, nil
+}
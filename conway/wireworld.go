@@ -0,0 +1,121 @@
+// WireWorld is a cellular automaton with four states which is well suited for
+// simulating electronic logic elements. It is simulated over the same
+// generations used for the Conway's Game, where the state of every cell is
+// directly its color index
+
+package conway
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// Constants
+// ----------------------------------------------------------------------------
+
+// States of the cells in WireWorld. They are used as color indexes as well
+const (
+	WireEmpty uint8 = iota
+	WireConductor
+	WireHead
+	WireTail
+)
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the default palette of WireWorld: empty cells are black, conductors
+// are orange, electron heads are blue and electron tails are red
+func WireWorldPalette() color.Palette {
+	return color.Palette{
+		color.RGBA{0x00, 0x00, 0x00, 0xff},
+		color.RGBA{0xff, 0x88, 0x00, 0xff},
+		color.RGBA{0x00, 0x44, 0xff, 0xff},
+		color.RGBA{0xff, 0x00, 0x00, 0xff}}
+}
+
+// Read a WireWorld circuit drawn with characters from the given reader, and
+// return its contents row by row. Every line stands for a row of cells with the
+// following characters:
+//
+//	'.' or ' ' empty cell
+//	'#'        conductor
+//	'@'        electron head
+//	'~'        electron tail
+//
+// Lines starting with '!' are comments and are ignored. In case any other
+// character is found an error is returned
+func ReadWires(r io.Reader) (rows [][]uint8, err error) {
+
+	scanner := bufio.NewScanner(r)
+	for nbline := 1; scanner.Scan(); nbline++ {
+
+		// skip comments
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		// and translate every character into a state
+		row := make([]uint8, 0, len(line))
+		for column, char := range line {
+			switch char {
+			case '.', ' ':
+				row = append(row, WireEmpty)
+			case '#':
+				row = append(row, WireConductor)
+			case '@':
+				row = append(row, WireHead)
+			case '~':
+				row = append(row, WireTail)
+			default:
+				return nil, fmt.Errorf("Unknown character '%c' in line %v, column %v", char, nbline, 1+column)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+// Generation
+// ----------------------------------------------------------------------------
+
+// methods
+
+// Return the next generation of a WireWorld automaton. The rules are:
+//
+//  1. Empty cells remain empty
+//  2. Electron heads become electron tails
+//  3. Electron tails become conductors
+//  4. Conductors become electron heads if exactly one or two of their
+//     neighbours are electron heads, otherwise they remain conductors
+func (g *generation) nextWireWorld() *generation {
+
+	// create a new generation with the same features than this one
+	next := g.empty(1 + g.nbgeneration)
+
+	// for all cells in this generation
+	for x := 0; x <= g.img.Rect.Max.X/g.ratio.X; x++ {
+		for y := 0; y <= g.img.Rect.Max.Y/g.ratio.Y; y++ {
+			switch g.ColorIndexAt(x, y) {
+			case WireHead:
+				next.SetColorIndex(x, y, WireTail)
+			case WireTail:
+				next.SetColorIndex(x, y, WireConductor)
+			case WireConductor:
+				if heads := g.nbstate(x, y, WireHead); heads == 1 || heads == 2 {
+					next.SetColorIndex(x, y, WireHead)
+				} else {
+					next.SetColorIndex(x, y, WireConductor)
+				}
+			}
+		}
+	}
+
+	// and return the next generation
+	return next
+}
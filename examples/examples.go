@@ -0,0 +1,148 @@
+// This package provides curated presets of the Conway's Game and other
+// Life-like rules. Every preset returns a complete configuration, so that it
+// can be run programmatically with a single call, e.g.:
+//
+//	err := examples.GliderGunShowcase().Run(w)
+package examples
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// Registry of all presets by name
+var presets = map[string]func() conway.Config{
+	"glider-gun":    GliderGunShowcase,
+	"r-pentomino":   RPentomino,
+	"acorn":         Acorn,
+	"spaceships":    Spaceships,
+	"replicator":    HighLifeReplicator,
+	"day-and-night": DayAndNight,
+}
+
+// Functions
+// ----------------------------------------------------------------------------
+
+// Return the names of all presets in alphabetical order
+func Names() []string {
+
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Return the configuration of the preset with the given name, along with an
+// error if there is none
+func Lookup(name string) (conway.Config, error) {
+
+	preset, ok := presets[name]
+	if !ok {
+		return conway.Config{}, fmt.Errorf("Unknown example '%v'", name)
+	}
+	return preset(), nil
+}
+
+// The Gosper glider gun, the first gun ever found, which shoots a new glider
+// every 30 generations
+func GliderGunShowcase() conway.Config {
+	return conway.Config{
+		Name:        "glider-gun",
+		Description: "The Gosper glider gun shooting a glider every 30 generations",
+		Width:       120,
+		Height:      80,
+		Generations: 300,
+		Patterns: []conway.Placement{{
+			Pattern: "........................o.........../" +
+				"......................o.o.........../" +
+				"............oo......oo............oo/" +
+				"...........o...o....oo............oo/" +
+				"oo........o.....o...oo............../" +
+				"oo........o...o.oo....o.o.........../" +
+				"..........o.....o.......o.........../" +
+				"...........o...o..................../" +
+				"............oo......................",
+			X: 4, Y: 4}},
+	}
+}
+
+// The R-pentomino, a methuselah of only five cells which takes more than a
+// thousand generations to stabilize
+func RPentomino() conway.Config {
+	return conway.Config{
+		Name:        "r-pentomino",
+		Description: "The R-pentomino, a methuselah of five cells",
+		Width:       160,
+		Height:      120,
+		Generations: 500,
+		Model:       "radial",
+		Patterns:    []conway.Placement{{Pattern: ".oo/oo./.o.", X: 79, Y: 59}},
+	}
+}
+
+// The acorn, a methuselah of seven cells which takes more than five thousand
+// generations to stabilize
+func Acorn() conway.Config {
+	return conway.Config{
+		Name:        "acorn",
+		Description: "The acorn, a methuselah of seven cells",
+		Width:       200,
+		Height:      150,
+		Generations: 500,
+		Model:       "radial",
+		Patterns:    []conway.Placement{{Pattern: ".o...../...o.../oo..ooo", X: 96, Y: 74}},
+	}
+}
+
+// A fleet of gliders and lightweight spaceships travelling over a torus
+func Spaceships() conway.Config {
+	return conway.Config{
+		Name:        "spaceships",
+		Description: "Gliders and lightweight spaceships travelling over a torus",
+		Width:       100,
+		Height:      100,
+		Generations: 200,
+		Boundary:    conway.TorusBoundary,
+		Patterns: []conway.Placement{
+			{Pattern: "glider", X: 10, Y: 10},
+			{Pattern: "glider", X: 30, Y: 10},
+			{Pattern: "glider", X: 50, Y: 10},
+			{Pattern: "lightweight spaceship", X: 10, Y: 40},
+			{Pattern: "lightweight spaceship", X: 10, Y: 60},
+			{Pattern: "lightweight spaceship", X: 10, Y: 80},
+		},
+	}
+}
+
+// The replicator of HighLife (B36/S23), which makes copies of itself
+func HighLifeReplicator() conway.Config {
+	return conway.Config{
+		Name:        "replicator",
+		Description: "The replicator of HighLife (B36/S23)",
+		Width:       160,
+		Height:      160,
+		Generations: 300,
+		Rule:        "B36/S23",
+		Model:       "radial",
+		Patterns:    []conway.Placement{{Pattern: "..ooo/.o..o/o...o/o..o./ooo..", X: 78, Y: 78}},
+	}
+}
+
+// A random soup of Day & Night (B3678/S34678), where patterns of dead cells
+// in a sea of living cells behave as patterns of living cells do
+func DayAndNight() conway.Config {
+	return conway.Config{
+		Name:        "day-and-night",
+		Description: "A random soup of Day & Night (B3678/S34678) over a torus",
+		Width:       150,
+		Height:      150,
+		Generations: 200,
+		Rule:        "B3678/S34678",
+		Boundary:    conway.TorusBoundary,
+		Density:     0.5,
+	}
+}
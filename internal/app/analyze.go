@@ -0,0 +1,202 @@
+// Analysis of recorded runs
+//
+// Runs recorded with -record store the cells born and those that died in
+// every generation, so that any two generations can be compared without
+// simulating the game again, the census of any generation can be taken and
+// its symmetries can be analyzed
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// writeRecording
+//
+// write a recording of the given game to the file with the given name
+func writeRecording(filename string, game *conway.Conway) error {
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return game.Recording().Write(f)
+}
+
+// getDiff
+//
+// return the indices of the two generations to compare given as FROM:TO,
+// along with an error if any is found
+func (a *App) getDiff(spec string) (from, to int, err error) {
+
+	re := regexp.MustCompile(`^\s*(\d+)\s*:\s*(\d+)\s*$`)
+	match := re.FindStringSubmatch(spec)
+	if match == nil {
+		return 0, 0, errors.New(a.tr("Syntax error in the generations to compare"))
+	}
+	from, _ = strconv.Atoi(match[1])
+	to, _ = strconv.Atoi(match[2])
+	return from, to, nil
+}
+
+// census
+//
+// write the census of the generation with the given index of the given
+// recording to the standard output. It returns the exit code of the program
+func (a *App) census(recording *conway.Recording, index int) int {
+
+	alive, err := recording.Alive(index)
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to take the census: %v"), err)
+		return EXIT_FAILURE
+	}
+	cells := make([]image.Point, 0, len(alive))
+	for p := range alive {
+		cells = append(cells, p)
+	}
+
+	counts, unknown := conway.NewDictionary().Census(cells)
+	fmt.Fprintf(a.stdout, a.tr(" Generation %v\n"), index)
+	for _, name := range conway.CensusNames(counts) {
+		fmt.Fprintf(a.stdout, " %-24v %v\n", name, counts[name])
+	}
+	fmt.Fprintf(a.stdout, a.tr(" Unidentified groups: %v\n"), unknown)
+	return EXIT_SUCCESS
+}
+
+// symmetry
+//
+// write the symmetries of the generation given in spec of the given recording
+// to the standard output or, if spec is "run", the symmetries of the first
+// generation and when every one of them is broken. It returns the exit code of
+// the program
+func (a *App) symmetry(recording *conway.Recording, spec string) int {
+
+	if spec != "run" {
+		index, err := strconv.Atoi(spec)
+		if err != nil {
+			a.log.Printf(a.tr(" Wrong generation '%v'"), spec)
+			return EXIT_FAILURE
+		}
+		symmetries, err := recording.Symmetries(index)
+		if err != nil {
+			a.log.Printf(a.tr(" It was not possible to analyze the symmetries: %v"), err)
+			return EXIT_FAILURE
+		}
+		fmt.Fprintf(a.stdout, a.tr(" Generation %v: %v\n"), index, symmetries)
+		return EXIT_SUCCESS
+	}
+
+	initial, breaks := recording.SymmetryBreaks()
+	fmt.Fprintf(a.stdout, a.tr(" Initial symmetries: %v\n"), initial)
+	for _, symmetry := range initial.Split() {
+		if index, ok := breaks[symmetry]; ok {
+			fmt.Fprintf(a.stdout, a.tr(" %v broken at generation %v\n"), symmetry, index)
+		} else {
+			fmt.Fprintf(a.stdout, a.tr(" %v preserved\n"), symmetry)
+		}
+	}
+	return EXIT_SUCCESS
+}
+
+// analyze
+//
+// compare the two generations given with -diff of the recording given in
+// args, writing a report to the standard output and an image with the
+// differences, take the census of the generation given with -census or
+// analyze the symmetries given with -symmetry. It returns the exit code of the
+// program
+func (a *App) analyze(args []string) int {
+
+	var diff, filename, symmetry string
+	var scale, census int
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.StringVar(&diff, "diff", "", a.tr("generations to compare given as FROM:TO"))
+	flags.IntVar(&census, "census", -1, a.tr("generation whose objects are counted. Type 'objects list' to show the objects acknowledged"))
+	flags.StringVar(&symmetry, "symmetry", "", a.tr("generation whose symmetries are shown, or 'run' to show when every symmetry of the first generation is broken"))
+	flags.StringVar(&filename, "image", "diff.png", a.tr("name of the PNG file where cells born are shown in green, those that died in red and those that persisted in grey"))
+	flags.IntVar(&scale, "scale", 4, a.tr("number of pixels per side of every cell in the image"))
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+	modes := 0
+	for _, given := range []bool{diff != "", census >= 0, symmetry != ""} {
+		if given {
+			modes++
+		}
+	}
+	if flags.NArg() != 1 || modes != 1 {
+		a.log.Printf(a.tr(" Usage: %v analyze (-diff FROM:TO | -census GENERATION | -symmetry GENERATION|run) RECORDING-FILE"), program)
+		return EXIT_USAGE
+	}
+	if scale < 1 {
+		a.log.Print(a.tr(" The scale must be strictly positive"))
+		return EXIT_FAILURE
+	}
+	var from, to int
+	var err error
+	if diff != "" {
+		if from, to, err = a.getDiff(diff); err != nil {
+			a.log.Printf(" %v", err)
+			return EXIT_FAILURE
+		}
+	}
+
+	// read the recording
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to open the recording: %v"), err)
+		return EXIT_FAILURE
+	}
+	defer f.Close()
+	recording, err := conway.ReadRecording(f)
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to read the recording: %v"), err)
+		return EXIT_FAILURE
+	}
+	if census >= 0 {
+		return a.census(recording, census)
+	}
+	if symmetry != "" {
+		return a.symmetry(recording, symmetry)
+	}
+
+	// compare both generations and write the report
+	d, err := recording.Diff(from, to)
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to compare the generations: %v"), err)
+		return EXIT_FAILURE
+	}
+	fmt.Fprintf(a.stdout, a.tr(" Generations %v -> %v\n"), d.From, d.To)
+	fmt.Fprintf(a.stdout, a.tr(" Born:      %v\n"), len(d.Born))
+	fmt.Fprintf(a.stdout, a.tr(" Died:      %v\n"), len(d.Died))
+	fmt.Fprintf(a.stdout, a.tr(" Persisted: %v\n"), len(d.Persisted))
+
+	// and the image with the differences
+	g, err := os.Create(filename)
+	if err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+	defer g.Close()
+	if err := png.Encode(g, d.Image(conway.AspectRatio{X: scale, Y: scale})); err != nil {
+		a.log.Printf(a.tr(" It was not possible to write the image: %v"), err)
+		return EXIT_FAILURE
+	}
+
+	return EXIT_SUCCESS
+}
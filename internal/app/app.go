@@ -0,0 +1,2482 @@
+// Command line interface
+//
+// The application parses the arguments given in the command line, simulates
+// the game requested and writes all the outputs. All options are stored in
+// the application itself, so that it can be run any number of times, e.g., by
+// the subcommands that re-simulate games
+package app
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// constants
+// ----------------------------------------------------------------------------
+const EXIT_SUCCESS = 0
+const EXIT_FAILURE = 1
+
+// exit code used when the command line can not be parsed
+const EXIT_USAGE = 2
+
+const version = "1.0"
+
+// name of the program shown in all messages
+const program = "conway-game"
+
+// in accessibility mode, cells are magnified at least accessibleRatio times in
+// both axes, and frames are shown at least accessibleDelay 100th of a second
+const accessibleRatio = 4
+const accessibleDelay = 10
+
+// default colours of the four species of QuadLife
+const quadlifeSpecies = "#ff0000:#00ff00:#0088ff:#ffff00"
+
+// syntax of the age colour model, and number of generations cells need to
+// reach its last colour unless another one is given
+const ageModel = `^\s*age\s+(\#[a-fA-F0-9]{6}):(\#[a-fA-F0-9]{6}):(\#[a-fA-F0-9]{6})(?:;(\d+))?\s*$`
+const defaultMaturity = 100
+
+// App
+//
+// an application stores all options given in the command line along with the
+// random number generator used for initializing the first generation and the
+// outputs where results and messages are written
+type App struct {
+	filename        string
+	format          string
+	ffmpeg          string
+	render          string
+	fps             int
+	frames          string
+	stream          bool
+	optimize        bool
+	width, height   int
+	xratio, yratio  int
+	cellsize        string
+	grid            bool
+	gridcolor       string
+	glyph           string
+	hud             bool
+	background      string
+	backgroundtint  float64
+	effects         string
+	trail           int
+	delay, delay0   int
+	population      int
+	patternfile     string
+	pattern         string
+	rotate          int
+	flip            string
+	layout          string
+	seedimage       string
+	savestate       string
+	resume          string
+	snapshots       string
+	snapshotevery   int
+	snapshotformat  string
+	threshold       float64
+	nbgenerations   int
+	burnin          int
+	every           string
+	framestep       int
+	until           string
+	automaton       string
+	wires           string
+	ants            string
+	turns           string
+	wolfram         int
+	margolus        string
+	cyclic          string
+	rpsthreshold    int
+	growth          float64
+	lightning       float64
+	row             string
+	depth           int
+	rule3d          string
+	smoothlife      string
+	lenia           string
+	projection      string
+	model           string
+	colorrule       string
+	palette         string
+	average         int
+	want_model_help bool
+	want_version    bool
+	lang            string
+	rule            string
+	pbirth          float64
+	psurvival       float64
+	ruleseed        int64
+	accessible      bool
+	species         string
+	sheet           string
+	sheetevery      int
+	sheetcolumns    int
+	sheetstyle      string
+	maxmemory       int
+	maxdimension    int
+	oversize        string
+	seed            int64
+	sums            string
+	record          string
+	rle             string
+	life106         string
+	macrocell       string
+	svg             string
+	finalpng        string
+	svgshape        string
+	annotations     string
+	script          string
+	example         string
+	compat          string
+	ramp            string
+	loop            bool
+	interpolate     int
+	loopcount       int
+	pingpong        bool
+	autocrop        bool
+	autocroppadding int
+	infinite        bool
+	view            string
+	track           string
+	zoom            float64
+	engine          string
+	boundary        string
+	topology        string
+	lifespan        int
+	schedule        string
+	noise           string
+	noiseevery      int
+	noiseseed       int64
+	halo            int
+
+	seeds  conway.Seeds
+	rng    *rand.Rand
+	flags  *flag.FlagSet
+	stdout io.Writer
+	stderr io.Writer
+	log    *log.Logger
+
+	// language of all messages and the catalog used for translating them.
+	// English messages are not translated
+	language string
+	catalog  map[string]string
+}
+
+// filenameFields
+//
+// values of a run that can be used in the name of the GIF file
+type filenameFields struct {
+	Automaton   string
+	Rule        string
+	Seed        int64
+	Width       int
+	Height      int
+	Generations int
+	Population  int
+	Model       string
+	Date        string
+	Time        string
+}
+
+// colorScheme
+//
+// palette of the first generation along with its colour model, the center of
+// radial models and the number of species, if any. Custom colour models are
+// given in colorModel
+type colorScheme struct {
+	model      string
+	center     image.Point
+	palette    []color.Color
+	nbspecies  int
+	colorModel conway.ColorModel
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// New
+//
+// return a new application. Its options are set when it is run
+func New() *App {
+	return &App{}
+}
+
+// flagSet
+//
+// return a new set of flags for parsing all options of this application,
+// which are set to their default values. Errors are written to the standard
+// error of this application
+func (a *App) flagSet() *flag.FlagSet {
+
+	flags := flag.NewFlagSet(program, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), a.tr("Usage of %s:\n"), program)
+		flags.PrintDefaults()
+	}
+
+	// command line arguments for parsing the name of the gif file
+	flags.StringVar(&a.filename, "filename", "conway.gif", a.tr("name of the GIF file. It can contain placeholders such as {{.Rule}}-{{.Seed}}-{{.Date}}.gif which are substituted with the values of the run: Automaton, Rule, Seed, Width, Height, Generations, Population, Model, Date and Time"))
+	flags.StringVar(&a.format, "format", "gif", a.tr("format of the animation, either gif, apng (animated PNG, which is not limited to 256 colours), webp (animated WebP, far smaller than GIF files), mp4 or webm (videos encoded with ffmpeg), or frames to write only the frames given with -frames. Unless -filename is given, files are named conway.png, conway.webp, conway.mp4 and conway.webm"))
+	flags.StringVar(&a.ffmpeg, "ffmpeg", "ffmpeg", a.tr("ffmpeg binary used for encoding videos with -format mp4 or webm"))
+	flags.StringVar(&a.render, "render", "gif", a.tr("how the run is shown, either gif to write the animation in the format given with -format, terminal to print every generation to the standard output with ANSI colours instead, or tui to explore the run interactively in the terminal (play, pause, step, speed and jump to any generation)"))
+	flags.IntVar(&a.fps, "fps", 10, a.tr("number of generations shown per second with -render terminal or tui"))
+	flags.StringVar(&a.frames, "frames", "", a.tr("directory where every frame of the animation is written as a PNG image named frame_000000.png, frame_000001.png, ... in addition to the animation, unless -format frames is given"))
+	flags.BoolVar(&a.optimize, "optimize", false, a.tr("write only the area of every frame of GIF files which changed with respect to the previous frame, which drastically shrinks animations of sparse patterns on large boards"))
+	flags.BoolVar(&a.stream, "stream", false, a.tr("write every frame of the GIF file as soon as its generation is computed, so that very long runs take a bounded amount of memory. It can not be combined with options that need the whole animation, such as -loop, -ramp, -interpolate, -pingpong, -accessible, -frames, -sheet, -sums, -record or -snapshots"))
+
+	// command line arguments for parsing the dimensions of the grid
+	flags.IntVar(&a.width, "width", 100, a.tr("Width of the grid"))
+	flags.IntVar(&a.height, "height", 100, a.tr("Height of the grid"))
+
+	// command line arguments for parsing the aspect ratio
+	flags.IntVar(&a.xratio, "xratio", 1, a.tr("x aspect ratio"))
+	flags.IntVar(&a.yratio, "yratio", 1, a.tr("y aspect ratio"))
+	flags.StringVar(&a.cellsize, "cell-size", "", a.tr("size of the cells in pixels, either as a single size or as WIDTHxHEIGHT, e.g., 1.5x1.5. Sizes can be non-integer, and they can not be combined with -xratio and -yratio"))
+	flags.BoolVar(&a.autocrop, "autocrop", false, a.tr("crop all frames to the bounding box of the cells alive in any generation, so that small patterns on large boards do not produce mostly empty animations"))
+	flags.IntVar(&a.autocroppadding, "autocrop-padding", 2, a.tr("number of cells added around the bounding box of the frames cropped with -autocrop"))
+	flags.BoolVar(&a.grid, "grid", false, a.tr("draw thin grid lines between cells along the axes where cells are at least 4 pixels large, e.g., with -xratio 8 -yratio 8"))
+	flags.StringVar(&a.gridcolor, "grid-color", "#404040", a.tr("colour of the grid lines drawn with -grid in the format #RRGGBB"))
+	flags.StringVar(&a.glyph, "glyph", "square", a.tr("glyph used for drawing living cells at least 4 pixels wide and tall: square (filled blocks), circle, diamond or rounded (rounded squares)"))
+	flags.BoolVar(&a.hud, "hud", false, a.tr("draw a small HUD over every frame with the generation, the population and the rule"))
+	flags.StringVar(&a.background, "background", "", a.tr("PNG, JPEG or GIF image scaled to the frames and shown behind the cells, so that dead cells are transparent unless -background-tint is given"))
+	flags.Float64Var(&a.backgroundtint, "background-tint", 0, a.tr("opacity in the range [0, 1] of the colour of dead cells drawn over the image given with -background"))
+	flags.StringVar(&a.effects, "effects", "", a.tr("comma-separated list of effects applied in order to every frame, each given as NAME[:VALUE]: glow (VALUE is the radius in pixels, 4 by default), scanlines or vignette (VALUE is the intensity in the range [0, 1], 0.5 by default), e.g., glow:6,vignette"))
+	flags.IntVar(&a.trail, "trail", 0, a.tr("number of generations during which cells which have died are drawn with their colour fading out, so that gliders leave comet-like trails"))
+
+	// command line argument for parsing the delays between frames
+	flags.IntVar(&a.delay0, "delay0", 100, a.tr("delay of the first frame"))
+	flags.IntVar(&a.delay, "delay", 1, a.tr("delay between frames in 100th of a second"))
+	flags.BoolVar(&a.loop, "loop", false, a.tr("if the animation ends up in a cycle, trim it to a single period of the cycle so that it loops seamlessly"))
+	flags.IntVar(&a.loopcount, "loop-count", 0, a.tr("number of times the animation is played before stopping on its last frame, or 0 to play it forever"))
+	flags.BoolVar(&a.pingpong, "pingpong", false, a.tr("play the animation forward and then backward, so that it does not jump from the last generation back to the first one"))
+	flags.StringVar(&a.ramp, "ramp", "", a.tr("ramp the delay between frames across the animation, either 'slow-fast', 'fast-slow' or keyframes POSITION:DELAY, where positions are percentages, e.g., 0:20,50:5,100:2"))
+	flags.IntVar(&a.interpolate, "interpolate", 0, a.tr("number of interpolated frames inserted between consecutive generations, where newborn cells fade in and dying cells fade out. They are shown with the delay given with -delay"))
+
+	// command line argument to determine the initial number of alive cells
+	flags.IntVar(&a.population, "population", 100, a.tr("initial population"))
+	flags.StringVar(&a.patternfile, "pattern-file", "", a.tr("file with the initial population in RLE, Life 1.06, Life 1.05 or macrocell format, which is placed at the center of the grid. Its rule is used unless -rule is given"))
+	flags.StringVar(&a.pattern, "pattern", "", a.tr("name of a pattern of the library used as the initial population, e.g., glider or gosper-gun, which is placed at the center of the grid. It can also be an apgcode, e.g., xq4_153, a URL of a pattern file or the name of a pattern of LifeWiki prefixed with wiki:, e.g., wiki:puffer-train, which are downloaded once and cached. Use 'list' to show all of them"))
+	flags.IntVar(&a.rotate, "rotate", 0, a.tr("clockwise rotation in degrees of the pattern given with -pattern or -pattern-file, either 0, 90, 180 or 270"))
+	flags.StringVar(&a.flip, "flip", "none", a.tr("flip of the pattern given with -pattern or -pattern-file, applied before rotating it, either 'none', 'horizontal', 'vertical' or 'both'"))
+	flags.StringVar(&a.layout, "layout", "", a.tr("JSON file with a layout of patterns used as the initial population, every one with its location, rotation, flip and repetitions. The dimensions of the board and the rule given in it are used unless given in the command line"))
+	flags.StringVar(&a.seedimage, "seed-image", "", a.tr("PNG, JPEG or GIF image used as the initial population once scaled to the grid, where cells are alive if their brightness is at least the threshold. Unless -height is given, it is computed so that the image keeps its proportions"))
+	flags.StringVar(&a.savestate, "save-state", "", a.tr("file where the state of the last generation is saved in JSON, including the state of all random streams, so that the run can be resumed later with -resume"))
+	flags.StringVar(&a.resume, "resume", "", a.tr("file with a state saved with -save-state whose last generation becomes the first one of this run. The dimensions of the board, the automaton, the rule, the boundary, the lifespan and the seed are taken from it unless given in the command line"))
+	flags.Float64Var(&a.threshold, "threshold", 0.5, a.tr("brightness in the range [0, 1] from which the pixels of the image given with -seed-image are alive"))
+
+	// command line argument for parsing the seed used for initializing the
+	// first generation
+	flags.Int64Var(&a.seed, "seed", 0, a.tr("seed of the random number generator. If none is given, a new one is chosen"))
+
+	// command line argument for getting the name of the checksums file
+	flags.StringVar(&a.sums, "sums", "", a.tr("name of a file where the checksums of all frames are written. They can be verified later with 'verify GIF-FILE SUMS-FILE'. They can be written only for GIF files which are not split into tiles"))
+
+	// command line argument for getting the name of the recording file
+	flags.StringVar(&a.record, "record", "", a.tr("name of a file where the cells born and those that died in every generation are recorded. Generations can be compared later with 'analyze -diff FROM:TO RECORDING-FILE'"))
+	flags.StringVar(&a.rle, "rle", "", a.tr("name of a file where the last generation is written in RLE format, cropped to its living cells"))
+	flags.StringVar(&a.life106, "life106", "", a.tr("name of a file where the last generation is written in Life 1.06 format"))
+	flags.StringVar(&a.macrocell, "macrocell", "", a.tr("name of a file where the last generation is written in the macrocell format of Golly"))
+	flags.StringVar(&a.svg, "svg", "", a.tr("name of a file where the last generation is written as an SVG image, which can be printed at any resolution"))
+	flags.StringVar(&a.svgshape, "svg-shape", "square", a.tr("shape of the living cells drawn with -svg, either square or circle"))
+	flags.StringVar(&a.finalpng, "final-png", "", a.tr("name of a file where the frame of the last generation is written as a PNG image, with the same aspect ratio or cell size of the animation"))
+	flags.StringVar(&a.snapshots, "snapshots", "", a.tr("directory where snapshots of the board are written every number of generations given with -snapshot-every, e.g., gen-000100.rle"))
+	flags.IntVar(&a.snapshotevery, "snapshot-every", 100, a.tr("number of generations between consecutive snapshots written with -snapshots"))
+	flags.StringVar(&a.snapshotformat, "snapshot-format", "rle", a.tr("format of the snapshots written with -snapshots, either rle, life106 or macrocell"))
+
+	// command line argument for getting the name of the director script
+	flags.StringVar(&a.script, "script", "", a.tr("YAML file with a timeline of cues executed by the director, e.g., seeding the board, placing patterns, zooming into regions, switching palettes or stopping once the game stabilizes"))
+
+	// command line argument for running one of the curated examples
+	flags.StringVar(&a.example, "example", "", a.tr("name of a curated example to run instead of the game given in the command line, e.g., glider-gun. Use 'list' to show all of them"))
+
+	// command line argument for getting the name of the annotations file
+	flags.StringVar(&a.annotations, "annotations", "", a.tr("CSV file with annotations drawn over the frames, one per line given as GENERATION,X,Y,TEXT[,#RRGGBB], where GENERATION can also be a range FROM-TO"))
+
+	// command line argument for getting the desired number of generations
+	flags.IntVar(&a.nbgenerations, "generations", 100, a.tr("number of generations"))
+
+	// command line argument for getting the number of generations to simulate
+	// before recording the first one
+	flags.IntVar(&a.burnin, "burnin", 0, a.tr("number of generations to simulate before recording the first one"))
+
+	// command line argument for selecting the generations to render
+	flags.StringVar(&a.every, "every", "1", a.tr("generations to render: either a number n to render one every n generations, 'log:d' to render generations in a logarithmic scale with density d, or a condition such as 'activity>0.01' to render only the generations which satisfy it"))
+	flags.IntVar(&a.framestep, "frame-step", 1, a.tr("render only one every n generations, so that long-period patterns are simulated generation by generation but with far fewer frames. It is an alias of -every n, and both can not be given together"))
+	flags.StringVar(&a.until, "until", "", a.tr("condition which stops the game at the first generation satisfying it, given as METRIC OPERATOR THRESHOLD, e.g., 'activity<0.001'. The operators are <, <=, > and >=, and the metrics are population, derivative (absolute change of the population), activity (fraction of cells born or died), entropy (of blocks of 2x2 cells, in bits) and objects"))
+
+	// command line arguments for selecting the automaton to simulate and the
+	// circuit to use in WireWorld
+	flags.StringVar(&a.automaton, "automaton", "life", a.tr("automaton to simulate: life, wireworld, ant, elementary, immigration, quadlife, life3d, margolus, cyclic, rps, forestfire, brain, smoothlife or lenia"))
+	flags.StringVar(&a.wires, "wires", "", a.tr("file with the circuit to simulate in WireWorld"))
+
+	// command line arguments for parsing the ants and their turns
+	flags.StringVar(&a.ants, "ants", "", a.tr("semicolon-separated list of ants given as x,y,direction with direction being one of N, E, S or W. By default, a single ant is located at the center of the grid facing north"))
+	flags.StringVar(&a.turns, "turns", "RL", a.tr("turns made by ants on each colour: R (right), L (left), N (no turn) or U (u-turn)"))
+
+	// command line arguments for parsing the Life-like rule and the
+	// probabilities of births and survivals
+	flags.StringVar(&a.rule, "rule", "B3/S23", a.tr("Life-like rule in B/S notation, e.g., B36/S23, in Hensel notation for isotropic non-totalistic rules, e.g., B2-a/S12, or as a weighted rule B<sums>/S<sums>/W<weights> with the nine weights of the 3x3 neighbourhood given row by row, e.g., B4,5/S3,4,5/W1,2,1,2,0,2,1,2,1"))
+	flags.StringVar(&a.schedule, "schedule", "", a.tr("comma-separated list of Life-like rules used in ranges of generations given as RULE:FROM-TO, where TO can be omitted, e.g., B36/S23:201-. Other generations follow the rule given with -rule"))
+	flags.StringVar(&a.noise, "noise", "", a.tr("number of random cells, or percentage of the board followed by '%', whose state is flipped every -noise-every generations in Life-like rules"))
+	flags.IntVar(&a.noiseevery, "noise-every", 1, a.tr("number of generations between successive applications of noise"))
+	flags.Int64Var(&a.noiseseed, "noise-seed", 0, a.tr("seed of the random number generator used by noise. If none is given, it is derived from the seed of the initial population"))
+	flags.Float64Var(&a.pbirth, "birth-probability", 1.0, a.tr("probability that a dead cell with the right number of neighbours takes birth"))
+	flags.Float64Var(&a.psurvival, "survival-probability", 1.0, a.tr("probability that a living cell with the right number of neighbours survives"))
+	flags.IntVar(&a.lifespan, "lifespan", 0, a.tr("maximum number of consecutive generations that cells can be alive in Life-like rules. By default, cells can live forever"))
+	flags.Int64Var(&a.ruleseed, "rule-seed", 0, a.tr("seed of the random number generator used by stochastic rules. If none is given, it is derived from the seed of the initial population"))
+
+	// command line argument for parsing the colours of the species in
+	// multi-colour variants
+	flags.StringVar(&a.species, "species", "#ff0000:#00aaff", a.tr("colon-separated list of colours of the species in multi-colour variants. QuadLife uses four species with its own colours by default"))
+
+	// command line arguments for parsing the rule of elementary automata and
+	// their first row
+	flags.IntVar(&a.wolfram, "wolfram", 30, a.tr("rule of elementary automata in Wolfram's code (0-255)"))
+	flags.StringVar(&a.margolus, "margolus", "critters", a.tr("rule of block automata with the Margolus neighbourhood: critters, bbm (billiard-ball model), tron, or the 16 blocks which replace every block from 0 to 15 separated by commas, where the upper-left cell of every block is its least significant bit"))
+	flags.StringVar(&a.cyclic, "cyclic", "R1/T1/C14/NN", a.tr("rule of cyclic automata in the notation of MCell: range of the neighbourhood, threshold of neighbours with the next state, number of states and neighbourhood, either von Neumann (NN) or Moore (NM). States are coloured with the colour model, if any, or over the hue wheel otherwise"))
+	flags.IntVar(&a.rpsthreshold, "rps-threshold", 3, a.tr("number of neighbours of its predator required for consuming a cell in the rock-paper-scissors automaton, in the range [1, 8]"))
+	flags.Float64Var(&a.growth, "growth", 0.05, a.tr("probability that an empty cell grows a tree in the forest-fire model"))
+	flags.Float64Var(&a.lightning, "lightning", 0.0001, a.tr("probability that lightning strikes a tree in the forest-fire model"))
+	flags.StringVar(&a.row, "row", "center", a.tr("first row of elementary automata: either center (a single living cell at the center) or random (as many living cells as the initial population placed randomly)"))
+
+	// command line arguments for parsing the depth, rule and projection of 3D
+	// Life
+	flags.IntVar(&a.depth, "depth", 32, a.tr("number of layers of the volume in 3D Life (1-254)"))
+	flags.StringVar(&a.rule3d, "rule3d", "4555", a.tr("rule of 3D Life in Bays' notation ElEuFlFu, e.g., 4555 or 5766"))
+	flags.StringVar(&a.projection, "projection", "orthographic", a.tr("projection of the volume in 3D Life: either orthographic (seen from the front) or oblique (seen at an angle)"))
+
+	// command line arguments for parsing the rules of continuous automata
+	flags.StringVar(&a.smoothlife, "smoothlife", "", a.tr("rule of SmoothLife as comma-separated pairs KEY=VALUE with keys ri, ra, b1, b2, d1, d2, alphan, alpham and dt, e.g., ra=12,dt=0.1"))
+	flags.StringVar(&a.lenia, "lenia", "", a.tr("rule of Lenia as comma-separated pairs KEY=VALUE with keys R, T, mu and sigma, e.g., R=13,T=10,mu=0.15,sigma=0.015"))
+
+	// command line arguments for simulating the game over an infinite board
+	// and selecting how it is rendered
+	flags.BoolVar(&a.infinite, "infinite", false, a.tr("simulate the game over an infinite board which grows automatically when living cells approach its boundary"))
+	flags.StringVar(&a.view, "view", "fit", a.tr("view of the infinite board: either fit (the whole board is scaled to fit in the frame), viewport (only the original board is shown) or track (a camera follows the living cells)"))
+	flags.StringVar(&a.track, "track", "centroid", a.tr("target followed by the camera with --view track: either centroid (the centroid of all living cells) or box (the center of their bounding box)"))
+	flags.Float64Var(&a.zoom, "zoom", 0, a.tr("magnification of the cells filmed with --view track, e.g., 2 shows twice as large cells. If 0, the camera zooms automatically to fit all living cells"))
+
+	// command line argument for selecting the boundary condition
+	flags.StringVar(&a.boundary, "boundary", "dead", a.tr("boundary condition at the edges of the grid: dead (cells off the grid are dead), torus (the grid wraps around), mirror (cells off the grid are the mirror image of those inside it), klein (the grid wraps around as a Klein bottle, flipped horizontally across the top and bottom edges) or mobius (the grid wraps around as a Möbius strip, flipped vertically across the left and right edges)"))
+
+	// command line argument for selecting the topology of the grid
+	flags.StringVar(&a.topology, "topology", "square", a.tr("topology of the grid: square, triangular (triangles with 12 neighbours sharing an edge or a vertex) or triangular-edge (triangles with 3 neighbours sharing an edge)"))
+
+	// command line argument for padding the grid with a halo of dead cells
+	flags.IntVar(&a.halo, "halo", 0, a.tr("number of dead rows and columns added around the grid, which are simulated but not rendered, so that the edges of the grid do not show up in the animation"))
+
+	// command line argument for selecting the engine used for simulating the
+	// game
+	flags.StringVar(&a.engine, "engine", "dense", a.tr("engine used for simulating Life-like rules: dense (every generation is stored as an image), sparse (only living cells are stored) or hashlife (HashLife over an infinite board, where only the generations rendered are stored)"))
+
+	// command line arguments for parsing the contact sheet
+	flags.StringVar(&a.sheet, "sheet", "", a.tr("name of a PNG or PDF file where a contact sheet with a grid of generations is written"))
+	flags.IntVar(&a.sheetevery, "sheet-every", 10, a.tr("generations shown in the contact sheet: one every n generations"))
+	flags.IntVar(&a.sheetcolumns, "sheet-columns", 0, a.tr("number of columns of the contact sheet. By default, the grid is made as square as possible"))
+	flags.StringVar(&a.sheetstyle, "sheet-style", "contact", a.tr("style of the sheet written with -sheet, either contact (generations are labelled and separated by margins) or sprite (generations are packed next to each other without labels)"))
+
+	// command line argument for parsing the color model
+	flags.StringVar(&a.model, "model", "", a.tr("color model. Type --help-model to show additional help"))
+	flags.StringVar(&a.palette, "palette", "", a.tr("file with the colours of the color model, either a GIMP palette (.gpl) or a plain list of colours #RRGGBB, one per line. The first colour is used for dead cells and all the others are spread across the living cells, so that models can be given just by their name, e.g., -model radial;50,50"))
+	flags.StringVar(&a.colorrule, "colorrule", "", a.tr("expression computing the colour of every living cell, e.g., 'age>10 ? #ff0000 : neighbors==3 ? #00ff00 : #ffffff'. It takes precedence over the color model. Type --help-model to show additional help"))
+
+	// command line argument for parsing the averaging option
+	flags.IntVar(&a.average, "average", 1, a.tr("it assigns a color to each cell which is the average of the color indices of the last n generations, with n being the value of average given here"))
+
+	// command line argument for getting the maximum memory allowed
+	flags.IntVar(&a.maxdimension, "max-dimension", conway.MaxGIFDimension, a.tr("maximum width and height (in pixels) of GIF files. Larger animations are handled according to -oversize"))
+	flags.StringVar(&a.oversize, "oversize", "tile", a.tr("policy for animations larger than -max-dimension: tile (split them into a grid of GIF files named after the row and column of every tile) or fail"))
+	flags.IntVar(&a.maxmemory, "max-memory", 2048, a.tr("maximum memory (in MB) that can be used. If the estimated memory exceeds half this value a warning is issued, and if it exceeds it the program aborts. Use 0 to disable this check"))
+
+	// command line argument for selecting the language of all messages.
+	// Note it is processed before parsing the flags
+	flags.StringVar(&a.lang, "lang", a.language, a.tr("language of messages: en or es. By default, it is taken from the environment variable LANG"))
+
+	// command line argument for requesting the accessibility mode
+	flags.BoolVar(&a.accessible, "accessible", false, a.tr("accessibility mode: high-contrast colours, large cells and reduced flicker"))
+
+	// whether additional help on color models was requested
+	flags.BoolVar(&a.want_model_help, "help-model", false, a.tr("shows additional information on color models"))
+
+	// also, create an additional flag for showing the version
+	flags.BoolVar(&a.want_version, "version", false, a.tr("shows version info and exits"))
+
+	// and another one for preserving the behaviour of earlier versions
+	flags.StringVar(&a.compat, "compat", "", a.tr("compatibility mode which acknowledges only the flags of the given version with their original behaviour, e.g., v0.1"))
+
+	return flags
+}
+
+// fresh
+//
+// return a new application which writes to the same outputs than this one,
+// with all options set to their default values
+func (a *App) fresh() *App {
+
+	result := &App{stdout: a.stdout, stderr: a.stderr, log: a.log, language: a.language, catalog: a.catalog}
+	result.flags = result.flagSet()
+	return result
+}
+
+// showModelHelp
+//
+// show additional information on color models
+func (a *App) showModelHelp() {
+	fmt.Fprint(a.stdout, a.trText("model-help"))
+}
+
+// showVersion
+//
+// show the current version of this program
+func (a *App) showVersion() {
+	fmt.Fprintf(a.stdout, " %v %v\n", program, version)
+}
+
+// parseHex
+//
+// return the decimal representation of a number in hexadecimal notation,
+// which is assumed to be well formed
+func parseHex(hexnum string) uint8 {
+	result, _ := strconv.ParseUint(hexnum, 16, 8)
+	return uint8(result)
+}
+
+// getColor
+//
+// return a color from an hexadecimal representation #RRGGBB
+func getColor(hexcolor string) color.Color {
+
+	// parse all the hexadecimal components
+	re := regexp.MustCompile(`([a-fA-F0-9]{2})([a-fA-F0-9]{2})([a-fA-F0-9]{2})`)
+	match := re.FindStringSubmatch(hexcolor)
+
+	// and return a color under the RGB model
+	return color.RGBA{parseHex(match[1]), parseHex(match[2]), parseHex(match[3]), 255}
+}
+
+// getGradientPalette
+//
+// return the palette of colors to use for gradient palettes. It receives a
+// slice of strings which is the output of the regexp matching the color model
+// with the user specification
+func getGradientPalette(match []string) []color.Color {
+	return conway.GradientPalette(getColor(match[2]), getColor(match[3]), getColor(match[4]))
+}
+
+// getHighContrastPalette
+//
+// return a palette with the given number of colours for the accessibility mode:
+// the first one, used for dead cells, is black and all the others are yellow
+func getHighContrastPalette(n int) (hpalette []color.Color) {
+
+	hpalette = append(hpalette, color.RGBA{0, 0, 0, 255})
+	for i := 1; i < n; i++ {
+		hpalette = append(hpalette, color.RGBA{255, 255, 0, 255})
+	}
+	return
+}
+
+// getPalette
+//
+// return the colour model chosen by the user, the center given (if any, by
+// default the point 0,0) and a palette of colours, along with an error if any
+// is found
+func (a *App) getPalette(model string) (string, image.Point, []color.Color, error) {
+
+	// the bichrome model takes either the colour of living cells, which are
+	// shown over black, or the colours of dead and living cells
+	bichrome := regexp.MustCompile(`^\s*bichrome\s+(\#[a-fA-F0-9]{6})(?::(\#[a-fA-F0-9]{6}))?\s*$`)
+	if match := bichrome.FindStringSubmatch(model); match != nil {
+		if match[2] == "" {
+			return "bichrome", image.Point{}, conway.BichromePalette(color.RGBA{0, 0, 0, 255}, getColor(match[1])), nil
+		}
+		return "bichrome", image.Point{}, conway.BichromePalette(getColor(match[1]), getColor(match[2])), nil
+	}
+
+	// the rainbow model walks the hue wheel either across generations, as the
+	// gradient model, or across the distance to a center, as the radial one
+	rainbow := regexp.MustCompile(`^\s*rainbow\s+(\#[a-fA-F0-9]{6})(?:;(\d+),\s*(\d+))?\s*$`)
+	if match := rainbow.FindStringSubmatch(model); match != nil {
+		if match[2] == "" {
+			return "gradient", image.Point{}, conway.RainbowPalette(getColor(match[1])), nil
+		}
+		xcenter, _ := strconv.Atoi(match[2])
+		ycenter, _ := strconv.Atoi(match[3])
+		return "radial", image.Point{X: xcenter, Y: ycenter}, conway.RainbowPalette(getColor(match[1])), nil
+	}
+
+	// the age model colours cells with a gradient palette indexed by their age
+	if match := regexp.MustCompile(ageModel).FindStringSubmatch(model); match != nil {
+		return "age", image.Point{}, conway.GradientPalette(getColor(match[1]), getColor(match[2]), getColor(match[3])), nil
+	}
+
+	// and the neighbour model by their number of living neighbours
+	neighbors := regexp.MustCompile(`^\s*neighbors\s+(\#[a-fA-F0-9]{6}):(\#[a-fA-F0-9]{6}):(\#[a-fA-F0-9]{6})\s*$`)
+	if match := neighbors.FindStringSubmatch(model); match != nil {
+		return "neighbors", image.Point{}, conway.GradientPalette(getColor(match[1]), getColor(match[2]), getColor(match[3])), nil
+	}
+
+	// set up a regular expression to match the color model specifications
+	re := regexp.MustCompile(`\s*(gradient|radial)\s+(\#[a-fA-F0-9]{6}):(\#[a-fA-F0-9]{6}):(\#[a-fA-F0-9]{6})(;(\d+),\s*(\d+))?$`)
+
+	// and match the given color model
+	match := re.FindStringSubmatch(model)
+	if len(match) == 0 {
+		return "", image.Point{},
+			[]color.Color{},
+			errors.New(a.tr("Syntax error in the specification of the color model"))
+	}
+
+	// get the center provided by the user, and if not is given, then use the
+	// default values 0, 0
+	var xcenter, ycenter int64
+	if match[6] != "" {
+		xcenter, _ = strconv.ParseInt(match[6], 10, 0)
+	}
+	if match[7] != "" {
+		ycenter, _ = strconv.ParseInt(match[7], 10, 0)
+	}
+
+	// and apply the given color model
+	switch {
+
+	// gradient color model
+	case match[1] == "gradient" || match[1] == "radial":
+		return match[1], image.Point{X: int(xcenter), Y: int(ycenter)}, getGradientPalette(match), nil
+	}
+
+	// in case the previous switch did not return a palette then an error
+	// occurred
+	return "", image.Point{}, []color.Color{}, errors.New(a.tr("Unknown model specification"))
+}
+
+// getPaletteFile
+//
+// return the colours of the palette given in the file with the given name,
+// along with an error if any is found
+func getPaletteFile(filename string) (color.Palette, error) {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return conway.ReadPalette(f)
+}
+
+// completeModel
+//
+// return the given colour model with placeholder colours if it is given just
+// by its name, e.g., gradient or radial;x,y, so that it is acknowledged by
+// getPalette. The gradient model is used if none is given
+func completeModel(model string) string {
+
+	model = strings.TrimSpace(model)
+	if model == "" {
+		model = "gradient"
+	}
+	if strings.Contains(model, "#") {
+		return model
+	}
+	name, rest, found := strings.Cut(model, ";")
+	colors := " #000000:#000000:#000000"
+	switch strings.TrimSpace(name) {
+	case "bichrome":
+		colors = " #000000:#000000"
+	case "rainbow":
+		colors = " #000000"
+	}
+	if found {
+		return strings.TrimSpace(name) + colors + ";" + rest
+	}
+	return strings.TrimSpace(name) + colors
+}
+
+// getModel
+//
+// return the colour model given in spec as getPalette does. If colours are
+// given, e.g., from a palette file, they replace those of the model: the
+// first one is used for dead cells and all the others are spread across the
+// colours of living cells
+func (a *App) getModel(spec string, colors color.Palette) (string, image.Point, []color.Color, error) {
+
+	model, center, palette, err := a.getPalette(spec)
+	if err != nil || colors == nil {
+		return model, center, palette, err
+	}
+	living := colors[1:]
+	if len(living) == 0 {
+		living = colors
+	}
+	return model, center, append([]color.Color{colors[0]}, conway.StretchPalette(living, len(palette)-1)...), nil
+}
+
+// getAgeModel
+//
+// return the age colour model given in spec as age COLOR:COLOR:COLOR[;N],
+// where N is the number of generations cells need to reach the last colour
+// (100 by default), along with an error if any is found
+func (a *App) getAgeModel(spec string) (conway.AgeColorModel, error) {
+
+	match := regexp.MustCompile(ageModel).FindStringSubmatch(spec)
+	if match == nil {
+		return conway.AgeColorModel{}, errors.New(a.tr("Syntax error in the specification of the color model"))
+	}
+	maturity := defaultMaturity
+	if match[4] != "" {
+		maturity, _ = strconv.Atoi(match[4])
+	}
+	return conway.NewAgeColorModel(maturity)
+}
+
+// getFrameSelector
+//
+// return the frame selector of the given game specified by the user, along
+// with an error if any is found. Frame selectors are given either as a
+// positive integer n, to render one every n generations, as log:d, to render
+// generations in a logarithmic scale with density d, or as a condition over a
+// metric, to render only the generations which satisfy it
+func (a *App) getFrameSelector(spec string, game *conway.Conway) (conway.FrameSelector, error) {
+
+	// conditions are distinguished by their operators
+	if strings.ContainsAny(spec, "<>") {
+		condition, err := conway.ParseCondition(spec)
+		if err != nil {
+			return nil, err
+		}
+		return game.MetricFrames(condition), nil
+	}
+
+	// set up a regular expression to match the frame selector specifications
+	re := regexp.MustCompile(`^\s*(?:(\d+)|log:(\d+(?:\.\d+)?))\s*$`)
+
+	// and match the given frame selector
+	match := re.FindStringSubmatch(spec)
+	if len(match) == 0 {
+		return nil, errors.New(a.tr("Syntax error in the specification of the frames to render"))
+	}
+
+	// every n generations
+	if match[1] != "" {
+		n, _ := strconv.Atoi(match[1])
+		if n < 1 {
+			return nil, errors.New(a.tr("The number of generations between frames must be strictly positive"))
+		}
+		return conway.EveryFrame(n), nil
+	}
+
+	// logarithmic scale
+	density, _ := strconv.ParseFloat(match[2], 64)
+	if density <= 0 {
+		return nil, errors.New(a.tr("The density of the logarithmic scale must be strictly positive"))
+	}
+	return conway.LogarithmicFrames(density), nil
+}
+
+// getFilename
+//
+// return the name of the GIF file given in spec once all its placeholders have
+// been substituted with the values of the current run, along with an error if
+// the template is not well formed or it refers to unknown fields. Slashes are
+// removed from rules so that they do not create directories
+func (a *App) getFilename(spec string) (string, error) {
+
+	// earlier versions used the name of the file literally
+	if a.compat != "" {
+		return spec, nil
+	}
+
+	tmpl, err := template.New("filename").Parse(spec)
+	if err != nil {
+		return "", err
+	}
+
+	modelname, _, _ := strings.Cut(strings.TrimSpace(a.model), " ")
+	now := time.Now()
+	var result strings.Builder
+	if err := tmpl.Execute(&result, filenameFields{
+		Automaton:   a.automaton,
+		Rule:        strings.ReplaceAll(a.rule, "/", ""),
+		Seed:        a.seed,
+		Width:       a.width,
+		Height:      a.height,
+		Generations: a.nbgenerations,
+		Population:  a.population,
+		Model:       modelname,
+		Date:        now.Format("20060102"),
+		Time:        now.Format("150405")}); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// getView
+//
+// return the view of an infinite board given either as fit, viewport or
+// track, along with an error if it is not recognized
+func (a *App) getView(spec string) (conway.View, error) {
+
+	switch spec {
+	case "fit":
+		return conway.ScaleToFit, nil
+	case "viewport":
+		return conway.Viewport, nil
+	case "track":
+		return conway.Tracking, nil
+	}
+	return conway.ScaleToFit, fmt.Errorf(a.tr("Unknown view '%v'"), spec)
+}
+
+// getBoundary
+//
+// return the boundary condition given either as dead, torus, mirror, klein or
+// mobius, along with an error if it is not recognized
+func (a *App) getBoundary(spec string) (conway.Boundary, error) {
+
+	switch spec {
+	case "dead":
+		return conway.DeadBoundary, nil
+	case "torus":
+		return conway.TorusBoundary, nil
+	case "mirror":
+		return conway.MirrorBoundary, nil
+	case "klein":
+		return conway.KleinBoundary, nil
+	case "mobius":
+		return conway.MobiusBoundary, nil
+	}
+	return conway.DeadBoundary, fmt.Errorf(a.tr("Unknown boundary condition '%v'"), spec)
+}
+
+// getDownscalePolicy
+//
+// return the policy used for downscaling previews given either as majority or
+// any, along with an error if it is not recognized. By default, the majority
+// policy is used
+func (a *App) getDownscalePolicy(spec string) (conway.DownscalePolicy, error) {
+
+	switch spec {
+	case "", "majority":
+		return conway.MajorityPolicy, nil
+	case "any":
+		return conway.AnyAlivePolicy, nil
+	}
+	return conway.MajorityPolicy, fmt.Errorf(a.tr("Unknown downscaling policy '%v'"), spec)
+}
+
+// getTopology
+//
+// return the topology given either as square, triangular or triangular-edge,
+// along with an error if it is not recognized
+func (a *App) getTopology(spec string) (conway.Topology, error) {
+
+	switch spec {
+	case "square":
+		return conway.SquareTopology, nil
+	case "triangular":
+		return conway.TriangularTopology, nil
+	case "triangular-edge":
+		return conway.TriangularEdgeTopology, nil
+	}
+	return conway.SquareTopology, fmt.Errorf(a.tr("Unknown topology '%v'"), spec)
+}
+
+// getEngine
+//
+// return the engine given either as dense, sparse or hashlife, along with an
+// error if it is not recognized
+func (a *App) getEngine(spec string) (conway.Engine, error) {
+
+	switch spec {
+	case "dense":
+		return conway.DenseEngine, nil
+	case "sparse":
+		return conway.SparseEngine, nil
+	case "hashlife":
+		return conway.HashLifeEngine, nil
+	}
+	return conway.DenseEngine, fmt.Errorf(a.tr("Unknown engine '%v'"), spec)
+}
+
+// getAnnotations
+//
+// return the annotations given in the CSV file with the given name, along
+// with an error if any is found
+func getAnnotations(filename string) ([]conway.Annotation, error) {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return conway.ReadAnnotations(f)
+}
+
+// getBackground
+//
+// return the PNG, JPEG or GIF image given in the file with the given name,
+// along with an error if any is found
+func getBackground(filename string) (image.Image, error) {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// getScript
+//
+// return the cues of the director script given in the file with the given
+// name, along with an error if any is found
+func getScript(filename string) ([]conway.Cue, error) {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return conway.ReadScript(f)
+}
+
+// getWires
+//
+// return the states of all cells of a WireWorld circuit drawn in the given
+// file, along with an error if any is found. The circuit is placed at the
+// upper-left corner of a grid with the given dimensions
+func (a *App) getWires(filename string, width, height int) ([]uint8, error) {
+
+	// open the file and read the circuit
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rows, err := conway.ReadWires(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// and copy it into the grid
+	states := make([]uint8, (1+width)*(1+height))
+	for y, row := range rows {
+		for x, state := range row {
+			if x > width || y > height {
+				return nil, fmt.Errorf(a.tr("The circuit does not fit in a grid of dimensions %vx%v"), width, height)
+			}
+			states[y*(1+width)+x] = state
+		}
+	}
+	return states, nil
+}
+
+// getAnts
+//
+// return the ants given in the specification provided by the user, along with
+// an error if any is found. Ants are given as a semicolon-separated list of
+// triplets x,y,direction. If no ant is given, a single one is located at the
+// center of a grid with the given dimensions facing north
+func (a *App) getAnts(spec string, width, height int) ([]conway.Ant, error) {
+
+	// by default, use a single ant at the center facing north
+	if spec == "" {
+		return []conway.Ant{{
+			Position:  image.Point{X: width / 2, Y: height / 2},
+			Direction: conway.North}}, nil
+	}
+
+	// set up a regular expression to match the specification of every ant
+	re := regexp.MustCompile(`^\s*(\d+)\s*,\s*(\d+)\s*,\s*([NESW])\s*$`)
+	directions := map[string]int{
+		"N": conway.North,
+		"E": conway.East,
+		"S": conway.South,
+		"W": conway.West}
+
+	var result []conway.Ant
+	for _, item := range strings.Split(spec, ";") {
+		match := re.FindStringSubmatch(item)
+		if len(match) == 0 {
+			return nil, fmt.Errorf(a.tr("Syntax error in the specification of the ant '%v'"), item)
+		}
+		x, _ := strconv.Atoi(match[1])
+		y, _ := strconv.Atoi(match[2])
+		result = append(result, conway.Ant{
+			Position:  image.Point{X: x, Y: y},
+			Direction: directions[match[3]]})
+	}
+	return result, nil
+}
+
+// checkMemory
+//
+// estimate the peak memory used by the game and issue a warning if it exceeds
+// half the maximum memory given in MB, or abort if it exceeds it. If the
+// maximum memory is 0, nothing is checked
+func (a *App) checkMemory(selector conway.FrameSelector) error {
+
+	if a.maxmemory <= 0 {
+		return nil
+	}
+
+	// count the number of frames to render
+	var frames int
+	for gen := 0; gen < a.nbgenerations; gen++ {
+		if selector(gen) {
+			frames++
+		}
+	}
+
+	// and estimate the peak memory. Engines other than the dense one retain
+	// only the first generation as an image, and all frames are computed
+	// separately
+	generations, averaged := a.nbgenerations, a.average
+	if a.engine != "dense" {
+		generations, averaged = 1, max(a.average, 2)
+	}
+	estimate := conway.EstimateMemory(a.width+2*a.halo, a.height+2*a.halo,
+		conway.AspectRatio{X: a.xratio, Y: a.yratio},
+		generations, frames, averaged)
+
+	// generations of 3D Life additionally store their volume, one byte per
+	// cell
+	if a.automaton == "life3d" {
+		volume := uint64(generations) * uint64(1+a.width) * uint64(1+a.height) * uint64(max(a.depth, 1))
+		estimate.Generations += volume
+		estimate.Total += volume
+	}
+
+	// and generations of continuous automata store their values, eight bytes
+	// per cell
+	if a.automaton == "smoothlife" || a.automaton == "lenia" {
+		values := 8 * uint64(generations) * uint64(1+a.width) * uint64(1+a.height)
+		estimate.Generations += values
+		estimate.Total += values
+	}
+	megabytes := func(bytes uint64) float64 { return float64(bytes) / (1024 * 1024) }
+	if estimate.Total > uint64(a.maxmemory)*1024*1024 {
+		return fmt.Errorf(a.tr("The estimated memory (%.1f MB: %.1f MB for generations, %.1f MB for frames and %.1f MB for the encoder) exceeds the maximum allowed (%v MB). Use -max-memory to change it"),
+			megabytes(estimate.Total), megabytes(estimate.Generations),
+			megabytes(estimate.Frames), megabytes(estimate.Encoder), a.maxmemory)
+	}
+	if estimate.Total > uint64(a.maxmemory)*1024*1024/2 {
+		a.log.Printf(a.tr(" Warning: the estimated memory (%.1f MB) is close to the maximum allowed (%v MB)"),
+			megabytes(estimate.Total), a.maxmemory)
+	}
+	return nil
+}
+
+// getRow
+//
+// return the contents of the first generation of an elementary automaton over
+// a grid with the given dimensions, where only the first row is populated,
+// along with an error if any is found. The first row is either "center", with
+// a single living cell at the center, or "random", with as many living cells
+// as the initial population located randomly
+func (a *App) getRow(spec string) ([]bool, error) {
+
+	contents := make([]bool, (1+a.width)*(1+a.height))
+	switch spec {
+	case "center":
+		contents[a.width/2] = true
+	case "random":
+		for _, x := range a.rng.Perm(1 + a.width) {
+			if x < a.population {
+				contents[x] = true
+			}
+		}
+	default:
+		return nil, fmt.Errorf(a.tr("Unknown first row '%v'"), spec)
+	}
+	return contents, nil
+}
+
+// getVolume
+//
+// return the contents of the first generation of 3D Life, where as many
+// living cells as the initial population are located randomly in the volume
+func (a *App) getVolume() []bool {
+
+	layer := (1 + a.width) * (1 + a.height)
+	volume := make([]bool, layer*a.depth)
+	cells := a.rng.Perm(a.width * a.height * a.depth)
+	for _, cell := range cells[:min(a.population, len(cells))] {
+		z, xy := cell/(a.width*a.height), cell%(a.width*a.height)
+		volume[z*layer+(xy/a.width)*(1+a.width)+xy%a.width] = true
+	}
+	return volume
+}
+
+// getValues
+//
+// return the values of the first generation of a continuous automaton, where
+// the living cells of the given contents are given random values and all the
+// others are empty
+func (a *App) getValues(contents []bool) []float64 {
+
+	values := make([]float64, len(contents))
+	for i, alive := range contents {
+		if alive {
+			values[i] = a.rng.Float64()
+		}
+	}
+	return values
+}
+
+// getContinuousRule
+//
+// return the rule of the continuous automaton selected by the user, along with
+// an error if it is not well formed
+func (a *App) getContinuousRule() (conway.ContinuousRule, error) {
+
+	if a.automaton == "smoothlife" {
+		return conway.ParseSmoothLifeRule(a.smoothlife)
+	}
+	return conway.ParseLeniaRule(a.lenia)
+}
+
+// getCyclicStates
+//
+// return the contents of the first generation of a cyclic automaton, where
+// every cell is randomly given one of the given number of states
+func (a *App) getCyclicStates(nbstates int) []uint8 {
+
+	states := make([]uint8, (1+a.width)*(1+a.height))
+	for y := 0; y < a.height; y++ {
+		for x := 0; x < a.width; x++ {
+			states[y*(1+a.width)+x] = uint8(a.rng.Intn(nbstates))
+		}
+	}
+	return states
+}
+
+// getCyclicRule
+//
+// return the rule of cyclic automata, either the one given by the user or the
+// rule of the rock-paper-scissors automaton with the threshold given by the
+// user, along with an error if it is not well formed
+func (a *App) getCyclicRule() (conway.CyclicRule, error) {
+
+	if a.automaton == "rps" {
+		if a.rpsthreshold < 1 || a.rpsthreshold > 8 {
+			return conway.CyclicRule{}, fmt.Errorf(a.tr("The threshold %v is out of the range [1, 8]"), a.rpsthreshold)
+		}
+		return conway.RockPaperScissorsRule(a.rpsthreshold), nil
+	}
+	return conway.ParseCyclicRule(a.cyclic)
+}
+
+// getStates
+//
+// return the states of the first generation of automata other than the
+// Conway's Game, where living cells given in contents are given the state
+// alive (e.g., trees in the forest-fire model) and all others are given the
+// state 0
+func getStates(contents []bool, alive uint8) []uint8 {
+
+	states := make([]uint8, len(contents))
+	for i, isalive := range contents {
+		if isalive {
+			states[i] = alive
+		}
+	}
+	return states
+}
+
+// getProjection
+//
+// return the projection given either as orthographic or oblique, along with
+// an error if it is not recognized
+func (a *App) getProjection(spec string) (conway.Projection, error) {
+
+	switch spec {
+	case "orthographic":
+		return conway.OrthographicProjection, nil
+	case "oblique":
+		return conway.ObliqueProjection, nil
+	}
+	return conway.OrthographicProjection, fmt.Errorf(a.tr("Unknown projection '%v'"), spec)
+}
+
+// newGame
+//
+// return a new game (either the Conway's Game or any other automaton) as
+// specified by the user, along with an error if its specification is not
+// correct. The game is not run
+func (a *App) newGame() (*conway.Conway, error) {
+
+	// the first generation is given by the board and the automaton requested,
+	// unless a run is resumed
+	state, err := a.setSeeds()
+	if err != nil {
+		return nil, err
+	}
+	cellsize, err := a.getCellSize()
+	if err != nil {
+		return nil, err
+	}
+	contents, err := a.getBoard(state)
+	if err != nil {
+		return nil, err
+	}
+	scheme, err := a.getColorScheme()
+	if err != nil {
+		return nil, err
+	}
+	game, err := a.newAutomaton(contents, state, scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	// and then it is simulated, rendered and written as requested
+	if err := a.setSimulation(game, state); err != nil {
+		return nil, err
+	}
+	if err := a.setRendering(game, cellsize); err != nil {
+		return nil, err
+	}
+	if err := a.setOutput(game); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// setSeeds
+//
+// create the random streams of the current run and return the state of the
+// run resumed, if any, along with an error if it could not be read
+func (a *App) setSeeds() (*conway.State, error) {
+
+	// create the random number generator used for initializing the first
+	// generation. In case no seed was given, a new one is chosen and stored so
+	// that it can be recorded. In case a run is resumed, its state is read
+	// first, since it gives the seed and the state of all random streams
+	var state *conway.State
+	if a.resume != "" {
+		var err error
+		if state, err = a.getState(); err != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to resume the run: %v"), err)
+		}
+	}
+	if a.seed == 0 {
+		a.seed = time.Now().UnixNano()
+	}
+	a.seeds = conway.NewSeeds(a.seed)
+	if state != nil {
+		if err := a.seeds.Restore(state.Streams); err != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to resume the run: %v"), err)
+		}
+	}
+	a.rng = a.seeds.Rand(conway.SoupStream)
+
+	return state, nil
+}
+
+// getCellSize
+//
+// return the size of cells given with -cell-size if it can not be simulated
+// as an aspect ratio, along with an error if it is not correct. Integer sizes
+// are stored as aspect ratios instead, and cells are magnified in
+// accessibility mode
+func (a *App) getCellSize() (*conway.CellSize, error) {
+
+	// cells can be given a size instead of an aspect ratio. Integer sizes are
+	// simulated as aspect ratios, and any other is used for rendering frames
+	var cellsize *conway.CellSize
+	if a.cellsize != "" {
+		size, err := conway.ParseCellSize(a.cellsize)
+		if err != nil {
+			return nil, err
+		}
+		if a.xratio != 1 || a.yratio != 1 {
+			return nil, errors.New(a.tr("The cell size can not be combined with an aspect ratio"))
+		}
+		if ratio, ok := size.Ratio(); ok {
+			a.xratio, a.yratio = ratio.X, ratio.Y
+		} else {
+			cellsize = &size
+		}
+	}
+
+	// in accessibility mode, make sure that cells are large enough and frames
+	// are not shown too fast
+	if a.accessible {
+		if cellsize != nil {
+			cellsize.X, cellsize.Y = max(cellsize.X, accessibleRatio), max(cellsize.Y, accessibleRatio)
+		} else {
+			a.xratio, a.yratio = max(a.xratio, accessibleRatio), max(a.yratio, accessibleRatio)
+		}
+		a.delay = max(a.delay, accessibleDelay)
+	}
+
+	return cellsize, nil
+}
+
+// getBoard
+//
+// return the contents of the first generation, either a random soup or those
+// given by a pattern, layout or image, along with an error if they can not be
+// read. No soup is drawn if the run resumes the given state
+func (a *App) getBoard(state *conway.State) ([]bool, error) {
+
+	// layouts can give the dimensions of the board, so that they are read
+	// before initializing the first generation
+	var layout conway.Layout
+	if a.layout != "" {
+		if a.patternfile != "" || a.pattern != "" {
+			return nil, errors.New(a.tr("Layouts can not be combined with -pattern or -pattern-file"))
+		}
+		var err error
+		if layout, err = a.getLayout(); err != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to read the layout: %v"), err)
+		}
+	}
+
+	// and so do images
+	var img image.Image
+	if a.seedimage != "" {
+		if a.patternfile != "" || a.pattern != "" || a.layout != "" {
+			return nil, errors.New(a.tr("Images can not be combined with -pattern, -pattern-file or -layout"))
+		}
+		var err error
+		if img, err = a.getSeedImage(); err != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to read the image: %v"), err)
+		}
+	}
+
+	// initialize the first generation randomly. Note that cells of 3D Life are
+	// located in a volume
+	capacity := (1 + a.width) * (1 + a.height)
+	if a.automaton == "life3d" {
+		capacity = a.width * a.height * max(a.depth, 1)
+	}
+	if a.population > capacity {
+		a.log.Printf(a.tr(" Pruning the initial population to %v individuals"), capacity)
+		a.population = capacity
+	}
+
+	// Resumed runs take their first generation from their state instead, and
+	// drawing no soup leaves the stream of soups as in an uninterrupted run
+	contents := make([]bool, (1+a.width)*(1+a.height))
+	if state == nil {
+		for i := 0; i < min(a.population, len(contents)); i++ {
+			contents[i] = true
+		}
+		a.rng.Shuffle(a.width*a.height, func(i, j int) {
+			contents[i], contents[j] = contents[j], contents[i]
+		})
+
+		// v0.1 read the first generation by rows of width cells
+		if a.compat != "" {
+			contents = compatContents(contents, a.width, a.height)
+		}
+	}
+
+	// unless it is read from a pattern file or taken from the library
+	if a.patternfile != "" && a.pattern != "" {
+		return nil, errors.New(a.tr("Patterns can not be taken from a file and the library at the same time"))
+	}
+	if a.patternfile != "" || a.pattern != "" {
+		var err error
+		if contents, err = a.getPattern(); err != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to read the pattern: %v"), err)
+		}
+	}
+	if a.layout != "" {
+		var err error
+		if contents, err = layout.Contents(a.width, a.height); err != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to read the layout: %v"), err)
+		}
+	}
+	if a.seedimage != "" {
+		var err error
+		if contents, err = conway.ImageContents(img, a.width, a.height, a.threshold); err != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to read the image: %v"), err)
+		}
+	}
+
+	return contents, nil
+}
+
+// getColorScheme
+//
+// return the colours of the automaton requested, along with an error if they
+// are not correctly specified
+func (a *App) getColorScheme() (colorScheme, error) {
+
+	// the colours of the palette file, if any, replace those of the colour
+	// model, which can be then given just by its name
+	var palettecolors color.Palette
+	if a.palette != "" {
+		var err error
+		if palettecolors, err = getPaletteFile(a.palette); err != nil {
+			return colorScheme{}, fmt.Errorf(a.tr("It was not possible to read the palette: %v"), err)
+		}
+		if a.colorrule != "" {
+			return colorScheme{}, errors.New(a.tr("Colour rules can not be combined with a palette file"))
+		}
+		a.model = completeModel(a.model)
+	}
+
+	// get a palette according to the user's specification along with the colour
+	// model and the center used in the radial model. WireWorld uses instead its
+	// own palette where colours stand for the states of cells
+	var ok error
+	var usermodel string
+	var center image.Point
+	var palette []color.Color
+	var nbspecies int
+	var colorrule *conway.ColorRule
+	switch a.automaton {
+	case "life", "elementary", "margolus":
+
+		// colour rules define their own palette with the colours they use
+		if a.colorrule != "" {
+			if a.automaton != "life" {
+				return colorScheme{}, errors.New(a.tr("Colour rules can only be used with Life-like rules"))
+			}
+			if colorrule, ok = conway.ParseColorRule(a.colorrule); ok != nil {
+				return colorScheme{}, fmt.Errorf(a.tr("Wrong colour rule: %v"), ok)
+			}
+			palette = colorrule.Palette(color.RGBA{0, 0, 0, 255})
+			break
+		}
+		if usermodel, center, palette, ok = a.getModel(a.model, palettecolors); ok != nil {
+			return colorScheme{}, fmt.Errorf(a.tr("Unknown color model: %v"), ok)
+		}
+		if a.accessible {
+			palette = getHighContrastPalette(len(palette))
+		}
+	case "immigration", "quadlife":
+		dead := color.Color(color.RGBA{0, 0, 0, 255})
+		if a.model != "" {
+			if usermodel, center, palette, ok = a.getModel(a.model, palettecolors); ok != nil {
+				return colorScheme{}, fmt.Errorf(a.tr("Unknown color model: %v"), ok)
+			}
+			dead = palette[0]
+		}
+		if a.automaton == "quadlife" && !a.isFlagSet("species") {
+			a.species = quadlifeSpecies
+		}
+		colors, err := a.getSpeciesColors(a.species)
+		if err != nil {
+			return colorScheme{}, fmt.Errorf(a.tr("Wrong colours of species: %v"), err)
+		}
+		if a.automaton == "quadlife" && len(colors) != 4 {
+			return colorScheme{}, errors.New(a.tr("QuadLife requires exactly four species"))
+		}
+		palette, nbspecies = conway.SpeciesPalette(dead, colors), len(colors)
+	case "cyclic", "rps":
+		rule, err := a.getCyclicRule()
+		if err != nil {
+			return colorScheme{}, fmt.Errorf(a.tr("Wrong rule: %v"), err)
+		}
+		var colors []color.Color
+		if a.model != "" {
+			if _, _, colors, ok = a.getModel(a.model, palettecolors); ok != nil {
+				return colorScheme{}, fmt.Errorf(a.tr("Unknown color model: %v"), ok)
+			}
+		}
+		palette = conway.CyclicPalette(colors, rule.States)
+	case "wireworld":
+		palette = conway.WireWorldPalette()
+	case "forestfire":
+		palette = conway.ForestFirePalette()
+	case "brain":
+		palette = conway.BrainPalette()
+	case "ant":
+		palette = conway.AntPalette(len(a.turns))
+	case "life3d":
+		if a.depth < 1 || a.depth > 254 {
+			return colorScheme{}, errors.New(a.tr("The depth of 3D Life must be in the range [1, 254]"))
+		}
+		palette = conway.Life3DPalette(color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}, a.depth)
+	case "smoothlife", "lenia":
+		palette = conway.GradientPalette(color.RGBA{0, 0, 0, 255}, color.RGBA{0x1e, 0x3c, 0x78, 0xff}, color.RGBA{0xff, 0xdc, 0x50, 0xff})
+		if a.model != "" {
+			if usermodel, center, palette, ok = a.getModel(a.model, palettecolors); ok != nil {
+				return colorScheme{}, fmt.Errorf(a.tr("Unknown color model: %v"), ok)
+			}
+		}
+	default:
+		return colorScheme{}, fmt.Errorf(a.tr("Unknown automaton '%v'"), a.automaton)
+	}
+
+	// custom colour models are given either with colour rules or by the name of
+	// the colour model
+	var model conway.ColorModel
+	switch {
+	case colorrule != nil:
+		model = colorrule
+	case usermodel == "age":
+		age, err := a.getAgeModel(a.model)
+		if err != nil {
+			return colorScheme{}, fmt.Errorf(a.tr("Unknown color model: %v"), err)
+		}
+		model = age
+	case usermodel == "neighbors":
+		model = conway.NeighborColorModel{}
+	}
+	return colorScheme{model: usermodel, center: center, palette: palette, nbspecies: nbspecies, colorModel: model}, nil
+}
+
+// newAutomaton
+//
+// return a new game whose first generation has the given contents and colours
+// and follows the automaton requested, along with an error if it is not
+// correctly specified. Randomly initialized automata draw no states if the run
+// resumes the given state
+func (a *App) newAutomaton(contents []bool, state *conway.State, scheme colorScheme) (*conway.Conway, error) {
+
+	// create the first generation and set its contents
+	initial := conway.NewGeneration(image.Rectangle{
+		Min: image.Point{X: 0, Y: 0},
+		Max: image.Point{X: a.width, Y: a.height}},
+		scheme.palette,
+		conway.AspectRatio{X: a.xratio, Y: a.yratio},
+		scheme.model,
+		1, a.nbgenerations)
+
+	// and set the center. Note that the conway package will use it only in case
+	// the colour model requested by the user is radial
+	initial.SetCenter(scheme.center)
+	initial.SetAutomaton(a.automaton)
+	if scheme.colorModel != nil {
+		if err := initial.SetColorModel(scheme.colorModel); err != nil {
+			return nil, fmt.Errorf(a.tr("Unknown color model: %v"), err)
+		}
+	}
+
+	// and the boundary condition
+	b, err := a.getBoundary(a.boundary)
+	if err != nil {
+		return nil, err
+	}
+	initial.SetBoundary(b)
+
+	// and the topology of the grid
+	t, err := a.getTopology(a.topology)
+	if err != nil {
+		return nil, err
+	}
+	if err := initial.SetTopology(t); err != nil {
+		return nil, fmt.Errorf(a.tr("It was not possible to use the topology '%v': %v"), a.topology, err)
+	}
+
+	// and the lifespan of cells, if any
+	if a.lifespan != 0 {
+		if err := initial.SetLifespan(a.lifespan); err != nil {
+			return nil, fmt.Errorf(a.tr("Wrong lifespan: %v"), err)
+		}
+	}
+
+	// the contents of the first generation in WireWorld are given by the
+	// circuit provided by the user
+	if a.automaton == "wireworld" {
+		states, err := a.getWires(a.wires, a.width, a.height)
+		if err != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to read the circuit: %v"), err)
+		}
+		if ok := initial.SetStates(states); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+		}
+	} else if a.automaton == "ant" {
+		antlist, err := a.getAnts(a.ants, a.width, a.height)
+		if err != nil {
+			return nil, fmt.Errorf(a.tr("Wrong specification of ants: %v"), err)
+		}
+		if ok := initial.SetAnts(antlist, a.turns); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the ants: %v"), ok)
+		}
+	} else if a.automaton == "life3d" {
+		rule, err := conway.ParseLife3DRule(a.rule3d)
+		if err != nil {
+			return nil, fmt.Errorf(a.tr("Wrong rule: %v"), err)
+		}
+		projection, err := a.getProjection(a.projection)
+		if err != nil {
+			return nil, err
+		}
+		if ok := initial.SetVolume(a.getVolume(), a.depth, rule, projection); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+		}
+	} else if a.automaton == "cyclic" || a.automaton == "rps" {
+		rule, err := a.getCyclicRule()
+		if err != nil {
+			return nil, fmt.Errorf(a.tr("Wrong rule: %v"), err)
+		}
+		if ok := initial.SetCyclicRule(rule); ok != nil {
+			return nil, fmt.Errorf(a.tr("Wrong rule: %v"), ok)
+		}
+		// as with soups, no states are drawn when resuming a run
+		states := make([]uint8, (1+a.width)*(1+a.height))
+		if state == nil {
+			states = a.getCyclicStates(rule.States)
+		}
+		if ok := initial.SetStates(states); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+		}
+	} else if a.automaton == "forestfire" {
+		rule := conway.ForestFireRule{Growth: a.growth, Lightning: a.lightning}
+		if ok := initial.SetForestFire(rule, a.seeds.Rand(conway.ForestFireStream)); ok != nil {
+			return nil, fmt.Errorf(a.tr("Wrong rule: %v"), ok)
+		}
+		if ok := initial.SetStates(getStates(contents, conway.ForestTree)); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+		}
+	} else if a.automaton == "brain" {
+		if ok := initial.SetStates(getStates(contents, conway.BrainFiring)); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+		}
+	} else if a.automaton == "smoothlife" || a.automaton == "lenia" {
+		rule, err := a.getContinuousRule()
+		if err != nil {
+			return nil, fmt.Errorf(a.tr("Wrong rule: %v"), err)
+		}
+		if ok := initial.SetValues(a.getValues(contents), rule); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+		}
+	} else if a.automaton == "margolus" {
+		rule, err := conway.ParseMargolusRule(a.margolus)
+		if err != nil {
+			return nil, fmt.Errorf(a.tr("Wrong rule: %v"), err)
+		}
+		initial.SetMargolusRule(rule)
+		if ok := initial.Set(contents); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+		}
+	} else if a.automaton == "elementary" {
+		if a.wolfram < 0 || a.wolfram > 255 {
+			return nil, errors.New(a.tr("The rule of elementary automata must be in the range [0, 255]"))
+		}
+		initial.SetWolframRule(uint8(a.wolfram))
+		first, err := a.getRow(a.row)
+		if err != nil {
+			return nil, fmt.Errorf(a.tr("Wrong specification of the first row: %v"), err)
+		}
+		if ok := initial.Set(first); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+		}
+	} else {
+
+		// Life-like rules are stochastic if births or survivals do not happen
+		// always. In this case, a separate random number generator is created
+		// from its own stream so that the initial population does not depend
+		// on the rule
+		liferule, err := conway.ParseLifeRule(a.rule)
+		if err != nil {
+			return nil, fmt.Errorf(a.tr("Wrong rule: %v"), err)
+		}
+		liferule.BirthProbability, liferule.SurvivalProbability = a.pbirth, a.psurvival
+		if a.ruleseed == 0 {
+			a.ruleseed = a.seeds.Seed(conway.RuleStream)
+		}
+		if ok := initial.SetRule(liferule, a.seeds.Stream(conway.RuleStream, a.ruleseed)); ok != nil {
+			return nil, fmt.Errorf(a.tr("Wrong rule: %v"), ok)
+		}
+		if a.automaton == "immigration" || a.automaton == "quadlife" {
+			if ok := initial.SetSpecies(a.getSpecies(contents, scheme.nbspecies), scheme.nbspecies); ok != nil {
+				return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+			}
+		} else if ok := initial.Set(contents); ok != nil {
+			return nil, fmt.Errorf(a.tr("It was not possible to initialize the first generation: %v"), ok)
+		}
+	}
+
+	// Create a Conway's Game with this generation
+	game := conway.NewConway(a.width, a.height, a.nbgenerations, initial)
+	return &game, nil
+}
+
+// setSimulation
+//
+// set how the given game is simulated, resuming the given state, if any, and
+// return an error if any option is not correct
+func (a *App) setSimulation(game *conway.Conway, state *conway.State) error {
+
+	game.SetSeeds(a.seeds)
+	game.SetBurnIn(a.burnin)
+
+	// resume the saved run, if any
+	if state != nil {
+		if err := game.Restore(*state); err != nil {
+			return fmt.Errorf(a.tr("It was not possible to resume the run: %v"), err)
+		}
+		a.log.Printf(a.tr(" Resuming the run from generation %v"), state.Generation)
+	}
+
+	// and decide what generations are rendered
+	selector, err := a.getFrameSelector(a.every, game)
+	if err != nil {
+		return fmt.Errorf(a.tr("Wrong frame selection: %v"), err)
+	}
+	game.SetFrameSelector(selector)
+
+	// and when it stops, if it does before the last generation
+	if a.until != "" {
+		condition, err := conway.ParseCondition(a.until)
+		if err != nil {
+			return fmt.Errorf(a.tr("Wrong stop condition: %v"), err)
+		}
+		if err := game.SetUntil(condition); err != nil {
+			return fmt.Errorf(a.tr("Wrong stop condition: %v"), err)
+		}
+	}
+
+	// and whether it is padded with a halo
+	if a.halo != 0 {
+		if err := game.SetHalo(a.halo); err != nil {
+			return fmt.Errorf(a.tr("It was not possible to pad the grid with a halo: %v"), err)
+		}
+	}
+
+	// and the schedule of rules, if any
+	if a.schedule != "" {
+		schedule, err := conway.ParseSchedule(a.schedule)
+		if err != nil {
+			return fmt.Errorf(a.tr("Wrong schedule: %v"), err)
+		}
+		if err := game.SetSchedule(schedule); err != nil {
+			return fmt.Errorf(a.tr("Wrong schedule: %v"), err)
+		}
+	}
+
+	// and the noise, if any, which draws random numbers from its own stream
+	if a.noise != "" {
+		noise, err := conway.ParseNoise(a.noise, a.noiseevery)
+		if err != nil {
+			return fmt.Errorf(a.tr("Wrong noise: %v"), err)
+		}
+		if a.noiseseed == 0 {
+			a.noiseseed = a.seeds.Seed(conway.NoiseStream)
+		}
+		if err := game.SetNoise(noise, a.seeds.Stream(conway.NoiseStream, a.noiseseed)); err != nil {
+			return fmt.Errorf(a.tr("Wrong noise: %v"), err)
+		}
+	}
+
+	// and the script executed by the director, if any
+	if a.script != "" {
+		cues, err := getScript(a.script)
+		if err != nil {
+			return fmt.Errorf(a.tr("It was not possible to read the script: %v"), err)
+		}
+		if err := game.SetScript(cues); err != nil {
+			return fmt.Errorf(a.tr("Wrong script: %v"), err)
+		}
+	}
+
+	// and whether the game is simulated over an infinite board
+	if a.infinite {
+		v, err := a.getView(a.view)
+		if err != nil {
+			return err
+		}
+		if err := game.SetUnbounded(v); err != nil {
+			return fmt.Errorf(a.tr("It was not possible to simulate an infinite board: %v"), err)
+		}
+		if v == conway.Tracking {
+			target, err := conway.NewCameraTarget(a.track)
+			if err == nil {
+				err = game.SetCamera(conway.Camera{Target: target, Zoom: a.zoom})
+			}
+			if err != nil {
+				return fmt.Errorf(a.tr("It was not possible to film the infinite board: %v"), err)
+			}
+		}
+	}
+
+	// and the engine used for simulating it
+	e, err := a.getEngine(a.engine)
+	if err != nil {
+		return err
+	}
+	if err := game.SetEngine(e); err != nil {
+		return fmt.Errorf(a.tr("It was not possible to use the engine '%v': %v"), a.engine, err)
+	}
+
+	return nil
+}
+
+// setRendering
+//
+// set how the frames of the given game are rendered, with cells of the given
+// size, if any, and return an error if any option is not correct
+func (a *App) setRendering(game *conway.Conway, cellsize *conway.CellSize) error {
+
+	// draw the annotations over its frames, if any
+	if a.annotations != "" {
+		annotations, err := getAnnotations(a.annotations)
+		if err != nil {
+			return fmt.Errorf(a.tr("It was not possible to read the annotations: %v"), err)
+		}
+		game.SetAnnotations(annotations)
+	}
+
+	// and the grid lines drawn between cells, if requested
+	if a.grid {
+		colors, err := a.getSpeciesColors(a.gridcolor)
+		if err == nil && len(colors) != 1 {
+			err = fmt.Errorf(a.tr("Syntax error in the colour '%v'"), a.gridcolor)
+		}
+		if err == nil {
+			err = game.SetGrid(colors[0])
+		}
+		if err != nil {
+			return fmt.Errorf(a.tr("Wrong grid: %v"), err)
+		}
+	}
+
+	// and the image shown behind the cells, if any
+	if a.background != "" {
+		img, err := getBackground(a.background)
+		if err == nil {
+			err = game.SetBackground(conway.Background{Image: img, Tint: a.backgroundtint})
+		}
+		if err != nil {
+			return fmt.Errorf(a.tr("It was not possible to set the background: %v"), err)
+		}
+	}
+
+	// and the trail left by cells which have died, if any
+	if err := game.SetTrail(a.trail); err != nil {
+		return fmt.Errorf(a.tr("Wrong trail: %v"), err)
+	}
+
+	// and the effects applied to its frames, if any
+	if a.effects != "" {
+		effects, err := conway.ParseEffects(a.effects)
+		if err != nil {
+			return fmt.Errorf(a.tr("Wrong effects: %v"), err)
+		}
+		game.SetEffects(effects)
+	}
+
+	// and the HUD drawn over its frames, if requested
+	game.SetHUD(a.hud)
+
+	// and the glyph used for drawing living cells
+	glyph, err := conway.NewGlyph(a.glyph)
+	if err == nil {
+		err = game.SetGlyph(glyph)
+	}
+	if err != nil {
+		return fmt.Errorf(a.tr("Wrong glyph: %v"), err)
+	}
+
+	// and the size of cells in frames, if any
+	if cellsize != nil {
+		if err := game.SetCellSize(*cellsize); err != nil {
+			return fmt.Errorf(a.tr("It was not possible to use the cell size '%v': %v"), a.cellsize, err)
+		}
+	}
+
+	// and the region its frames are cropped to, if requested
+	if a.autocrop {
+		if err := game.SetAutoCrop(a.autocroppadding); err != nil {
+			return fmt.Errorf(a.tr("It was not possible to crop the frames: %v"), err)
+		}
+	}
+
+	return nil
+}
+
+// setOutput
+//
+// set how the animation of the given game is written and return an error if
+// any option is not correct
+func (a *App) setOutput(game *conway.Conway) error {
+
+	// set the number of times its animation is played
+	if err := game.SetLoopCount(a.gifLoopCount()); err != nil {
+		return fmt.Errorf(a.tr("Wrong loop count: %v"), err)
+	}
+	return nil
+}
+
+// simulate
+//
+// run the Conway's Game (or any other automaton) as specified by the user and
+// return the game along with its GIF animation
+func (a *App) simulate() (*conway.Conway, gif.GIF, error) {
+
+	game, err := a.newGame()
+	if err != nil {
+		return nil, gif.GIF{}, err
+	}
+
+	// before running, verify that the game fits in memory. Earlier versions
+	// ran games of any size
+	if a.compat == "" {
+		if err := a.checkMemory(game.FrameSelector()); err != nil {
+			return nil, gif.GIF{}, err
+		}
+	}
+
+	// and that the ramp of delays, if any, is well formed
+	var ramp conway.Ramp
+	if a.ramp != "" {
+		if ramp, err = conway.ParseRamp(a.ramp, a.delay); err != nil {
+			return nil, gif.GIF{}, fmt.Errorf(a.tr("Wrong ramp: %v"), err)
+		}
+	}
+
+	// and run the Conway's Game over this initial generation
+	game.Run()
+
+	// and report the statistics of the burn-in phase, if any
+	if a.burnin > 0 {
+		stats := game.BurnInStats()
+		a.log.Printf(a.tr(" Burn-in: %v generations simulated in %v (population: %v -> %v)"),
+			stats.Generations, stats.Elapsed, stats.InitialPopulation, stats.FinalPopulation)
+	}
+
+	// get the image of the entire Conway's game using the delays and average
+	// values provided by the user
+	anim := game.GetGIF(a.delay0, a.delay, a.average)
+
+	// trim it to a seamless loop if requested and a cycle is found
+	if a.loop {
+		if loop, ok := conway.FindLoop(&anim); ok {
+			a.log.Printf(a.tr(" Loop detected: frame %v repeats every %v frames"), loop.Start, loop.Period)
+			conway.TrimLoop(&anim, loop)
+		} else {
+			a.log.Print(a.tr(" No loop was detected"))
+		}
+	}
+	// insert interpolated frames between generations if requested
+	conway.InterpolateGIF(&anim, a.interpolate, a.delay)
+	if ramp != nil {
+		conway.ApplyRamp(&anim, ramp)
+	}
+	if a.pingpong {
+		conway.PingPong(&anim)
+	}
+
+	// in accessibility mode, flicker is reduced. In any case, warn the user if
+	// the animation might be problematic for people with photosensitivity,
+	// unless it is run as in earlier versions, which did not
+	if a.accessible {
+		conway.ReduceFlicker(&anim, accessibleDelay)
+	}
+	if a.compat == "" {
+		if report := conway.AnalyzeFlashes(&anim); report.Hazardous() {
+			a.log.Printf(a.tr(" Warning: the animation contains up to %v flashes per second, which might be problematic for people with photosensitivity. Use -accessible to reduce them"),
+				report.MaxPerSecond)
+		}
+	}
+
+	return game, anim, nil
+}
+
+// gifLoopCount
+//
+// return the loop count of GIF animations, where 0 means forever and -1 means
+// once, equivalent to the number of times animations are played given in the
+// command line
+func (a *App) gifLoopCount() int {
+
+	if a.loopcount <= 0 {
+		return 0
+	}
+	if a.loopcount == 1 {
+		return -1
+	}
+	return a.loopcount - 1
+}
+
+// checkStream
+//
+// return an error if any option given in the command line can not be used
+// when the GIF file is written while the game is simulated
+func (a *App) checkStream() error {
+
+	if a.format != "gif" || a.render != "gif" {
+		return errors.New(a.tr("Only GIF files can be written while the game is simulated"))
+	}
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"loop", a.loop},
+		{"ramp", a.ramp != ""},
+		{"interpolate", a.interpolate > 0},
+		{"pingpong", a.pingpong},
+		{"autocrop", a.autocrop},
+		{"accessible", a.accessible},
+		{"frames", a.frames != ""},
+		{"sheet", a.sheet != ""},
+		{"sums", a.sums != ""},
+		{"record", a.record != ""},
+		{"snapshots", a.snapshots != ""},
+	} {
+		if option.set {
+			return fmt.Errorf(a.tr("-stream can not be combined with -%v"), option.name)
+		}
+	}
+	return nil
+}
+
+// streamGIF
+//
+// run the game as specified by the user while its GIF file is written, and
+// return it. Only the generations needed for computing the next ones are kept
+func (a *App) streamGIF() (*conway.Conway, error) {
+
+	game, err := a.newGame()
+	if err != nil {
+		return nil, err
+	}
+	name, err := a.getFilename(a.filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	if err := game.EncodeGIF(w, conway.GIFOptions{Delay0: a.delay0, Delay: a.delay, Average: a.average, LoopCount: a.gifLoopCount(), Optimize: a.optimize}); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	if a.burnin > 0 {
+		stats := game.BurnInStats()
+		a.log.Printf(a.tr(" Burn-in: %v generations simulated in %v (population: %v -> %v)"),
+			stats.Generations, stats.Elapsed, stats.InitialPopulation, stats.FinalPopulation)
+	}
+	return game, nil
+}
+
+// writeGIF
+//
+// write the given animation to the file with the given name. Animations larger
+// than the maximum dimension either fail or are split into tiles, which are
+// written to separate files named after their row and column, e.g.,
+// conway-0-1.gif. Frames are optimized if requested. Earlier versions always
+// wrote a single file
+func (a *App) writeGIF(name string, anim *gif.GIF) error {
+
+	width, height := conway.GIFDimensions(anim)
+	if a.compat == "" && (width > a.maxdimension || height > a.maxdimension) {
+		if a.oversize == "fail" {
+			return fmt.Errorf(a.tr("The animation (%vx%v) exceeds the maximum dimension of GIF files (%v). Use -oversize tile to split it into tiles"),
+				width, height, a.maxdimension)
+		}
+		tiles := conway.SplitGIF(anim, a.maxdimension)
+		ext := filepath.Ext(name)
+		var names []string
+		for _, tile := range tiles {
+			tilename := fmt.Sprintf("%v-%v-%v%v", strings.TrimSuffix(name, ext), tile.Row, tile.Column, ext)
+			if err := encodeGIF(tilename, a.optimized(&tile.Anim)); err != nil {
+				return err
+			}
+			names = append(names, tilename)
+		}
+		a.log.Printf(a.tr(" The animation (%vx%v) exceeds the maximum dimension of GIF files (%v), and it was split into %v tiles: %v"),
+			width, height, a.maxdimension, len(tiles), strings.Join(names, ", "))
+		return nil
+	}
+	return encodeGIF(name, a.optimized(anim))
+}
+
+// optimized
+//
+// return the given animation with its frames optimized if requested, or the
+// same animation otherwise
+func (a *App) optimized(anim *gif.GIF) *gif.GIF {
+
+	if !a.optimize {
+		return anim
+	}
+	result := conway.OptimizeGIF(anim)
+	return &result
+}
+
+// encodeGIF
+//
+// write the given animation to the file with the given name
+func encodeGIF(name string, anim *gif.GIF) error {
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return gif.EncodeAll(f, anim)
+}
+
+// encodeAPNG
+//
+// write the given animation to the file with the given name as an animated PNG
+func encodeAPNG(name string, anim *gif.GIF) error {
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return conway.EncodeAPNG(f, anim)
+}
+
+// encodeWebP
+//
+// write the given animation to the file with the given name as an animated WebP
+func encodeWebP(name string, anim *gif.GIF) error {
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return conway.EncodeWebP(f, anim)
+}
+
+// writeFrames
+//
+// write every frame of the given animation as a complete PNG image into the
+// directory with the given name, which is created if necessary. Files are
+// named after the index of their frame, e.g., frame_000042.png
+func writeFrames(dir string, anim *gif.GIF) error {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return conway.ComposeGIF(anim, func(index int, img *image.NRGBA) error {
+		f, err := os.Create(filepath.Join(dir, fmt.Sprintf("frame_%06d.png", index)))
+		if err != nil {
+			return err
+		}
+		err = png.Encode(f, img)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	})
+}
+
+// isFlagSet
+//
+// return whether the flag with the given name was explicitly given in the
+// command line
+func (a *App) isFlagSet(name string) (result bool) {
+	a.flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			result = true
+		}
+	})
+	return
+}
+
+// getSpeciesColors
+//
+// return the colours of all species given in a colon-separated list of colours
+// in the format #RRGGBB, along with an error if any is found
+func (a *App) getSpeciesColors(spec string) ([]color.Color, error) {
+
+	re := regexp.MustCompile(`^\s*(\#[a-fA-F0-9]{6})\s*$`)
+	var colors []color.Color
+	for _, item := range strings.Split(spec, ":") {
+		if !re.MatchString(item) {
+			return nil, fmt.Errorf(a.tr("Syntax error in the colour '%v'"), item)
+		}
+		colors = append(colors, getColor(item))
+	}
+	return colors, nil
+}
+
+// getSpecies
+//
+// return the species of all cells given in contents, so that dead cells are
+// given species 0 and living cells are randomly given a species in the range
+// [1, nbspecies]. Species are drawn from their own random stream
+func (a *App) getSpecies(contents []bool, nbspecies int) []uint8 {
+
+	rng := a.seeds.Rand(conway.SpeciesStream)
+	species := make([]uint8, len(contents))
+	for i, alive := range contents {
+		if alive {
+			species[i] = uint8(1 + rng.Intn(nbspecies))
+		}
+	}
+	return species
+}
+
+// writeSheet
+//
+// write a contact or sprite sheet of the given game to the file with the given
+// name, either in PNG or PDF format according to its extension
+func (a *App) writeSheet(filename string, game *conway.Conway) error {
+
+	// the format is checked first, so that no file is created otherwise
+	var encode func(w io.Writer, img image.Image) error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		encode = conway.WritePDF
+	case ".png":
+		encode = png.Encode
+	default:
+		return errors.New(a.tr("Unknown format of the contact sheet: use either .png or .pdf"))
+	}
+
+	img := game.ContactSheet(a.sheetevery, a.sheetcolumns, true)
+	if a.sheetstyle == "sprite" {
+		img = game.SpriteSheet(a.sheetevery, a.sheetcolumns)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return encode(f, img)
+}
+
+// Run
+//
+// run this application with the given arguments (excluding the name of the
+// program), writing its output to stdout and all messages to stderr, and
+// return its exit code
+func (a *App) Run(args []string, stdout, stderr io.Writer) int {
+
+	a.stdout, a.stderr = stdout, stderr
+	a.log = log.New(stderr, "", log.LstdFlags)
+
+	// first, select the language of all messages
+	a.setLanguage(getLanguage(args))
+
+	// subcommands are processed separately
+	if len(args) > 0 && args[0] == "verify" {
+		return a.verify(args[1:])
+	}
+	if len(args) > 0 && args[0] == "serve" {
+		return a.serve(args[1:])
+	}
+	if len(args) > 0 && args[0] == "analyze" {
+		return a.analyze(args[1:])
+	}
+	if len(args) > 0 && args[0] == "objects" {
+		return a.objects(args[1:])
+	}
+	if len(args) > 0 && args[0] == "tiles" {
+		return a.tiles(args[1:])
+	}
+	if len(args) > 0 && args[0] == "convert" {
+		return a.convert(args[1:])
+	}
+
+	// first things first, parse the flags
+	a.flags = a.flagSet()
+	if err := a.flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return EXIT_SUCCESS
+		}
+		return EXIT_USAGE
+	}
+
+	// scripts written for earlier versions are verified before doing anything
+	// else
+	if a.compat != "" {
+		if err := a.checkCompat(); err != nil {
+			a.log.Printf(" %v", err)
+			return EXIT_USAGE
+		}
+	}
+
+	// if additional information has been requested on color models show it and
+	// then gracefully exit
+	if a.want_model_help {
+		a.showModelHelp()
+		return EXIT_SUCCESS
+	}
+
+	// if the current version is requested, then show it on the standard output
+	// and exit
+	if a.want_version {
+		a.showVersion()
+		return EXIT_SUCCESS
+	}
+
+	// curated examples are run with their own configuration
+	if a.example != "" {
+		return a.runExample(a.example)
+	}
+
+	// and the patterns of the library are shown if requested
+	if a.pattern == "list" {
+		return a.listPatterns()
+	}
+
+	// verify that the name of the GIF file is well formed before running the
+	// game
+	if _, err := a.getFilename(a.filename); err != nil {
+		a.log.Printf(a.tr(" Wrong name of the GIF file: %v"), err)
+		return EXIT_FAILURE
+	}
+
+	// and also its format
+	if _, ok := videoCodecs[a.format]; !ok && a.format != "gif" && a.format != "apng" && a.format != "webp" && a.format != "frames" {
+		a.log.Printf(a.tr(" Unknown format of the animation '%v'"), a.format)
+		return EXIT_FAILURE
+	}
+	if a.render != "gif" && a.render != "terminal" && a.render != "tui" {
+		a.log.Printf(a.tr(" Unknown renderer '%v'"), a.render)
+		return EXIT_FAILURE
+	}
+	if a.loopcount < 0 || a.loopcount > math.MaxUint16+1 {
+		a.log.Printf(a.tr(" The number of times the animation is played must be in the range [0, %v]"), math.MaxUint16+1)
+		return EXIT_FAILURE
+	}
+	if a.interpolate < 0 {
+		a.log.Print(a.tr(" The number of interpolated frames can not be negative"))
+		return EXIT_FAILURE
+	}
+	if a.fps < 1 {
+		a.log.Print(a.tr(" The number of frames per second must be at least 1"))
+		return EXIT_FAILURE
+	}
+	if a.isFlagSet("frame-step") {
+		if a.isFlagSet("every") {
+			a.log.Print(a.tr(" The flags -frame-step and -every can not be given together"))
+			return EXIT_FAILURE
+		}
+		if a.framestep < 1 {
+			a.log.Print(a.tr(" The frame step must be strictly positive"))
+			return EXIT_FAILURE
+		}
+		a.every = strconv.Itoa(a.framestep)
+	}
+	if a.format == "frames" && a.frames == "" {
+		a.log.Print(a.tr(" The directory where frames are written must be given with -frames"))
+		return EXIT_FAILURE
+	}
+	if a.sums != "" && (a.format != "gif" || a.render != "gif") {
+		a.log.Print(a.tr(" Checksums can only be written along with GIF files"))
+		return EXIT_FAILURE
+	}
+
+	// and also the policy for animations which are too large
+	if a.maxdimension < 1 || a.maxdimension > conway.MaxGIFDimension {
+		a.log.Printf(a.tr(" The maximum dimension must be in the range [1, %v]"), conway.MaxGIFDimension)
+		return EXIT_FAILURE
+	}
+	if a.oversize != "tile" && a.oversize != "fail" {
+		a.log.Printf(a.tr(" Unknown policy for large animations '%v'"), a.oversize)
+		return EXIT_FAILURE
+	}
+
+	// and the circuit simulated in WireWorld
+	if a.automaton == "wireworld" && a.wires == "" {
+		a.log.Print(a.tr(" WireWorld requires a circuit given with -wires"))
+		return EXIT_FAILURE
+	}
+
+	// and the shape of cells in SVG images
+	if _, err := conway.NewSVGShape(a.svgshape); err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+
+	// and the style of the contact sheet
+	if a.sheetstyle != "contact" && a.sheetstyle != "sprite" {
+		a.log.Printf(a.tr(" Unknown style of sheets '%v'"), a.sheetstyle)
+		return EXIT_FAILURE
+	}
+
+	// and the snapshots to write, if any
+	if a.snapshots != "" {
+		if a.snapshotevery < 1 {
+			a.log.Print(a.tr(" The number of generations between snapshots must be at least 1"))
+			return EXIT_FAILURE
+		}
+		if _, ok := snapshotFormats[a.snapshotformat]; !ok {
+			a.log.Printf(a.tr(" Unknown format of snapshots '%v'"), a.snapshotformat)
+			return EXIT_FAILURE
+		}
+	}
+
+	// streamed animations are neither kept in memory nor post-processed
+	if a.stream {
+		if err := a.checkStream(); err != nil {
+			a.log.Printf(" %v", err)
+			return EXIT_FAILURE
+		}
+	}
+
+	// runs explored interactively are simulated on demand
+	if a.render == "tui" {
+		game, err := a.newGame()
+		if err == nil {
+			err = a.explore(game, a.fps)
+		}
+		if err != nil {
+			a.log.Printf(" %v", err)
+			return EXIT_FAILURE
+		}
+		return EXIT_SUCCESS
+	}
+
+	// run the game
+	var game *conway.Conway
+	var anim gif.GIF
+	var err error
+	if a.stream {
+		game, err = a.streamGIF()
+	} else {
+		game, anim, err = a.simulate()
+	}
+	if err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+
+	// checksums are verified against a single GIF file, so that they can not
+	// be written if the animation is split into tiles
+	if a.sums != "" {
+		if width, height := conway.GIFDimensions(&anim); width > a.maxdimension || height > a.maxdimension {
+			a.log.Printf(a.tr(" Checksums can not be written for animations (%vx%v) which exceed the maximum dimension of GIF files (%v)"),
+				width, height, a.maxdimension)
+			return EXIT_FAILURE
+		}
+	}
+
+	// and now that the seed is known, write the animation or show it in the
+	// terminal
+	name, _ := a.getFilename(a.filename)
+	if a.format != "gif" && !a.isFlagSet("filename") {
+		extension := map[string]string{"apng": ".png", "webp": ".webp", "mp4": ".mp4", "webm": ".webm"}[a.format]
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + extension
+	}
+	switch {
+	case a.stream:
+	case a.render == "terminal":
+		err = a.renderTerminal(a.stdout, game, a.fps)
+	case a.format == "frames":
+	case a.format == "apng":
+		err = encodeAPNG(name, &anim)
+	case a.format == "webp":
+		err = encodeWebP(name, &anim)
+	case a.format == "mp4" || a.format == "webm":
+		err = a.encodeVideo(name, a.format, a.ffmpeg, &anim)
+	default:
+		err = a.writeGIF(name, &anim)
+	}
+	if err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+
+	// write every frame as a separate image if requested
+	if a.frames != "" {
+		if err := writeFrames(a.frames, &anim); err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the frames: %v"), err)
+			return EXIT_FAILURE
+		}
+		a.log.Printf(a.tr(" %v frames written to '%v'"), len(anim.Image), a.frames)
+	}
+
+	// write the contact sheet if requested
+	if a.sheet != "" {
+		if err := a.writeSheet(a.sheet, game); err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the contact sheet: %v"), err)
+			return EXIT_FAILURE
+		}
+	}
+
+	// and write the checksums of all frames if requested
+	if a.sums != "" {
+		if err := writeChecksums(a.sums, args, a.seed, a.optimized(&anim)); err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the checksums: %v"), err)
+			return EXIT_FAILURE
+		}
+	}
+
+	// and record the game if requested
+	if a.record != "" {
+		if err := writeRecording(a.record, game); err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the recording: %v"), err)
+			return EXIT_FAILURE
+		}
+	}
+
+	// and export the last generation if requested
+	if a.rle != "" {
+		if err := writePattern(a.rle, game, conway.Pattern.ExportRLE); err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the last generation: %v"), err)
+			return EXIT_FAILURE
+		}
+	}
+	if a.life106 != "" {
+		if err := writePattern(a.life106, game, conway.Pattern.ExportLife106); err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the last generation: %v"), err)
+			return EXIT_FAILURE
+		}
+	}
+	if a.macrocell != "" {
+		if err := writePattern(a.macrocell, game, conway.Pattern.ExportMacrocell); err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the last generation: %v"), err)
+			return EXIT_FAILURE
+		}
+	}
+	if a.svg != "" {
+		if err := writeSVG(a.svg, a.svgshape, game); err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the last generation: %v"), err)
+			return EXIT_FAILURE
+		}
+	}
+
+	if a.finalpng != "" {
+		if err := writeFinalPNG(a.finalpng, game); err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the last generation: %v"), err)
+			return EXIT_FAILURE
+		}
+	}
+
+	// and write the snapshots if requested
+	if a.snapshots != "" {
+		nbsnapshots, err := writeSnapshots(a.snapshots, a.snapshotevery, a.snapshotformat, game)
+		if err != nil {
+			a.log.Printf(a.tr(" It was not possible to write the snapshots: %v"), err)
+			return EXIT_FAILURE
+		}
+		a.log.Printf(a.tr(" %v snapshots written to '%v'"), nbsnapshots, a.snapshots)
+	}
+
+	// and save its state if requested
+	if a.savestate != "" {
+		if err := writeState(a.savestate, game); err != nil {
+			a.log.Printf(a.tr(" It was not possible to save the state: %v"), err)
+			return EXIT_FAILURE
+		}
+	}
+
+	return EXIT_SUCCESS
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// run the application with the given arguments and return its exit code and
+// everything written to the standard error
+func run(args ...string) (int, string) {
+
+	var stdout, stderr bytes.Buffer
+	code := New().Run(args, &stdout, &stderr)
+	return code, stderr.String()
+}
+
+func TestRunExitCodes(t *testing.T) {
+
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"success", []string{"-width", "20", "-height", "20", "-generations", "5", "-seed", "1", "-model", "bichrome #ffffff", "-filename", filepath.Join(dir, "success.gif")}, EXIT_SUCCESS},
+		{"help", []string{"-h"}, EXIT_SUCCESS},
+		{"unknown flag", []string{"-no-such-flag"}, EXIT_USAGE},
+		{"wrong value", []string{"-width", "wide"}, EXIT_USAGE},
+		{"checksums of webp", []string{"-width", "20", "-height", "20", "-generations", "5", "-model", "bichrome #ffffff", "-format", "webp", "-filename", filepath.Join(dir, "sums.webp"), "-sums", filepath.Join(dir, "webp.sums")}, EXIT_FAILURE},
+		{"checksums of tiles", []string{"-width", "20", "-height", "20", "-generations", "5", "-model", "bichrome #ffffff", "-max-dimension", "10", "-filename", filepath.Join(dir, "tiles.gif"), "-sums", filepath.Join(dir, "tiles.sums")}, EXIT_FAILURE},
+		{"frame step and every", []string{"-width", "20", "-height", "20", "-generations", "5", "-model", "bichrome #ffffff", "-frame-step", "2", "-every", "2", "-filename", filepath.Join(dir, "step.gif")}, EXIT_FAILURE},
+		{"null frame step", []string{"-width", "20", "-height", "20", "-generations", "5", "-model", "bichrome #ffffff", "-frame-step", "0", "-filename", filepath.Join(dir, "step.gif")}, EXIT_FAILURE},
+		{"sheet of unknown format", []string{"-width", "20", "-height", "20", "-generations", "5", "-model", "bichrome #ffffff", "-filename", filepath.Join(dir, "sheet.gif"), "-sheet", filepath.Join(dir, "sheet.jpg")}, EXIT_FAILURE},
+		{"bad model", []string{"-width", "20", "-height", "20", "-generations", "5", "-seed", "1", "-model", "foo", "-filename", filepath.Join(dir, "bad.gif")}, EXIT_FAILURE},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code, stderr := run(test.args...); code != test.code {
+				t.Errorf("Run(%q) = %v, want %v\n%s", test.args, code, test.code, stderr)
+			}
+		})
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "success.gif")); err != nil || info.Size() == 0 {
+		t.Errorf("No animation was written by a successful run: %v", err)
+	}
+	for _, name := range []string{"bad.gif", "sums.webp", "tiles-0-0.gif", "step.gif", "sheet.jpg"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("The file %v was written by a failed run", name)
+		}
+	}
+}
+
+// -frame-step is an alias of -every
+func TestFrameStep(t *testing.T) {
+
+	dir := t.TempDir()
+	var animations [][]byte
+	for _, flag := range []string{"-frame-step", "-every"} {
+		name := filepath.Join(dir, flag[1:]+".gif")
+		if code, stderr := run("-width", "20", "-height", "20", "-generations", "30", "-seed", "1", "-model", "bichrome #ffffff", flag, "7", "-filename", name); code != EXIT_SUCCESS {
+			t.Fatalf("Run with %v = %v, want %v\n%s", flag, code, EXIT_SUCCESS, stderr)
+		}
+		contents, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		animations = append(animations, contents)
+	}
+	if !bytes.Equal(animations[0], animations[1]) {
+		t.Error("The animations written with -frame-step 7 and -every 7 differ")
+	}
+}
+
+// a run of 20 generations resumed for 20 more leaves the same board and random
+// streams as an uninterrupted run of 39 generations
+func TestResume(t *testing.T) {
+
+	dir := t.TempDir()
+	common := []string{"-width", "30", "-height", "30", "-model", "bichrome #ffffff", "-noise", "5"}
+	runs := [][]string{
+		{"-seed", "1", "-generations", "39", "-save-state", filepath.Join(dir, "straight.json")},
+		{"-seed", "1", "-generations", "20", "-save-state", filepath.Join(dir, "first.json")},
+		{"-resume", filepath.Join(dir, "first.json"), "-generations", "20", "-save-state", filepath.Join(dir, "resumed.json")},
+	}
+	for i, args := range runs {
+		args = append(append(args, common...), "-filename", filepath.Join(dir, "conway.gif"))
+		if code, stderr := run(args...); code != EXIT_SUCCESS {
+			t.Fatalf("Run %v = %v, want %v\n%s", i, code, EXIT_SUCCESS, stderr)
+		}
+	}
+
+	states := make([]map[string]any, 2)
+	for i, name := range []string{"straight.json", "resumed.json"} {
+		contents, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(contents, &states[i]); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		// the number of generations is the only one allowed to differ
+		delete(states[i], "generations")
+	}
+	for key, want := range states[0] {
+		if got := states[1][key]; !reflect.DeepEqual(got, want) {
+			t.Errorf("The resumed run saved %v = %v, want %v", key, got, want)
+		}
+	}
+}
+
+// applications run concurrently in different languages must show their
+// messages each in its own language
+func TestRunLanguages(t *testing.T) {
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for lang, msg := range map[string]string{
+			"en": "Unknown color model",
+			"es": "Modelo de color desconocido",
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				code, stderr := run("-lang", lang, "-width", "20", "-height", "20", "-generations", "5", "-model", "foo", "-filename", filepath.Join(t.TempDir(), "conway.gif"))
+				if code != EXIT_FAILURE || !strings.Contains(stderr, msg) {
+					t.Errorf("Run in %v = %v, %q, want %v and %q", lang, code, stderr, EXIT_FAILURE, msg)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
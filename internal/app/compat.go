@@ -0,0 +1,89 @@
+// Compatibility modes
+//
+// Scripts written for earlier versions of the program keep working with
+// '-compat VERSION', which acknowledges only the flags available in that
+// version and preserves their original behaviour, e.g., the syntax of color
+// models and names of GIF files which are used literally
+package app
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// flags available in every version acknowledged by the compatibility mode.
+// Note that v0.1 had no seed, but -seed is acknowledged so that its runs can
+// be reproduced. Soups are drawn as in v0.1 seeded with it
+var compatFlags = map[string][]string{
+	"v0.1": {"filename", "width", "height", "xratio", "yratio", "delay0", "delay",
+		"population", "generations", "model", "average", "help-model", "version",
+		"seed"},
+}
+
+// syntax of color models in every version acknowledged by the compatibility
+// mode
+var compatModels = map[string]*regexp.Regexp{
+	"v0.1": regexp.MustCompile(`\s*(gradient|radial)\s+(\#[a-fA-F0-9]{6}):(\#[a-fA-F0-9]{6}):(\#[a-fA-F0-9]{6})(;(\d+),\s*(\d+))?$`),
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// checkCompat
+//
+// verify that the flags given in the command line are all available in the
+// version requested with -compat, and that the color model is given with the
+// syntax of that version. It returns an error otherwise
+func (a *App) checkCompat() error {
+
+	available, ok := compatFlags[a.compat]
+	if !ok {
+		var versions []string
+		for version := range compatFlags {
+			versions = append(versions, version)
+		}
+		sort.Strings(versions)
+		return fmt.Errorf(a.tr("Unknown version '%v'. Available versions are: %v"), a.compat, strings.Join(versions, ", "))
+	}
+
+	var err error
+	a.flags.Visit(func(f *flag.Flag) {
+		if err != nil || f.Name == "compat" {
+			return
+		}
+		for _, name := range available {
+			if f.Name == name {
+				return
+			}
+		}
+		err = fmt.Errorf(a.tr("The flag -%v is not available in version %v"), f.Name, a.compat)
+	})
+	if err != nil {
+		return err
+	}
+
+	// note that no color model is required for showing help or the version
+	if !a.want_model_help && !a.want_version && !compatModels[a.compat].MatchString(a.model) {
+		return fmt.Errorf(a.tr("Syntax error in the specification of the color model of version %v"), a.compat)
+	}
+	return nil
+}
+
+// compatContents
+//
+// return the contents of a board with the given dimensions indexed by rows of
+// width+1 cells, as expected by Set, where the cell at (x, y) is the one which
+// v0.1 read from the given contents, i.e., by rows of width cells
+func compatContents(contents []bool, width, height int) []bool {
+
+	result := make([]bool, len(contents))
+	for y := 0; y <= height; y++ {
+		for x := 0; x <= width; x++ {
+			result[y*(1+width)+x] = contents[y*width+x]
+		}
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// animations written in compatibility mode are the same written by v0.1. The
+// golden files in testdata were written by v0.1 with the same flags, where the
+// clock used for seeding its random number generator was replaced with the
+// seed given here
+func TestCompatV01(t *testing.T) {
+
+	dir := t.TempDir()
+	for _, test := range []struct {
+		golden string
+		args   []string
+	}{
+		{"v0.1-gradient.gif", []string{"-width", "30", "-height", "20", "-xratio", "3", "-yratio", "2",
+			"-population", "200", "-generations", "10", "-delay0", "50", "-delay", "5", "-average", "2",
+			"-model", "gradient #000000:#00ff00:#ff0000"}},
+		{"v0.1-radial.gif", []string{"-width", "24", "-height", "24", "-population", "150", "-generations", "8",
+			"-model", "radial #101010:#ffff00:#0000ff;12,12"}},
+	} {
+		name := filepath.Join(dir, test.golden)
+		args := append([]string{"-compat", "v0.1", "-seed", "20260101", "-filename", name}, test.args...)
+		if code, stderr := run(args...); code != EXIT_SUCCESS {
+			t.Fatalf("Run of %v = %v, want %v\n%s", test.golden, code, EXIT_SUCCESS, stderr)
+		}
+
+		contents, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", test.golden))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(contents, want) {
+			t.Errorf("The animation written with -compat v0.1 differs from %v (%v bytes, want %v)", test.golden, len(contents), len(want))
+		}
+	}
+}
+
+// flags and colour models added after v0.1 are rejected in compatibility mode
+func TestCompatRejects(t *testing.T) {
+
+	dir := t.TempDir()
+	for _, test := range []struct {
+		name string
+		args []string
+	}{
+		{"bichrome model", []string{"-model", "bichrome #ffffff"}},
+		{"rainbow model", []string{"-model", "rainbow #000000"}},
+		{"age model", []string{"-model", "age #000000:#00ff00:#ff0000"}},
+		{"model without colours", []string{"-model", "gradient"}},
+		{"logarithmic frames", []string{"-model", "gradient #000000:#00ff00:#ff0000", "-every", "log:2"}},
+		{"frame step", []string{"-model", "gradient #000000:#00ff00:#ff0000", "-frame-step", "2"}},
+		{"automaton", []string{"-model", "gradient #000000:#00ff00:#ff0000", "-automaton", "brain"}},
+		{"unknown version", []string{"-model", "gradient #000000:#00ff00:#ff0000", "-compat", "v0.0"}},
+	} {
+		name := filepath.Join(dir, "conway.gif")
+		args := append([]string{"-compat", "v0.1", "-width", "10", "-height", "10", "-generations", "2", "-filename", name}, test.args...)
+		if code, stderr := run(args...); code != EXIT_USAGE {
+			t.Errorf("Run with %v = %v, want %v\n%s", test.name, code, EXIT_USAGE, stderr)
+		}
+		if _, err := os.Stat(name); err == nil {
+			t.Errorf("Run with %v wrote an animation", test.name)
+			os.Remove(name)
+		}
+	}
+}
+
+// names of files are used literally in compatibility mode, instead of being
+// taken as templates
+func TestCompatFilename(t *testing.T) {
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "conway-{{.Seed}}.gif")
+	if code, stderr := run("-compat", "v0.1", "-seed", "3", "-filename", name,
+		"-width", "10", "-height", "10", "-generations", "2",
+		"-model", "gradient #000000:#00ff00:#ff0000"); code != EXIT_SUCCESS {
+		t.Fatalf("Run = %v, want %v\n%s", code, EXIT_SUCCESS, stderr)
+	}
+	if _, err := os.Stat(name); err != nil {
+		t.Errorf("The animation was not written to %v: %v", name, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "conway-3.gif")); err == nil {
+		t.Error("The name of the animation was taken as a template")
+	}
+}
+
+// animations larger than the maximum dimension of GIF files are never split
+// into tiles in compatibility mode, so that they can not be written
+func TestCompatNoTiles(t *testing.T) {
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "conway.gif")
+	code, stderr := run("-compat", "v0.1", "-filename", name,
+		"-width", "70000", "-height", "1", "-population", "0", "-generations", "2",
+		"-model", "gradient #000000:#00ff00:#00ff00")
+	if code != EXIT_FAILURE {
+		t.Errorf("Run = %v, want %v\n%s", code, EXIT_FAILURE, stderr)
+	}
+
+	tiles, err := filepath.Glob(filepath.Join(dir, "conway-*.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tiles) > 0 {
+		t.Errorf("The animation was split into tiles in compatibility mode: %v", tiles)
+	}
+}
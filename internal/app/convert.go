@@ -0,0 +1,149 @@
+// Conversion of pattern files
+//
+// Patterns can be translated between all formats acknowledged, so that the
+// program doubles as a converter of pattern files. The format of the input is
+// recognized automatically, and the format of the output is given by the
+// extension of its file unless it is given explicitly
+package app
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// types
+// ----------------------------------------------------------------------------
+
+// patternFormat
+//
+// a format of pattern files is given by the functions used for reading and
+// writing patterns, and whether it acknowledges rules
+type patternFormat struct {
+	load  func(io.Reader) (conway.Pattern, error)
+	store func(conway.Pattern, io.Writer) error
+	rules bool
+}
+
+// globals
+// ----------------------------------------------------------------------------
+
+// formats of pattern files acknowledged by convert
+var patternFormats = map[string]patternFormat{
+	"rle":       {conway.LoadRLE, conway.Pattern.ExportRLE, true},
+	"life105":   {conway.LoadLife105, conway.Pattern.ExportLife105, true},
+	"life106":   {conway.LoadLife106, conway.Pattern.ExportLife106, false},
+	"cells":     {conway.LoadPlaintext, conway.Pattern.ExportPlaintext, false},
+	"macrocell": {conway.LoadMacrocell, conway.Pattern.ExportMacrocell, true},
+}
+
+// formats of pattern files given by the extension of their files. Files with
+// the extension .lif are written in Life 1.06 format unless Life 1.05 is
+// requested
+var patternExtensions = map[string]string{
+	".rle":   "rle",
+	".lif":   "life106",
+	".life":  "life106",
+	".cells": "cells",
+	".mc":    "macrocell",
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// convert
+//
+// translate the pattern file given in args into the format given with -to, or
+// the one given by the extension of the output file otherwise. The format of
+// the input is recognized automatically unless it is given with -from. It
+// returns the exit code of the program
+func (a *App) convert(args []string) int {
+
+	var from, to, rule, name string
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.StringVar(&from, "from", "", a.tr("format of the input file, either rle, life105, life106, cells or macrocell. By default, it is recognized automatically"))
+	flags.StringVar(&to, "to", "", a.tr("format of the output file, either rle, life105, life106, cells or macrocell. By default, it is given by the extension of the output file: .rle, .lif, .life, .cells or .mc"))
+	flags.StringVar(&rule, "rule", "", a.tr("rule written in the output file instead of the one of the input file, if the format acknowledges rules"))
+	flags.StringVar(&name, "name", "", a.tr("name written in the output file instead of the one of the input file, if the format acknowledges names"))
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+	if flags.NArg() != 2 {
+		a.log.Printf(a.tr(" Usage: %v convert [-from FORMAT] [-to FORMAT] [-rule RULE] [-name NAME] INPUT-FILE OUTPUT-FILE"), program)
+		return EXIT_USAGE
+	}
+
+	// determine the formats of both files
+	if from != "" {
+		if _, ok := patternFormats[from]; !ok {
+			a.log.Printf(a.tr(" Unknown format of patterns '%v'"), from)
+			return EXIT_FAILURE
+		}
+	}
+	if to == "" {
+		to = patternExtensions[strings.ToLower(filepath.Ext(flags.Arg(1)))]
+		if to == "" {
+			a.log.Printf(a.tr(" The format of '%v' can not be recognized from its extension. Use -to"), flags.Arg(1))
+			return EXIT_FAILURE
+		}
+	}
+	format, ok := patternFormats[to]
+	if !ok {
+		a.log.Printf(a.tr(" Unknown format of patterns '%v'"), to)
+		return EXIT_FAILURE
+	}
+
+	// read the pattern
+	contents, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to read the pattern: %v"), err)
+		return EXIT_FAILURE
+	}
+	var pattern conway.Pattern
+	if from != "" {
+		pattern, err = patternFormats[from].load(bytes.NewReader(contents))
+	} else {
+		pattern, err = conway.LoadPattern(bytes.NewReader(contents))
+	}
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to read the pattern: %v"), err)
+		return EXIT_FAILURE
+	}
+	if rule != "" {
+		if _, err := conway.ParseLifeRule(rule); err != nil {
+			a.log.Printf(" %v", err)
+			return EXIT_FAILURE
+		}
+		pattern.Rule = rule
+	}
+	if name != "" {
+		pattern.Name = name
+	}
+	if parsed, err := conway.ParseLifeRule(pattern.Rule); !format.rules && pattern.Rule != "" &&
+		(err != nil || parsed.String() != conway.ConwayRule().String()) {
+		a.log.Printf(a.tr(" Warning: the rule %v is not written since the format %v does not acknowledge rules"), pattern.Rule, to)
+	}
+
+	// and write it
+	f, err := os.Create(flags.Arg(1))
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to write the pattern: %v"), err)
+		return EXIT_FAILURE
+	}
+	err = format.store(pattern, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to write the pattern: %v"), err)
+		return EXIT_FAILURE
+	}
+	a.log.Printf(a.tr(" Pattern written to '%v'"), flags.Arg(1))
+	return EXIT_SUCCESS
+}
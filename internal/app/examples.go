@@ -0,0 +1,78 @@
+// Curated examples
+//
+// The presets of the package examples can be run from the command line with
+// '-example NAME', and they are listed with '-example list'
+package app
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/clinaresl/conway-game/examples"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// runExample
+//
+// run the example with the given name and write it to the GIF file given with
+// -filename, whose placeholders are substituted with the values of the
+// example. If the name is 'list', all examples are shown instead. It returns
+// the exit code of the program
+func (a *App) runExample(name string) int {
+
+	if name == "list" {
+		writer := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, a.tr("NAME\tDESCRIPTION"))
+		for _, name := range examples.Names() {
+			config, _ := examples.Lookup(name)
+			fmt.Fprintf(writer, "%v\t%v\n", name, config.Description)
+		}
+		if err := writer.Flush(); err != nil {
+			a.log.Printf(" %v", err)
+			return EXIT_FAILURE
+		}
+		return EXIT_SUCCESS
+	}
+
+	config, err := examples.Lookup(name)
+	if err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+
+	// the seed is chosen here, so that it can be used in the name of the file
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	a.automaton, a.seed = "life", config.Seed
+	a.width, a.height, a.nbgenerations = config.Width, config.Height, config.Generations
+	a.rule, a.model = config.Rule, config.Model
+	if a.rule == "" {
+		a.rule = "B3/S23"
+	}
+	if a.model == "" {
+		a.model = "gradient"
+	}
+	filename, err := a.getFilename(a.filename)
+	if err != nil {
+		a.log.Printf(a.tr(" Wrong name of the GIF file: %v"), err)
+		return EXIT_FAILURE
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+	defer file.Close()
+	if err := config.Run(file); err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+	a.log.Printf(a.tr(" Example '%v' written to '%v'"), name, filename)
+	return EXIT_SUCCESS
+}
@@ -0,0 +1,145 @@
+// Pattern fetcher
+//
+// Patterns given with -pattern can also be downloaded, either from any URL
+// serving a pattern file or from the collection of patterns of LifeWiki by
+// name, e.g., wiki:puffer-train. Downloaded patterns are cached locally, so
+// that they are fetched only once
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// constants
+// ----------------------------------------------------------------------------
+
+// prefix of the names of patterns of LifeWiki
+const wikiPrefix = "wiki:"
+
+// location of the RLE files of all patterns of LifeWiki
+const wikiPatterns = "https://conwaylife.com/patterns/"
+
+// maximum time allowed for downloading a pattern
+const fetchTimeout = 30 * time.Second
+
+// maximum size of downloaded patterns in bytes
+const fetchLimit = 16 << 20
+
+// functions
+// ----------------------------------------------------------------------------
+
+// isRemotePattern
+//
+// return true if the given pattern has to be downloaded, i.e., if it is either
+// a URL or the name of a pattern of LifeWiki
+func isRemotePattern(spec string) bool {
+	return strings.HasPrefix(spec, "http://") ||
+		strings.HasPrefix(spec, "https://") ||
+		strings.HasPrefix(spec, wikiPrefix)
+}
+
+// patternURL
+//
+// return the URL of the given pattern. Names of patterns of LifeWiki are
+// resolved to their RLE files, which are named after the pattern in lowercase
+// without spaces, hyphens or underscores, e.g., wiki:puffer-train is served as
+// puffertrain.rle
+func (a *App) patternURL(spec string) (string, error) {
+
+	if !strings.HasPrefix(spec, wikiPrefix) {
+		return spec, nil
+	}
+	name := strings.ToLower(strings.TrimPrefix(spec, wikiPrefix))
+	name = strings.NewReplacer(" ", "", "-", "", "_", "").Replace(name)
+	if name == "" || strings.ContainsAny(name, "/?#") {
+		return "", fmt.Errorf(a.tr("Wrong name of a LifeWiki pattern '%v'"), spec)
+	}
+	return wikiPatterns + name + ".rle", nil
+}
+
+// patternCache
+//
+// return the name of the file where the pattern downloaded from the given URL
+// is cached, which is named after its hash in the cache directory of the user
+func patternCache(url string) (string, error) {
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "conway-game", "patterns",
+		fmt.Sprintf("%x.rle", sha256.Sum256([]byte(url)))), nil
+}
+
+// fetchPattern
+//
+// return the pattern given either as a URL or as the name of a pattern of
+// LifeWiki, along with an error if any is found. Patterns are taken from the
+// cache if they were downloaded before, and otherwise they are downloaded and
+// cached once they are known to be well formed. Failing to cache a pattern is
+// not an error
+func (a *App) fetchPattern(spec string) (conway.Pattern, error) {
+
+	url, err := a.patternURL(spec)
+	if err != nil {
+		return conway.Pattern{}, err
+	}
+	cache, cacheErr := patternCache(url)
+	if cacheErr == nil {
+		if contents, err := os.ReadFile(cache); err == nil {
+			return conway.LoadPattern(bytes.NewReader(contents))
+		}
+	}
+
+	client := http.Client{Timeout: fetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return conway.Pattern{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return conway.Pattern{}, fmt.Errorf(a.tr("It was not possible to download '%v': %v"), url, resp.Status)
+	}
+	contents, err := io.ReadAll(io.LimitReader(resp.Body, fetchLimit+1))
+	if err != nil {
+		return conway.Pattern{}, err
+	}
+	if len(contents) > fetchLimit {
+		return conway.Pattern{}, errors.New(a.tr("The pattern is too large"))
+	}
+	pattern, err := conway.LoadPattern(bytes.NewReader(contents))
+	if err != nil {
+		return conway.Pattern{}, err
+	}
+
+	// patterns are written to a temporary file first, so that concurrent runs
+	// never read partially written files
+	if cacheErr == nil {
+		if err := os.MkdirAll(filepath.Dir(cache), 0755); err == nil {
+			if f, err := os.CreateTemp(filepath.Dir(cache), "fetch-*"); err == nil {
+				_, err = f.Write(contents)
+				if cerr := f.Close(); err == nil {
+					err = cerr
+				}
+				if err == nil {
+					err = os.Rename(f.Name(), cache)
+				}
+				if err != nil {
+					os.Remove(f.Name())
+				}
+			}
+		}
+	}
+	return pattern, nil
+}
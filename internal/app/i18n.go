@@ -0,0 +1,97 @@
+// Localization
+//
+// All messages shown by the command line interface are written in English and
+// translated with message catalogs embedded in the binary. Catalogs are JSON
+// files in the directory locales/ named after the language they translate to,
+// e.g., es.json, which map every English message to its translation. Long
+// texts are stored in separate files named after the text and the language,
+// e.g., model-help.es.txt
+package app
+
+import (
+	"embed"
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// globals
+// ----------------------------------------------------------------------------
+
+//go:embed locales
+var locales embed.FS
+
+// functions
+// ----------------------------------------------------------------------------
+
+// getLanguage
+//
+// return the language requested by the user. Since messages are translated
+// before parsing the flags, the flag -lang is looked up directly in the given
+// arguments. If it is not given, the language is taken from the environment
+// variable LANG, and English is used by default
+func getLanguage(args []string) string {
+
+	for i, arg := range args {
+		for _, prefix := range []string{"-lang", "--lang"} {
+			if arg == prefix && i+1 < len(args) {
+				return args[i+1]
+			}
+			if strings.HasPrefix(arg, prefix+"=") {
+				return strings.TrimPrefix(arg, prefix+"=")
+			}
+		}
+	}
+
+	// LANG is given as language_TERRITORY.CODESET, e.g., es_ES.UTF-8
+	if lang := os.Getenv("LANG"); len(lang) >= 2 {
+		return strings.ToLower(lang[:2])
+	}
+	return "en"
+}
+
+// methods
+// ----------------------------------------------------------------------------
+
+// setLanguage
+//
+// set the language used for all messages of this application and load its
+// catalog. If there is no catalog for the given language, English is used
+// instead. Every application keeps its own language, so that applications run
+// concurrently do not interfere with each other
+func (a *App) setLanguage(lang string) {
+
+	a.language, a.catalog = "en", nil
+	contents, err := locales.ReadFile("locales/" + lang + ".json")
+	if err != nil {
+		return
+	}
+	var catalog map[string]string
+	if err := json.Unmarshal(contents, &catalog); err != nil {
+		return
+	}
+	a.language, a.catalog = lang, catalog
+}
+
+// tr
+//
+// return the translation of the given message into the language of this
+// application. If no translation is found, the message is returned
+func (a *App) tr(msg string) string {
+	if translation, ok := a.catalog[msg]; ok {
+		return translation
+	}
+	return msg
+}
+
+// trText
+//
+// return the long text with the given name in the language of this
+// application, or in English if it has not been translated
+func (a *App) trText(name string) string {
+	contents, err := locales.ReadFile("locales/" + name + "." + a.language + ".txt")
+	if err != nil {
+		contents, _ = locales.ReadFile("locales/" + name + ".en.txt")
+	}
+	return string(contents)
+}
@@ -0,0 +1,39 @@
+// Dictionary of objects
+//
+// The objects identified by the analyzer are listed with 'objects list', so
+// that users know which ones are acknowledged in every census
+package app
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// objects
+//
+// run the subcommand given in args over the dictionary of objects. Currently,
+// only 'list' is acknowledged, which shows the name, apgcode, period and
+// number of cells of every object. It returns the exit code of the program
+func (a *App) objects(args []string) int {
+
+	if len(args) != 1 || args[0] != "list" {
+		a.log.Printf(a.tr(" Usage: %v objects list"), program)
+		return EXIT_USAGE
+	}
+
+	writer := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, a.tr("NAME\tAPGCODE\tPERIOD\tCELLS"))
+	for _, object := range conway.NewDictionary().Objects() {
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\n", object.Name, object.Apgcode, object.Period, len(object.Cells))
+	}
+	if err := writer.Flush(); err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+	return EXIT_SUCCESS
+}
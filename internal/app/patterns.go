@@ -0,0 +1,327 @@
+// Pattern files
+//
+// Initial populations can be read from pattern files, and generations can be
+// written to them, so that they can be shared with other programs
+package app
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // PNG and GIF images are decoded by the rest of the package
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// globals
+// ----------------------------------------------------------------------------
+
+// formats of snapshots, given by the function used for writing patterns and
+// the extension of their files
+var snapshotFormats = map[string]struct {
+	export    func(conway.Pattern, io.Writer) error
+	extension string
+}{
+	"rle":       {conway.Pattern.ExportRLE, ".rle"},
+	"life106":   {conway.Pattern.ExportLife106, ".lif"},
+	"macrocell": {conway.Pattern.ExportMacrocell, ".mc"},
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// getPattern
+//
+// return the contents of the first generation with the pattern either read
+// from the file given with -pattern-file, in RLE, Life 1.06, Life 1.05 or
+// macrocell format, or given with -pattern either as the name of a pattern of
+// the library, an apgcode, a URL or the name of a pattern of LifeWiki, flipped
+// and rotated as given with -flip and -rotate, and placed at the center of the
+// grid, along with an error if any is found. Unless a rule is given in the
+// command line, the rule of Life-like automata is taken from the pattern, if
+// any
+func (a *App) getPattern() ([]bool, error) {
+
+	var pattern conway.Pattern
+	var err error
+	if isRemotePattern(a.pattern) {
+		pattern, err = a.fetchPattern(a.pattern)
+	} else if conway.IsApgcode(a.pattern) {
+		pattern, err = conway.DecodeApgcode(a.pattern)
+	} else if a.pattern != "" {
+		pattern, err = conway.LibraryPattern(a.pattern)
+	} else {
+		pattern, err = readPattern(a.patternfile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rotation, err := conway.NewRotation(a.rotate)
+	if err != nil {
+		return nil, err
+	}
+	flip, err := conway.NewFlip(a.flip)
+	if err != nil {
+		return nil, err
+	}
+	pattern = pattern.Transform(rotation, flip)
+
+	if pattern.Rule != "" && a.automaton == "life" && !a.isFlagSet("rule") {
+		a.rule = pattern.Rule
+	}
+	return pattern.Contents(a.width, a.height, image.Point{
+		X: (a.width - pattern.Width) / 2,
+		Y: (a.height - pattern.Height) / 2})
+}
+
+// getLayout
+//
+// return the layout read from the file given with -layout. Unless they are
+// given in the command line, the dimensions of the board and the rule of
+// Life-like automata are taken from the layout, if any
+func (a *App) getLayout() (conway.Layout, error) {
+
+	f, err := os.Open(a.layout)
+	if err != nil {
+		return conway.Layout{}, err
+	}
+	defer f.Close()
+	layout, err := conway.ReadLayout(f)
+	if err != nil {
+		return conway.Layout{}, err
+	}
+
+	if layout.Width > 0 && !a.isFlagSet("width") {
+		a.width = layout.Width
+	}
+	if layout.Height > 0 && !a.isFlagSet("height") {
+		a.height = layout.Height
+	}
+	if layout.Rule != "" && a.automaton == "life" && !a.isFlagSet("rule") {
+		a.rule = layout.Rule
+	}
+	return layout, nil
+}
+
+// getSeedImage
+//
+// return the image read from the file given with -seed-image. Unless it is
+// given in the command line, the height of the board is computed so that the
+// image keeps its proportions
+func (a *App) getSeedImage() (image.Image, error) {
+
+	f, err := os.Open(a.seedimage)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if bounds := img.Bounds(); !bounds.Empty() && !a.isFlagSet("height") {
+		a.height = max(1, (a.width*bounds.Dy()+bounds.Dx()/2)/bounds.Dx())
+	}
+	return img, nil
+}
+
+// readPattern
+//
+// return the pattern read from the file with the given name, whose format is
+// recognized automatically, along with an error if any is found
+func readPattern(filename string) (conway.Pattern, error) {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return conway.Pattern{}, err
+	}
+	defer f.Close()
+	return conway.LoadPattern(f)
+}
+
+// listPatterns
+//
+// show the name, dimensions and description of all patterns of the library.
+// It returns the exit code of the program
+func (a *App) listPatterns() int {
+
+	writer := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, a.tr("NAME\tSIZE\tDESCRIPTION"))
+	for _, name := range conway.LibraryNames() {
+		pattern, err := conway.LibraryPattern(name)
+		if err != nil {
+			a.log.Printf(" %v", err)
+			return EXIT_FAILURE
+		}
+		description := pattern.Name
+		if len(pattern.Comments) > 0 {
+			description = pattern.Comments[0]
+		}
+		fmt.Fprintf(writer, "%v\t%vx%v\t%v\n", name, pattern.Width, pattern.Height, description)
+	}
+	if err := writer.Flush(); err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+	return EXIT_SUCCESS
+}
+
+// writePattern
+//
+// write the last generation computed by the given game to the file with the
+// given name with the given export function, e.g., conway.Pattern.ExportRLE,
+// along with an error if any is found
+func writePattern(filename string, game *conway.Conway, export func(conway.Pattern, io.Writer) error) error {
+
+	pattern, err := game.Pattern(game.Last())
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return export(pattern, f)
+}
+
+// writeSVG
+//
+// write the last generation of the given game as an SVG image to the file with
+// the given name, where living cells are drawn with the shape given by name,
+// along with an error if any is found
+func writeSVG(filename, shape string, game *conway.Conway) error {
+
+	svgshape, err := conway.NewSVGShape(shape)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = game.ExportSVG(game.Last(), f, svgshape)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeFinalPNG
+//
+// write the frame of the last generation of the given game as a PNG image to
+// the file with the given name, along with an error if any is found
+func writeFinalPNG(filename string, game *conway.Conway) error {
+
+	img, err := game.Frame(game.Last())
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// getState
+//
+// return the state read from the file given with -resume. Unless they are
+// given in the command line, the dimensions of the board, the automaton, the
+// rule, the boundary, the lifespan and the seed are taken from the state
+func (a *App) getState() (*conway.State, error) {
+
+	f, err := os.Open(a.resume)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	state, err := conway.ReadState(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.isFlagSet("width") {
+		a.width = state.Width
+	}
+	if !a.isFlagSet("height") {
+		a.height = state.Height
+	}
+	if !a.isFlagSet("automaton") {
+		a.automaton = state.Automaton
+	}
+	if state.Rule != "" && !a.isFlagSet("rule") {
+		a.rule = state.Rule
+	}
+	if !a.isFlagSet("boundary") {
+		a.boundary = state.Boundary.String()
+	}
+	if !a.isFlagSet("lifespan") {
+		a.lifespan = state.Lifespan
+	}
+	if !a.isFlagSet("seed") {
+		a.seed = state.Seed
+	}
+	return &state, nil
+}
+
+// writeState
+//
+// write the state of the last generation computed by the given game to the
+// file with the given name, along with an error if any is found
+func writeState(filename string, game *conway.Conway) error {
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return game.Save(f)
+}
+
+// writeSnapshots
+//
+// write every given number of generations computed by the given game to the
+// directory with the given name, which is created if necessary, in the given
+// format. Files are named after the index of their generation counted from
+// the first generation of the first run, e.g., gen-000100.rle. It returns the
+// number of snapshots written, along with an error if any is found
+func writeSnapshots(dir string, every int, format string, game *conway.Conway) (int, error) {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	nbsnapshots := 0
+	for index := 0; index <= game.Last(); index += every {
+		pattern, err := game.Pattern(index)
+		if err != nil {
+			return nbsnapshots, err
+		}
+		name := filepath.Join(dir, fmt.Sprintf("gen-%06d%v", game.Offset()+index, snapshotFormats[format].extension))
+		f, err := os.Create(name)
+		if err != nil {
+			return nbsnapshots, err
+		}
+		err = snapshotFormats[format].export(pattern, f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nbsnapshots, err
+		}
+		nbsnapshots++
+	}
+	return nbsnapshots, nil
+}
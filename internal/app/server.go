@@ -0,0 +1,491 @@
+// Server mode
+//
+// Simulations are created with the same parameters given in the command line
+// and any of their generations can be requested on demand, so that web players
+// can seek arbitrary positions of the animation. Only keyframes are stored for
+// every simulation and any other generation is computed by replaying it from
+// the closest keyframe. Generations can also be served as previews, which are
+// downscaled so that they can be streamed quickly, and simulations can be
+// watched live as a motion JPEG while they are computed or streamed as deltas
+// over a WebSocket
+package app
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// constants
+// ----------------------------------------------------------------------------
+
+// default interval between keyframes
+const defaultKeyframes = 50
+
+// default downscaling factor of previews
+const defaultPreviewFactor = 4
+
+// default number of frames per second of streams
+const defaultStreamFPS = 10
+
+// quality of the JPEG images of streams, which is high so that cells look
+// crisp
+const streamQuality = 95
+
+// types
+// ----------------------------------------------------------------------------
+
+// server
+//
+// a server keeps track of all simulations created so far, identified by
+// consecutive numbers. Every simulation is created with a fresh copy of the
+// application which runs the server
+type server struct {
+	app         *App
+	keyframes   int
+	simulations map[string]*simulation
+	nbsims      int
+	mutex       sync.Mutex
+}
+
+// simulation
+//
+// a simulation served consists of a scrubber along with the seed used for
+// creating it
+type simulation struct {
+	ID          string `json:"id"`
+	Seed        int64  `json:"seed"`
+	Generations int    `json:"generations"`
+	scrubber    *conway.Scrubber
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// serve
+//
+// start a server with the arguments given in args. It returns the exit code
+// of the program
+func (a *App) serve(args []string) int {
+
+	var addr string
+	var keyframes int
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.StringVar(&addr, "addr", ":8080", a.tr("address the server listens to"))
+	flags.IntVar(&keyframes, "keyframes", defaultKeyframes, a.tr("number of generations between keyframes"))
+	if err := flags.Parse(args); err != nil {
+		return EXIT_FAILURE
+	}
+	if keyframes < 1 {
+		a.log.Print(a.tr(" The number of generations between keyframes must be strictly positive"))
+		return EXIT_FAILURE
+	}
+
+	s := &server{
+		app:         a,
+		keyframes:   keyframes,
+		simulations: make(map[string]*simulation)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/simulations", s.create)
+	mux.HandleFunc("/simulations/", s.route)
+
+	a.log.Printf(a.tr(" Listening on %v"), addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		a.log.Printf(a.tr(" The server stopped: %v"), err)
+		return EXIT_FAILURE
+	}
+	return EXIT_SUCCESS
+}
+
+// methods
+// ----------------------------------------------------------------------------
+
+// create
+//
+// create a new simulation with the parameters given in the query, which are
+// the same flags accepted in the command line, e.g., /simulations?width=100.
+// The identifier of the new simulation is returned in JSON format
+func (s *server) create(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var args []string
+	for name, values := range r.Form {
+		for _, value := range values {
+			args = append(args, fmt.Sprintf("-%v=%v", name, value))
+		}
+	}
+
+	// the parameters of every game are parsed by a fresh application so that
+	// games can be created concurrently. Errors are reported to the client
+	app := s.app.fresh()
+	app.flags.SetOutput(io.Discard)
+	if err := app.flags.Parse(args); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	game, err := app.newGame()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	scrubber, err := game.Scrubber(s.keyframes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.Lock()
+	s.nbsims++
+	sim := &simulation{
+		ID:          strconv.Itoa(s.nbsims),
+		Seed:        app.seed,
+		Generations: scrubber.Generations(),
+		scrubber:    scrubber}
+	s.simulations[sim.ID] = sim
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sim)
+}
+
+// route
+//
+// dispatch the requests to the resources of a simulation, which are given as
+// the last element of the path, e.g., /simulations/1/scrub
+func (s *server) route(w http.ResponseWriter, r *http.Request) {
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/scrub"):
+		s.scrub(w, r)
+	case strings.HasSuffix(r.URL.Path, "/preview"):
+		s.preview(w, r)
+	case strings.HasSuffix(r.URL.Path, "/stream"):
+		s.stream(w, r)
+	case strings.HasSuffix(r.URL.Path, "/ws"):
+		s.websocket(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// find
+//
+// return the simulation given in the path of a request to the given resource.
+// In case of error, it is reported to the client and false is returned
+func (s *server) find(w http.ResponseWriter, r *http.Request, resource string) (*simulation, bool) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	id, found := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/simulations/"), resource)
+	if !found {
+		http.NotFound(w, r)
+		return nil, false
+	}
+
+	s.mutex.Lock()
+	sim, ok := s.simulations[id]
+	s.mutex.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf(s.app.tr("Unknown simulation '%v'"), id), http.StatusNotFound)
+		return nil, false
+	}
+	return sim, true
+}
+
+// lookup
+//
+// return the simulation given in the path of a request to the given resource
+// along with the generation given in its query. In case of error, it is
+// reported to the client and false is returned
+func (s *server) lookup(w http.ResponseWriter, r *http.Request, resource string) (*simulation, int, bool) {
+
+	sim, ok := s.find(w, r, resource)
+	if !ok {
+		return nil, 0, false
+	}
+	gen, err := strconv.Atoi(r.URL.Query().Get("gen"))
+	if err != nil {
+		http.Error(w, s.app.tr("The generation must be an integer"), http.StatusBadRequest)
+		return nil, 0, false
+	}
+	return sim, gen, true
+}
+
+// scrub
+//
+// return the image of the generation given in the query of the simulation
+// given in the path, e.g., /simulations/1/scrub?gen=10, in PNG format. Since
+// generations never change, they can be cached by clients
+func (s *server) scrub(w http.ResponseWriter, r *http.Request) {
+
+	sim, gen, ok := s.lookup(w, r, "/scrub")
+	if !ok {
+		return
+	}
+	img, err := sim.scrubber.Generation(gen)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.encode(w, img)
+}
+
+// preview
+//
+// return a preview of the generation given in the query of the simulation
+// given in the path, where every block of cells is shown as a single pixel,
+// e.g., /simulations/1/preview?gen=10&factor=4&policy=any, in PNG format. By
+// default, blocks of 4x4 cells are shown alive if most of their cells are
+// alive
+func (s *server) preview(w http.ResponseWriter, r *http.Request) {
+
+	sim, gen, ok := s.lookup(w, r, "/preview")
+	if !ok {
+		return
+	}
+	factor := defaultPreviewFactor
+	if value := r.URL.Query().Get("factor"); value != "" {
+		var err error
+		if factor, err = strconv.Atoi(value); err != nil {
+			http.Error(w, s.app.tr("The downscaling factor must be an integer"), http.StatusBadRequest)
+			return
+		}
+	}
+	policy, err := s.app.getDownscalePolicy(r.URL.Query().Get("policy"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	img, err := sim.scrubber.Preview(gen, factor, policy)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.encode(w, img)
+}
+
+// pace
+//
+// return the first generation streamed and the number of frames per second
+// given in the query of a request to stream the given simulation, 0 and
+// defaultStreamFPS by default. In case of error, it is reported to the client
+// and false is returned
+func (s *server) pace(w http.ResponseWriter, r *http.Request, sim *simulation) (int, int, bool) {
+
+	from, fps := 0, defaultStreamFPS
+	for name, value := range map[string]*int{"from": &from, "fps": &fps} {
+		if query := r.URL.Query().Get(name); query != "" {
+			var err error
+			if *value, err = strconv.Atoi(query); err != nil {
+				http.Error(w, fmt.Sprintf(s.app.tr("The parameter '%v' must be an integer"), name), http.StatusBadRequest)
+				return 0, 0, false
+			}
+		}
+	}
+	if fps < 1 {
+		http.Error(w, s.app.tr("The number of frames per second must be at least 1"), http.StatusBadRequest)
+		return 0, 0, false
+	}
+	if from < 0 || from >= sim.Generations {
+		http.Error(w, fmt.Sprintf(s.app.tr("Generation %v out of range [0, %v)"), from, sim.Generations), http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return from, fps, true
+}
+
+// stream
+//
+// stream the generations of the simulation given in the path as a motion JPEG
+// (MJPEG), starting from the generation given in the query (0 by default) at
+// the number of frames per second given in the query (10 by default), e.g.,
+// /simulations/1/stream?from=100&fps=25. Generations are computed as they are
+// streamed, so that they can be watched live in a browser, e.g., in an img
+// element. The stream ends after the last generation or when the client
+// disconnects
+func (s *server) stream(w http.ResponseWriter, r *http.Request) {
+
+	sim, ok := s.find(w, r, "/stream")
+	if !ok {
+		return
+	}
+	from, fps, ok := s.pace(w, r, sim)
+	if !ok {
+		return
+	}
+
+	// every generation is sent as a separate part which replaces the previous
+	// one
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mw.Boundary())
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+	for gen := from; gen < sim.Generations; gen++ {
+		img, err := sim.scrubber.Generation(gen)
+		if err != nil {
+			s.app.log.Printf(s.app.tr(" It was not possible to encode the generation: %v"), err)
+			return
+		}
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"image/jpeg"},
+			"X-Generation": {strconv.Itoa(gen)}})
+		if err != nil {
+			return
+		}
+		if err := jpeg.Encode(part, img, &jpeg.Options{Quality: streamQuality}); err != nil {
+			s.app.log.Printf(s.app.tr(" It was not possible to encode the generation: %v"), err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+	mw.Close()
+}
+
+// websocket
+//
+// stream the generations of the simulation given in the path over a
+// WebSocket, starting from the generation given in the query at the number of
+// frames per second given in the query as the motion JPEG does, e.g.,
+// /simulations/1/ws?from=100&fps=25&format=binary. Every generation is sent
+// as a separate message with the cells born and those that died since the
+// previous one, either in JSON (by default) or in binary format. The
+// WebSocket is closed after the last generation or when the client closes it
+func (s *server) websocket(w http.ResponseWriter, r *http.Request) {
+
+	sim, ok := s.find(w, r, "/ws")
+	if !ok {
+		return
+	}
+	from, fps, ok := s.pace(w, r, sim)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "binary" {
+		http.Error(w, fmt.Sprintf(s.app.tr("Unknown format of deltas '%v'"), format), http.StatusBadRequest)
+		return
+	}
+	conn, rw, err := s.upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// messages sent by the client are read concurrently, so that the stream
+	// stops as soon as it is closed. Pings are answered by the main loop
+	closed, pings := make(chan struct{}), make(chan []byte, 1)
+	go func() {
+		defer close(closed)
+		for {
+			opcode, payload, err := s.readWebSocket(rw.Reader)
+			if err != nil || opcode == websocketClose {
+				return
+			}
+			if opcode == websocketPing {
+				select {
+				case pings <- payload:
+				default:
+				}
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+	var previous []image.Point
+	for gen := from; gen < sim.Generations; gen++ {
+		current, err := sim.scrubber.Alive(gen)
+		if err != nil {
+			s.app.log.Printf(" %v", err)
+			break
+		}
+		delta := generationDelta{Generation: gen}
+		if gen == from {
+			delta.Width, delta.Height = sim.scrubber.Dimensions()
+		}
+		delta.Born, delta.Died = diffCells(previous, current)
+		previous = current
+
+		if format == "binary" {
+			err = writeWebSocket(rw.Writer, websocketBinary, encodeDelta(delta))
+		} else {
+			var message []byte
+			message, err = json.Marshal(delta)
+			if err == nil {
+				err = writeWebSocket(rw.Writer, websocketText, message)
+			}
+		}
+		if err != nil {
+			return
+		}
+
+		for waiting := true; waiting; {
+			select {
+			case <-closed:
+				writeWebSocket(rw.Writer, websocketClose, nil)
+				return
+			case payload := <-pings:
+				if writeWebSocket(rw.Writer, websocketPong, payload) != nil {
+					return
+				}
+			case <-ticker.C:
+				waiting = false
+			}
+		}
+	}
+
+	// the normal closure is acknowledged by the client before closing the
+	// connection
+	writeWebSocket(rw.Writer, websocketClose, binary.BigEndian.AppendUint16(nil, 1000))
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+	}
+}
+
+// encode
+//
+// write the given image of a generation in PNG format. Since generations never
+// change, they can be cached by clients
+func (s *server) encode(w http.ResponseWriter, img image.Image) {
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if err := png.Encode(w, img); err != nil {
+		s.app.log.Printf(s.app.tr(" It was not possible to encode the generation: %v"), err)
+	}
+}
@@ -0,0 +1,116 @@
+// Terminal renderer
+//
+// Runs can be watched directly in the terminal with -render terminal, where
+// every generation is printed to the standard output with block characters
+// and ANSI colours. Every character shows two cells, one above the other, so
+// that cells look square in most terminals
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"time"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// constants
+// ----------------------------------------------------------------------------
+
+// escape sequences used for moving the cursor to the top left corner, hiding
+// and showing it, and resetting all colours
+const (
+	ansiHome       = "\x1b[H"
+	ansiClear      = "\x1b[2J"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+	ansiReset      = "\x1b[0m"
+)
+
+// block character whose upper half is drawn with the foreground colour and
+// whose lower half is drawn with the background colour
+const upperHalfBlock = "▀"
+
+// functions
+// ----------------------------------------------------------------------------
+
+// ansiColor
+//
+// return the escape sequence that sets the given colour in 24 bits either as
+// the foreground or as the background colour
+func ansiColor(c color.Color, background bool) string {
+
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	layer := 38
+	if background {
+		layer = 48
+	}
+	return fmt.Sprintf("\x1b[%v;2;%v;%v;%vm", layer, rgba.R, rgba.G, rgba.B)
+}
+
+// drawTerminal
+//
+// draw the given image over the previous contents of the terminal with two
+// pixels per character. Colours are changed only when needed, so that images
+// are printed quickly
+func drawTerminal(out io.Writer, img *image.Paletted) {
+
+	fmt.Fprint(out, ansiHome)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		upper, lower := -1, -1
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+
+			// images with an odd number of rows show the last one over the
+			// colour of dead cells
+			u, l := int(img.ColorIndexAt(x, y)), 0
+			if y+1 < bounds.Max.Y {
+				l = int(img.ColorIndexAt(x, y+1))
+			}
+			if u != upper {
+				fmt.Fprint(out, ansiColor(img.Palette[u], false))
+				upper = u
+			}
+			if l != lower {
+				fmt.Fprint(out, ansiColor(img.Palette[l], true))
+				lower = l
+			}
+			fmt.Fprint(out, upperHalfBlock)
+		}
+		fmt.Fprint(out, ansiReset+"\r\n")
+	}
+}
+
+// renderTerminal
+//
+// print every generation of the given game chosen by its frame selector, if
+// any, to the given writer with the given number of frames per second. Every
+// frame is drawn over the previous one
+func (a *App) renderTerminal(w io.Writer, game *conway.Conway, fps int) error {
+
+	out := bufio.NewWriter(w)
+	fmt.Fprint(out, ansiClear+ansiHideCursor)
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+	selector := game.FrameSelector()
+	for index := 0; index <= game.Last(); index++ {
+		if selector != nil && !selector(index) {
+			continue
+		}
+		img, err := game.Preview(index, 1, conway.MajorityPolicy)
+		if err != nil {
+			return err
+		}
+		drawTerminal(out, img)
+		fmt.Fprintf(out, a.tr("Generation %v/%v"), game.Offset()+index, game.Offset()+game.Last())
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		<-ticker.C
+	}
+	fmt.Fprint(out, ansiReset+ansiShowCursor+"\n")
+	return out.Flush()
+}
@@ -0,0 +1,123 @@
+// Multi-resolution tiles
+//
+// A generation of a game is cut into tiles at multiple resolutions which are
+// written as OUT/ZOOM/X/Y.png, following the layout of slippy maps, so that
+// web viewers can pan and zoom very large boards. The game is created with the
+// same flags accepted in the command line
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// constants
+// ----------------------------------------------------------------------------
+
+// default size of tiles, in pixels
+const defaultTileSize = 256
+
+// types
+// ----------------------------------------------------------------------------
+
+// tileIndex
+//
+// the index of the tiles written, which tells viewers the range of zoom
+// levels available and the simulation they come from
+type tileIndex struct {
+	Generation int   `json:"generation"`
+	Seed       int64 `json:"seed"`
+	TileSize   int   `json:"tileSize"`
+	MinZoom    int   `json:"minZoom"`
+	MaxZoom    int   `json:"maxZoom"`
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// tiles
+//
+// simulate the game given in args and write the tiles of the generation
+// requested with -gen into the directory given with -out. It returns the exit
+// code of the program
+func (a *App) tiles(args []string) int {
+
+	var gen, size int
+	var out, policy string
+	app := a.fresh()
+	app.flags.IntVar(&gen, "gen", 0, a.tr("generation whose tiles are written, starting from 0"))
+	app.flags.StringVar(&out, "out", "tiles", a.tr("directory where tiles are written as ZOOM/X/Y.png, along with an index in tiles.json"))
+	app.flags.IntVar(&size, "tile-size", defaultTileSize, a.tr("size of tiles in pixels"))
+	app.flags.StringVar(&policy, "policy", "any", a.tr("policy used for downscaling tiles at lower zoom levels: majority (blocks are alive if most of their cells are alive) or any (blocks are alive if any of their cells is alive)"))
+	if err := app.flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return EXIT_SUCCESS
+		}
+		return EXIT_USAGE
+	}
+	if app.flags.NArg() != 0 {
+		a.log.Printf(a.tr(" Usage: %v tiles -gen GENERATION -out DIRECTORY [FLAGS]"), program)
+		return EXIT_USAGE
+	}
+	if gen < 0 {
+		a.log.Printf(a.tr(" Wrong generation '%v'"), gen)
+		return EXIT_FAILURE
+	}
+	p, err := a.getDownscalePolicy(policy)
+	if err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+
+	// simulate the game at least up to the requested generation
+	app.nbgenerations = max(app.nbgenerations, gen+1)
+	game, err := app.newGame()
+	if err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+	game.Run()
+
+	// and write all tiles
+	zoom, err := game.Tiles(gen, size, p, func(tile conway.Tile) error {
+		dir := filepath.Join(out, strconv.Itoa(tile.Zoom), strconv.Itoa(tile.X))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		f, err := os.Create(filepath.Join(dir, strconv.Itoa(tile.Y)+".png"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return png.Encode(f, tile.Image)
+	})
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to write the tiles: %v"), err)
+		return EXIT_FAILURE
+	}
+
+	// along with their index
+	index, err := json.MarshalIndent(tileIndex{
+		Generation: gen,
+		Seed:       app.seed,
+		TileSize:   size,
+		MinZoom:    0,
+		MaxZoom:    zoom}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(out, "tiles.json"), append(index, '\n'), 0o644)
+	}
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to write the tiles: %v"), err)
+		return EXIT_FAILURE
+	}
+	fmt.Fprintf(a.stdout, a.tr(" Tiles of generation %v written to %v (zoom levels 0-%v)\n"), gen, out, zoom)
+	return EXIT_SUCCESS
+}
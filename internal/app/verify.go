@@ -0,0 +1,124 @@
+// Verification of animations
+//
+// The checksums of all frames of an animation are stored along with the seed
+// and arguments used for generating it. An animation is verified by comparing
+// the checksums of its frames with those stored, and also with those obtained
+// by re-simulating the game
+package app
+
+import (
+	"image/gif"
+	"os"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// functions
+// ----------------------------------------------------------------------------
+
+// writeChecksums
+//
+// write the checksums of all frames of the given animation to the file with
+// the given name, along with the seed and arguments used for generating it
+func writeChecksums(filename string, args []string, seed int64, anim *gif.GIF) error {
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	checksums := conway.Checksums{
+		Seed:   seed,
+		Args:   args,
+		Frames: conway.GIFChecksums(anim)}
+	return checksums.Write(f)
+}
+
+// compareChecksums
+//
+// return the index of the first frame whose checksums differ, or -1 if all of
+// them are equal. If the number of frames differ, the index returned is the
+// length of the shortest slice
+func compareChecksums(expected, actual []string) int {
+
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		if expected[i] != actual[i] {
+			return i
+		}
+	}
+	if len(expected) != len(actual) {
+		if len(expected) < len(actual) {
+			return len(expected)
+		}
+		return len(actual)
+	}
+	return -1
+}
+
+// verify
+//
+// verify that the GIF file and the checksums file given in args match, and
+// that re-simulating the game with the seed and arguments stored in the
+// checksums file produces the same frames. It returns the exit code of the
+// program
+func (a *App) verify(args []string) int {
+
+	if len(args) != 2 {
+		a.log.Printf(a.tr(" Usage: %v verify GIF-FILE SUMS-FILE"), program)
+		return EXIT_FAILURE
+	}
+
+	// read the checksums
+	f, err := os.Open(args[1])
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to open the checksums: %v"), err)
+		return EXIT_FAILURE
+	}
+	defer f.Close()
+	checksums, err := conway.ReadChecksums(f)
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to read the checksums: %v"), err)
+		return EXIT_FAILURE
+	}
+
+	// and the animation
+	g, err := os.Open(args[0])
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to open the animation: %v"), err)
+		return EXIT_FAILURE
+	}
+	defer g.Close()
+	anim, err := gif.DecodeAll(g)
+	if err != nil {
+		a.log.Printf(a.tr(" It was not possible to decode the animation: %v"), err)
+		return EXIT_FAILURE
+	}
+
+	// first, verify that the animation matches the checksums
+	if i := compareChecksums(checksums.Frames, conway.GIFChecksums(anim)); i >= 0 {
+		a.log.Printf(a.tr(" The animation does not match the checksums: frame %v differs"), i)
+		return EXIT_FAILURE
+	}
+	a.log.Printf(a.tr(" The animation matches the checksums (%v frames)"), len(checksums.Frames))
+
+	// second, re-simulate the game with the same seed and arguments
+	replay := a.fresh()
+	if err := replay.flags.Parse(checksums.Args); err != nil {
+		a.log.Printf(a.tr(" Wrong arguments in the checksums: %v"), err)
+		return EXIT_FAILURE
+	}
+	replay.seed = checksums.Seed
+	_, simulated, err := replay.simulate()
+	if err != nil {
+		a.log.Printf(" %v", err)
+		return EXIT_FAILURE
+	}
+	if i := compareChecksums(checksums.Frames, conway.GIFChecksums(replay.optimized(&simulated))); i >= 0 {
+		a.log.Printf(a.tr(" The simulation with seed %v does not match the checksums: frame %v differs"), replay.seed, i)
+		return EXIT_FAILURE
+	}
+	a.log.Printf(a.tr(" The simulation with seed %v matches the checksums"), replay.seed)
+
+	return EXIT_SUCCESS
+}
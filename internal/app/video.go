@@ -0,0 +1,116 @@
+// Video export
+//
+// Animations can be written as MP4 or WebM videos, which are an order of
+// magnitude smaller than GIF files for long runs. Frames are composited and
+// piped raw to ffmpeg, which has to be installed separately
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"os/exec"
+	"strings"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// globals
+// ----------------------------------------------------------------------------
+
+// arguments given to ffmpeg for encoding every format of videos. Dimensions
+// are padded to even numbers, as required by the chroma subsampling of most
+// players
+var videoCodecs = map[string][]string{
+	"mp4":  {"-c:v", "libx264", "-pix_fmt", "yuv420p", "-movflags", "+faststart"},
+	"webm": {"-c:v", "libvpx-vp9", "-pix_fmt", "yuv420p", "-b:v", "0", "-crf", "30"},
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// gcd
+//
+// return the greatest common divisor of the given numbers
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// encodeVideo
+//
+// write the given animation to the file with the given name as a video in the
+// given format, either mp4 or webm, using the given ffmpeg binary. Videos are
+// shown at a constant frame rate, so that every frame is repeated as many
+// times as needed to respect its delay. Transparent areas are shown black
+func (a *App) encodeVideo(name, format, ffmpeg string, anim *gif.GIF) error {
+
+	codec, ok := videoCodecs[format]
+	if !ok {
+		return fmt.Errorf(a.tr("Unknown format of videos '%v'"), format)
+	}
+	if len(anim.Image) == 0 {
+		return errors.New(a.tr("The animation has no frames"))
+	}
+
+	// the duration of every frame of the video is the greatest common divisor
+	// of all delays, in 100th of a second
+	unit := 0
+	for _, delay := range anim.Delay {
+		unit = gcd(max(delay, 1), unit)
+	}
+	unit = max(unit, 1)
+
+	bounds := anim.Image[0].Rect
+	if anim.Config.Width > 0 && anim.Config.Height > 0 {
+		bounds = image.Rect(0, 0, anim.Config.Width, anim.Config.Height)
+	}
+	args := []string{"-y", "-loglevel", "error",
+		"-f", "rawvideo", "-pix_fmt", "rgba",
+		"-s", fmt.Sprintf("%vx%v", bounds.Dx(), bounds.Dy()),
+		"-framerate", fmt.Sprintf("100/%v", unit),
+		"-i", "-",
+		"-vf", "pad=ceil(iw/2)*2:ceil(ih/2)*2"}
+	args = append(append(args, codec...), name)
+
+	cmd := exec.Command(ffmpeg, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf(a.tr("It was not possible to run '%v': %v"), ffmpeg, err)
+	}
+
+	// frames are written over an opaque background
+	frame := image.NewNRGBA(bounds)
+	background := image.NewUniform(image.Black)
+	err = conway.ComposeGIF(anim, func(index int, img *image.NRGBA) error {
+		draw.Draw(frame, frame.Rect, background, image.Point{}, draw.Src)
+		draw.Draw(frame, frame.Rect, img, img.Rect.Min, draw.Over)
+		delay := unit
+		if index < len(anim.Delay) {
+			delay = max(anim.Delay[index], 1)
+		}
+		for i := 0; i < delay/unit; i++ {
+			if _, err := stdin.Write(frame.Pix); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if cerr := stdin.Close(); err == nil {
+		err = cerr
+	}
+	if werr := cmd.Wait(); werr != nil {
+		return fmt.Errorf(a.tr("ffmpeg failed (%v): %v"), werr, strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
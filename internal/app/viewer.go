@@ -0,0 +1,265 @@
+// Interactive viewer
+//
+// Runs can be explored interactively in the terminal with -render tui: they
+// can be played and paused, stepped forward and backward one generation at a
+// time, played faster or slower, and any generation can be jumped to. Games
+// are simulated on demand with a scrubber, so that the viewer starts right
+// away. The terminal is switched to raw mode with stty, so that keys are read
+// as soon as they are pressed
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// constants
+// ----------------------------------------------------------------------------
+
+// number of generations between consecutive keyframes of the viewer
+const viewerKeyframes = 100
+
+// maximum number of generations per second shown by the viewer
+const viewerMaxFPS = 120
+
+// escape sequence used for clearing the rest of the line
+const ansiClearLine = "\x1b[K"
+
+// keys recognised by the viewer which are given as escape sequences
+const (
+	keyRight = "\x1b[C"
+	keyLeft  = "\x1b[D"
+	keyHome  = "\x1b[H"
+	keyEnd   = "\x1b[F"
+)
+
+// types
+// ----------------------------------------------------------------------------
+
+// viewer
+//
+// state of the interactive viewer: the application it belongs to, the
+// generation shown, whether it is being played and how fast, and the
+// generation typed by the user, if any, while jumping
+type viewer struct {
+	app      *App
+	scrubber *conway.Scrubber
+	index    int
+	playing  bool
+	fps      int
+	jumping  bool
+	target   string
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// rawTerminal
+//
+// switch the terminal attached to the standard input to raw mode without echo
+// and return a function that restores its previous state
+func (a *App) rawTerminal() (func(), error) {
+
+	stty := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		return cmd.Output()
+	}
+	state, err := stty("-g")
+	if err != nil {
+		return nil, errors.New(a.tr("The interactive viewer requires a terminal"))
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, fmt.Errorf(a.tr("It was not possible to set up the terminal: %v"), err)
+	}
+	return func() {
+		stty(strings.TrimSpace(string(state)))
+	}, nil
+}
+
+// readKeys
+//
+// return a channel where every key read from the standard input is sent,
+// either as a single character or as a whole escape sequence. The channel is
+// closed when the standard input is closed
+func readKeys() <-chan string {
+
+	keys := make(chan string)
+	go func() {
+		defer close(keys)
+		buffer := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buffer)
+			if err != nil {
+				return
+			}
+			input := string(buffer[:n])
+			for input != "" {
+				length := 1
+				for _, sequence := range []string{keyRight, keyLeft, keyHome, keyEnd} {
+					if strings.HasPrefix(input, sequence) {
+						length = len(sequence)
+					}
+				}
+				keys <- input[:length]
+				input = input[length:]
+			}
+		}
+	}()
+	return keys
+}
+
+// methods
+// ----------------------------------------------------------------------------
+
+// draw
+//
+// draw the generation currently shown along with a status line and the keys
+// available
+func (v *viewer) draw(out *bufio.Writer) error {
+
+	img, err := v.scrubber.Preview(v.index, 1, conway.MajorityPolicy)
+	if err != nil {
+		return err
+	}
+	drawTerminal(out, img)
+
+	state := v.app.tr("paused")
+	if v.playing {
+		state = v.app.tr("playing")
+	}
+	fmt.Fprintf(out, v.app.tr("Generation %v/%v"), v.index, v.scrubber.Generations()-1)
+	fmt.Fprintf(out, " | %v | %v fps", state, v.fps)
+	if v.jumping {
+		fmt.Fprintf(out, " | %v%v", v.app.tr("go to generation: "), v.target)
+	}
+	fmt.Fprint(out, ansiClearLine+"\r\n")
+	fmt.Fprint(out, v.app.tr("[space] play/pause  [n/→] step  [p/←] back  [+/-] speed  [g] go to  [Home/End] first/last  [q] quit"))
+	fmt.Fprint(out, ansiClearLine)
+	return out.Flush()
+}
+
+// seek
+//
+// show the generation with the given index, which is clamped to the range of
+// generations of the game. Playing stops at the last generation
+func (v *viewer) seek(index int) {
+
+	last := v.scrubber.Generations() - 1
+	v.index = max(0, min(index, last))
+	if v.index == last {
+		v.playing = false
+	}
+}
+
+// handle
+//
+// update the state of the viewer with the given key and return false if the
+// viewer has to quit
+func (v *viewer) handle(key string) bool {
+
+	// while jumping, keys are used for typing the generation
+	if v.jumping {
+		switch {
+		case key >= "0" && key <= "9":
+			v.target += key
+		case (key == "\x7f" || key == "\b") && v.target != "":
+			v.target = v.target[:len(v.target)-1]
+		case key == "\r" || key == "\n":
+			v.jumping = false
+			if index, err := strconv.Atoi(v.target); err == nil {
+				v.seek(index)
+			}
+		case key == "\x1b" || key == "\x03":
+			v.jumping = false
+		}
+		return true
+	}
+
+	switch key {
+	case "q", "Q", "\x03", "\x04":
+		return false
+	case " ":
+		v.playing = !v.playing && v.index < v.scrubber.Generations()-1
+	case "n", keyRight:
+		v.playing = false
+		v.seek(v.index + 1)
+	case "p", keyLeft:
+		v.playing = false
+		v.seek(v.index - 1)
+	case "+", "=":
+		v.fps = min(2*v.fps, viewerMaxFPS)
+	case "-", "_":
+		v.fps = max(v.fps/2, 1)
+	case "g", "G":
+		v.jumping, v.target = true, ""
+	case keyHome:
+		v.seek(0)
+	case keyEnd:
+		v.seek(v.scrubber.Generations() - 1)
+	}
+	return true
+}
+
+// explore
+//
+// explore the given game interactively in the terminal starting at the given
+// number of generations per second, until the user quits
+func (a *App) explore(game *conway.Conway, fps int) error {
+
+	scrubber, err := game.Scrubber(viewerKeyframes)
+	if err != nil {
+		return err
+	}
+	restore, err := a.rawTerminal()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	out := bufio.NewWriter(a.stdout)
+	fmt.Fprint(out, ansiClear+ansiHideCursor)
+	defer func() {
+		fmt.Fprint(out, ansiReset+ansiShowCursor+"\r\n")
+		out.Flush()
+	}()
+
+	v := &viewer{app: a, scrubber: scrubber, fps: fps}
+	keys := readKeys()
+	ticker := time.NewTicker(time.Second / time.Duration(v.fps))
+	defer ticker.Stop()
+	for {
+		if err := v.draw(out); err != nil {
+			return err
+		}
+
+		// wait until either a key is pressed or the next generation has to
+		// be shown, if the game is being played
+		for waiting := true; waiting; {
+			select {
+			case key, ok := <-keys:
+				fps := v.fps
+				if !ok || !v.handle(key) {
+					return nil
+				}
+				if v.fps != fps {
+					ticker.Reset(time.Second / time.Duration(v.fps))
+				}
+				waiting = false
+			case <-ticker.C:
+				if v.playing {
+					v.seek(v.index + 1)
+					waiting = false
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// WebSockets
+//
+// Generations can be streamed over a WebSocket as deltas, i.e., the cells born
+// and those that died with respect to the previous generation sent, so that
+// web frontends can render games at full resolution on the client side. Only
+// the part of the protocol (RFC 6455) needed by the server is implemented:
+// the handshake, unfragmented messages sent to clients and the control
+// messages received from them
+package app
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// constants
+// ----------------------------------------------------------------------------
+
+// identifier appended to the key of clients for accepting their handshake
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// operation codes of the messages used by the server
+const (
+	websocketText   = 0x1
+	websocketBinary = 0x2
+	websocketClose  = 0x8
+	websocketPing   = 0x9
+	websocketPong   = 0xa
+)
+
+// largest control message accepted from clients
+const websocketMaxControl = 125
+
+// types
+// ----------------------------------------------------------------------------
+
+// generationDelta
+//
+// changes of a generation with respect to the previous one sent. The first
+// message of every stream also carries the dimensions of the board, and all
+// the living cells of its first generation are given as born
+type generationDelta struct {
+	Generation int      `json:"gen"`
+	Width      int      `json:"width,omitempty"`
+	Height     int      `json:"height,omitempty"`
+	Born       [][2]int `json:"born"`
+	Died       [][2]int `json:"died"`
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// upgradeWebSocket
+//
+// accept the WebSocket handshake of the given request and return the
+// connection hijacked from the HTTP server. In case of error, it is reported
+// to the client
+func (s *server) upgradeWebSocket(w http.ResponseWriter, r *http.Request) (net.Conn, *bufio.ReadWriter, error) {
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		!strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") || key == "" {
+		err := errors.New(s.app.tr("A WebSocket handshake was expected"))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, err
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		err := errors.New(s.app.tr("Unsupported version of the WebSocket protocol"))
+		http.Error(w, err.Error(), http.StatusUpgradeRequired)
+		return nil, nil, err
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		err := errors.New(s.app.tr("The connection can not be upgraded to a WebSocket"))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, nil, err
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	digest := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %v\r\n\r\n",
+		base64.StdEncoding.EncodeToString(digest[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, rw, nil
+}
+
+// writeWebSocket
+//
+// write a single message with the given operation code and payload. Messages
+// sent by servers are not masked
+func writeWebSocket(w *bufio.Writer, opcode byte, payload []byte) error {
+
+	header := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		header = append(header, byte(len(payload)))
+	case len(payload) <= 0xffff:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(len(payload)))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(len(payload)))
+	}
+	w.Write(header)
+	w.Write(payload)
+	return w.Flush()
+}
+
+// readWebSocket
+//
+// read the next message sent by a client and return its operation code and
+// payload, which is unmasked. Messages sent by clients must be masked
+func (s *server) readWebSocket(r *bufio.Reader) (byte, []byte, error) {
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	opcode, masked := header[0]&0x0f, header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		if _, err := io.ReadFull(r, extended); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		if _, err := io.ReadFull(r, extended); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if !masked {
+		return 0, nil, errors.New(s.app.tr("Messages sent by clients must be masked"))
+	}
+
+	// messages other than control messages are not used, and they are
+	// discarded
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(r, mask); err != nil {
+		return 0, nil, err
+	}
+	if opcode < websocketClose || length > websocketMaxControl {
+		_, err := io.CopyN(io.Discard, r, int64(length))
+		return opcode, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// diffCells
+//
+// return the cells born and those that died in the given generation with
+// respect to the previous one. Both are sorted by rows and then by columns as
+// the living cells given are
+func diffCells(previous, current []image.Point) (born, died [][2]int) {
+
+	less := func(p, q image.Point) bool {
+		return p.Y < q.Y || (p.Y == q.Y && p.X < q.X)
+	}
+	born, died = [][2]int{}, [][2]int{}
+	i, j := 0, 0
+	for i < len(previous) || j < len(current) {
+		switch {
+		case j == len(current) || (i < len(previous) && less(previous[i], current[j])):
+			died = append(died, [2]int{previous[i].X, previous[i].Y})
+			i++
+		case i == len(previous) || less(current[j], previous[i]):
+			born = append(born, [2]int{current[j].X, current[j].Y})
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return born, died
+}
+
+// encodeDelta
+//
+// return the given delta in binary format: the generation, the width and
+// height of the board (only in the first message, and 0 otherwise), the
+// number of cells born and the number of cells that died followed by the
+// coordinates of all of them, all given as unsigned integers of 32 bits in
+// little endian
+func encodeDelta(delta generationDelta) []byte {
+
+	result := make([]byte, 0, 20+8*(len(delta.Born)+len(delta.Died)))
+	for _, value := range []int{delta.Generation, delta.Width, delta.Height, len(delta.Born), len(delta.Died)} {
+		result = binary.LittleEndian.AppendUint32(result, uint32(value))
+	}
+	for _, cells := range [][][2]int{delta.Born, delta.Died} {
+		for _, cell := range cells {
+			result = binary.LittleEndian.AppendUint32(result, uint32(cell[0]))
+			result = binary.LittleEndian.AppendUint32(result, uint32(cell[1]))
+		}
+	}
+	return result
+}
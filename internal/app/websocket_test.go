@@ -0,0 +1,148 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"reflect"
+	"testing"
+)
+
+// return a message with the given operation code and payload masked as
+// clients send them, where the length of the payload is given with the given
+// number of bytes of the extended length: 0, 2 or 8
+func maskedMessage(opcode byte, payload []byte, extended int) []byte {
+
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	message := []byte{0x80 | opcode}
+	switch extended {
+	case 0:
+		message = append(message, 0x80|byte(len(payload)))
+	case 2:
+		message = binary.BigEndian.AppendUint16(append(message, 0x80|126), uint16(len(payload)))
+	default:
+		message = binary.BigEndian.AppendUint64(append(message, 0x80|127), uint64(len(payload)))
+	}
+	message = append(message, mask...)
+	for i, b := range payload {
+		message = append(message, b^mask[i%4])
+	}
+	return message
+}
+
+func TestReadWebSocket(t *testing.T) {
+
+	s := &server{app: New()}
+	long := bytes.Repeat([]byte("conway"), 50)
+	tests := []struct {
+		name    string
+		message []byte
+		opcode  byte
+		payload []byte
+		fails   bool
+	}{
+		{"ping", maskedMessage(websocketPing, []byte("hello"), 0), websocketPing, []byte("hello"), false},
+		{"empty close", maskedMessage(websocketClose, nil, 0), websocketClose, []byte{}, false},
+		{"close with 16 bits length", maskedMessage(websocketClose, []byte{0x03, 0xe8}, 2), websocketClose, []byte{0x03, 0xe8}, false},
+		{"discarded text", maskedMessage(websocketText, long, 2), websocketText, nil, false},
+		{"discarded binary", maskedMessage(websocketBinary, long, 8), websocketBinary, nil, false},
+		{"discarded long ping", maskedMessage(websocketPing, long, 2), websocketPing, nil, false},
+		{"unmasked", []byte{0x80 | websocketPing, 0}, 0, nil, true},
+		{"truncated header", []byte{0x80 | websocketPing}, 0, nil, true},
+		{"truncated payload", maskedMessage(websocketPing, []byte("hello"), 0)[:8], 0, nil, true},
+	}
+	for _, test := range tests {
+		opcode, payload, err := s.readWebSocket(bufio.NewReader(bytes.NewReader(test.message)))
+		if test.fails {
+			if err == nil {
+				t.Errorf("readWebSocket of %v did not fail", test.name)
+			}
+			continue
+		}
+		if err != nil || opcode != test.opcode || !reflect.DeepEqual(payload, test.payload) {
+			t.Errorf("readWebSocket of %v = %#x, %q, %v, want %#x, %q", test.name, opcode, payload, err, test.opcode, test.payload)
+		}
+	}
+
+	// messages are read one after the other, even if they are discarded
+	var stream []byte
+	for _, test := range tests[:6] {
+		stream = append(stream, test.message...)
+	}
+	r := bufio.NewReader(bytes.NewReader(stream))
+	for _, test := range tests[:6] {
+		if opcode, _, err := s.readWebSocket(r); err != nil || opcode != test.opcode {
+			t.Errorf("readWebSocket of %v in a stream = %#x, %v, want %#x", test.name, opcode, err, test.opcode)
+		}
+	}
+}
+
+func TestWriteWebSocket(t *testing.T) {
+
+	for _, test := range []struct {
+		length int
+		header []byte
+	}{
+		{5, []byte{0x82, 5}},
+		{300, []byte{0x82, 126, 0x01, 0x2c}},
+		{70000, []byte{0x82, 127, 0, 0, 0, 0, 0, 0x01, 0x11, 0x70}},
+	} {
+		var buffer bytes.Buffer
+		payload := bytes.Repeat([]byte{0xab}, test.length)
+		if err := writeWebSocket(bufio.NewWriter(&buffer), websocketBinary, payload); err != nil {
+			t.Fatalf("writeWebSocket: %v", err)
+		}
+		want := append(append([]byte(nil), test.header...), payload...)
+		if !bytes.Equal(buffer.Bytes(), want) {
+			t.Errorf("writeWebSocket of %v bytes starts with % x, want % x", test.length, buffer.Bytes()[:len(test.header)], test.header)
+		}
+	}
+}
+
+func TestDiffCells(t *testing.T) {
+
+	points := func(coordinates ...int) []image.Point {
+		var result []image.Point
+		for i := 0; i < len(coordinates); i += 2 {
+			result = append(result, image.Point{X: coordinates[i], Y: coordinates[i+1]})
+		}
+		return result
+	}
+	for _, test := range []struct {
+		name              string
+		previous, current []image.Point
+		born, died        [][2]int
+	}{
+		{"empty", nil, nil, [][2]int{}, [][2]int{}},
+		{"first generation", nil, points(1, 0, 0, 1), [][2]int{{1, 0}, {0, 1}}, [][2]int{}},
+		{"extinction", points(1, 0, 0, 1), nil, [][2]int{}, [][2]int{{1, 0}, {0, 1}}},
+		{"still life", points(0, 0, 1, 0, 0, 1, 1, 1), points(0, 0, 1, 0, 0, 1, 1, 1), [][2]int{}, [][2]int{}},
+		{"blinker", points(1, 0, 1, 1, 1, 2), points(0, 1, 1, 1, 2, 1), [][2]int{{0, 1}, {2, 1}}, [][2]int{{1, 0}, {1, 2}}},
+		{"glider", points(1, 0, 2, 1, 0, 2, 1, 2, 2, 2), points(0, 1, 2, 1, 1, 2, 2, 2, 1, 3), [][2]int{{0, 1}, {1, 3}}, [][2]int{{1, 0}, {0, 2}}},
+	} {
+		born, died := diffCells(test.previous, test.current)
+		if !reflect.DeepEqual(born, test.born) || !reflect.DeepEqual(died, test.died) {
+			t.Errorf("diffCells of %v = %v, %v, want %v, %v", test.name, born, died, test.born, test.died)
+		}
+	}
+}
+
+func TestEncodeDelta(t *testing.T) {
+
+	delta := generationDelta{Generation: 3, Width: 10, Height: 20, Born: [][2]int{{1, 2}, {3, 4}}, Died: [][2]int{{5, 6}}}
+	var want []byte
+	for _, value := range []uint32{3, 10, 20, 2, 1, 1, 2, 3, 4, 5, 6} {
+		want = binary.LittleEndian.AppendUint32(want, value)
+	}
+	if got := encodeDelta(delta); !bytes.Equal(got, want) {
+		t.Errorf("encodeDelta = % x, want % x", got, want)
+	}
+
+	// deltas with no changes after the first one carry no dimensions
+	want = binary.LittleEndian.AppendUint32(make([]byte, 0, 20), 4)
+	want = append(want, make([]byte, 16)...)
+	if got := encodeDelta(generationDelta{Generation: 4, Born: [][2]int{}, Died: [][2]int{}}); !bytes.Equal(got, want) {
+		t.Errorf("encodeDelta of an empty delta = % x, want % x", got, want)
+	}
+}
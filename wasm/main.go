@@ -0,0 +1,205 @@
+//go:build js && wasm
+
+// Conway's Game in the browser
+//
+// This program is compiled to WebAssembly and it exports a small API to
+// JavaScript in the global object conway, so that the engine can power an
+// in-browser playground without any server:
+//
+//	conway.init({width: 200, height: 150, rule: "B3/S23", density: 0.3})
+//	conway.step(1)
+//	const frame = new ImageData(conway.frame(), conway.width(), conway.height())
+//
+// Functions that fail return an Error instead of throwing it
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/clinaresl/conway-game/conway"
+)
+
+// types
+// ----------------------------------------------------------------------------
+
+// playground
+//
+// a playground keeps the current generation of the game being played, which
+// is accessed through the functions given here
+type playground struct {
+	width, height int
+	nbgeneration  int
+	next          func()
+	rgba          func() []byte
+	population    func() int
+}
+
+// globals
+// ----------------------------------------------------------------------------
+
+// the game being played, which is nil until init is invoked
+var game *playground
+
+// boundary conditions by name
+var boundaries = map[string]conway.Boundary{
+	"dead":   conway.DeadBoundary,
+	"torus":  conway.TorusBoundary,
+	"mirror": conway.MirrorBoundary,
+	"klein":  conway.KleinBoundary,
+	"mobius": conway.MobiusBoundary,
+}
+
+// functions
+// ----------------------------------------------------------------------------
+
+// jsError
+//
+// return the given error as a JavaScript Error
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
+
+// configure
+//
+// return the configuration given in the JavaScript object options, whose
+// properties are all optional: width, height, generations (used by the colour
+// model), rule, boundary, model, seed, density and patterns, an array of
+// objects with a pattern and its location x and y
+func configure(options js.Value) (conway.Config, error) {
+
+	var config conway.Config
+	if options.Type() != js.TypeObject {
+		return config, nil
+	}
+	for name, value := range map[string]*int{"width": &config.Width, "height": &config.Height, "generations": &config.Generations} {
+		if property := options.Get(name); property.Type() == js.TypeNumber {
+			*value = property.Int()
+		}
+	}
+	for name, value := range map[string]*string{"rule": &config.Rule, "model": &config.Model} {
+		if property := options.Get(name); property.Type() == js.TypeString {
+			*value = property.String()
+		}
+	}
+	if property := options.Get("seed"); property.Type() == js.TypeNumber {
+		config.Seed = int64(property.Float())
+	}
+	if property := options.Get("density"); property.Type() == js.TypeNumber {
+		config.Density = property.Float()
+	}
+	if property := options.Get("boundary"); property.Type() == js.TypeString {
+		boundary, ok := boundaries[property.String()]
+		if !ok {
+			return config, fmt.Errorf("Unknown boundary condition '%v'", property.String())
+		}
+		config.Boundary = boundary
+	}
+	if property := options.Get("patterns"); property.Type() == js.TypeObject {
+		for i := 0; i < property.Length(); i++ {
+			placement := property.Index(i)
+			config.Patterns = append(config.Patterns, conway.Placement{
+				Pattern: placement.Get("pattern").String(),
+				X:       placement.Get("x").Int(),
+				Y:       placement.Get("y").Int()})
+		}
+	}
+	return config, nil
+}
+
+// initGame
+//
+// create a new game with the options given in the first argument and return
+// its dimensions as an object with the properties width and height
+func initGame(this js.Value, args []js.Value) any {
+
+	options := js.Undefined()
+	if len(args) > 0 {
+		options = args[0]
+	}
+	config, err := configure(options)
+	if err != nil {
+		return jsError(err)
+	}
+	current, err := config.Generation()
+	if err != nil {
+		return jsError(err)
+	}
+	width, height := current.Dimensions()
+	game = &playground{
+		width:  width,
+		height: height,
+		next: func() {
+			current = current.Next()
+		},
+		rgba: func() []byte {
+			return current.RGBA()
+		},
+		population: func() int {
+			return current.Population()
+		}}
+	return map[string]any{"width": game.width, "height": game.height}
+}
+
+// step
+//
+// advance the game as many generations as given in the first argument, one by
+// default, and return the index of the current generation
+func step(this js.Value, args []js.Value) any {
+
+	if game == nil {
+		return jsError(errors.New("The game has not been initialized"))
+	}
+	n := 1
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		n = args[0].Int()
+	}
+	for i := 0; i < n; i++ {
+		game.next()
+		game.nbgeneration++
+	}
+	return game.nbgeneration
+}
+
+// frame
+//
+// return the colours of all cells of the current generation as a
+// Uint8ClampedArray in RGBA with one pixel per cell, ready to be used as the
+// data of an ImageData
+func frame(this js.Value, args []js.Value) any {
+
+	if game == nil {
+		return jsError(errors.New("The game has not been initialized"))
+	}
+	pixels := game.rgba()
+	result := js.Global().Get("Uint8ClampedArray").New(len(pixels))
+	js.CopyBytesToJS(result, pixels)
+	return result
+}
+
+// main function
+//
+// register the API in the global object conway and wait forever, so that it
+// can be used as long as the page is open
+func main() {
+
+	getter := func(value func() int) js.Func {
+		return js.FuncOf(func(this js.Value, args []js.Value) any {
+			if game == nil {
+				return jsError(errors.New("The game has not been initialized"))
+			}
+			return value()
+		})
+	}
+	js.Global().Set("conway", js.ValueOf(map[string]any{
+		"init":       js.FuncOf(initGame),
+		"step":       js.FuncOf(step),
+		"frame":      js.FuncOf(frame),
+		"width":      getter(func() int { return game.width }),
+		"height":     getter(func() int { return game.height }),
+		"generation": getter(func() int { return game.nbgeneration }),
+		"population": getter(func() int { return game.population() }),
+	}))
+	select {}
+}